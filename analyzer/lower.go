@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/parser/token"
+	"github.com/CanPacis/lcl/types"
+)
+
+var ops = map[token.Kind]ir.Op{
+	token.PLUS:          ir.Add,
+	token.MINUS:         ir.Sub,
+	token.STAR:          ir.Mul,
+	token.FORWARD_SLASH: ir.Div,
+	token.PERCENT:       ir.Mod,
+	token.CARET:         ir.Pow,
+	token.EQUALS:        ir.Eq,
+	token.NOT_EQUALS:    ir.Neq,
+	token.LT:            ir.Lt,
+	token.LTE:           ir.Lte,
+	token.GT:            ir.Gt,
+	token.GTE:           ir.Gte,
+	token.AND:           ir.And,
+	token.OR:            ir.Or,
+}
+
+// Lower converts an expression to its ir form. The expression must have been
+// resolved by the scope of the package or one of its sub scopes since the
+// types of the ir are the ones the scope recorded.
+func (s *Semantics) Lower(expr ast.Expr) ir.Expr {
+	return s.lower(expr)
+}
+
+func (s *Semantics) lower(expr ast.Expr) ir.Expr {
+	typ := s.pkg.Scope.Types()[expr]
+
+	switch expr := expr.(type) {
+	case *ast.StringLitExpr:
+		return &ir.Literal{Value: expr.Value, Typ: typ}
+	case *ast.NumberLitExpr:
+		return &ir.Literal{Value: expr.Value, Typ: typ}
+	case *ast.IdentExpr:
+		if typ == types.Bool && (expr.Value == "true" || expr.Value == "false") {
+			return &ir.Literal{Value: expr.Value == "true", Typ: typ}
+		}
+		return &ir.Ref{Name: expr.Value, Typ: typ}
+	case *ast.ImportExpr:
+		return &ir.Import{Package: expr.Left.Value, Name: expr.Right.Value, Typ: typ}
+	case *ast.BinaryExpr:
+		left, right := operands(s.lower(expr.Left), s.lower(expr.Right))
+		return &ir.Binary{Op: ops[expr.Operator.Kind], Left: left, Right: right, Typ: typ}
+	case *ast.ArithmeticExpr:
+		left, right := operands(s.lower(expr.Left), s.lower(expr.Right))
+		return &ir.Binary{Op: ops[expr.Operator.Kind], Left: left, Right: right, Typ: typ}
+	case *ast.UnaryExpr:
+		op := ir.Neg
+		if expr.Operator.Kind == token.EXCLAMATION_MARK {
+			op = ir.Not
+		}
+		return &ir.Unary{Op: op, X: s.lower(expr.Expr), Typ: typ}
+	case *ast.TernaryExpr:
+		return &ir.Ternary{
+			Cond: s.lower(expr.Predicate),
+			Then: s.lower(expr.Left),
+			Else: s.lower(expr.Right),
+			Typ:  typ,
+		}
+	case *ast.CoalesceExpr:
+		return &ir.Coalesce{Left: s.lower(expr.Left), Right: s.lower(expr.Right), Typ: typ}
+	case *ast.CallExpr:
+		if _, ok := s.pkg.Scope.Types()[expr.Fn].(*types.Conversion); ok && len(expr.Args) == 1 {
+			return &ir.Convert{X: s.lower(expr.Args[0]), Typ: typ}
+		}
+
+		args := []ir.Expr{}
+		for _, arg := range expr.Args {
+			args = append(args, s.lower(arg))
+		}
+		return &ir.Call{Fn: s.lower(expr.Fn), Args: args, Typ: typ}
+	case *ast.MemberExpr:
+		return &ir.Member{X: s.lower(expr.Left), Name: expr.Right.Value, Typ: typ}
+	case *ast.IndexExpr:
+		return &ir.Index{X: s.lower(expr.Host), Index: s.lower(expr.Index), Typ: typ}
+	case *ast.GroupExpr:
+		return s.lower(expr.Expr)
+	case *ast.TemplateLitExpr:
+		template := &ir.Template{}
+		template.Typ, _ = typ.(*types.Template)
+		s.segments(template, expr)
+		return template
+	default:
+		return nil
+	}
+}
+
+// segments appends the parts of a template literal to template, nested
+// templates are inlined and adjacent text is merged
+func (s *Semantics) segments(template *ir.Template, expr *ast.TemplateLitExpr) {
+	for _, part := range expr.Value {
+		switch part := part.(type) {
+		case *ast.StringLitExpr:
+			if len(part.Value) == 0 {
+				continue
+			}
+
+			n := len(template.Segments)
+			if n > 0 && template.Segments[n-1].Value == nil {
+				template.Segments[n-1].Text += part.Value
+			} else {
+				template.Segments = append(template.Segments, ir.Segment{Text: part.Value})
+			}
+		case *ast.TemplateLitExpr:
+			s.segments(template, part)
+		default:
+			template.Segments = append(template.Segments, ir.Segment{Value: s.lower(part)})
+		}
+	}
+}
+
+// operands converts one of the numeric operands of a binary expression to
+// the type of the other if their types differ, like the branches of a
+// ternary. Untyped constants are left to take the type of the other operand,
+// otherwise the right operand takes the type of the left one, which is the
+// type of the result, unless only the right one is typed.
+func operands(left, right ir.Expr) (ir.Expr, ir.Expr) {
+	l, r := left.Type(), right.Type()
+	if !numeric(l) || !numeric(r) || types.Identical(l, r) {
+		return left, right
+	}
+
+	switch {
+	case constant(l), constant(r), untyped(l) && untyped(r):
+	case untyped(l):
+		left = &ir.Convert{X: left, Typ: r, Implicit: true}
+	default:
+		right = &ir.Convert{X: right, Typ: l, Implicit: true}
+	}
+	return left, right
+}
+
+func numeric(t types.Type) bool {
+	return t != nil && types.IsNumeric(t)
+}
+
+// constant reports whether a type is the one of an untyped constant
+func constant(t types.Type) bool {
+	u, ok := t.(*types.Untyped)
+	return ok && u.IsConstant()
+}
+
+func untyped(t types.Type) bool {
+	_, ok := t.(*types.Untyped)
+	return ok
+}
@@ -1,18 +1,28 @@
 package analyzer
 
 import (
-	"github.com/CanPacis/go-i18n/errs"
-	pkg "github.com/CanPacis/go-i18n/package"
-	"github.com/CanPacis/go-i18n/parser"
-	"github.com/CanPacis/go-i18n/parser/ast"
-	"github.com/CanPacis/go-i18n/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/types"
 	"golang.org/x/text/language"
 )
 
 type Semantics struct {
-	file    string
-	ast     *ast.File
-	checker *Checker
+	file string
+	ast  *ast.File
+	pkg  *pkg.Package
+
+	targets    []ir.Locale
+	targetDefs map[string]*ast.DeclTarget
+	// exports holds the top level definitions by their exported names
+	exports map[string]ast.Node
 
 	errors []error
 }
@@ -27,20 +37,48 @@ func (s Semantics) Errors() error {
 	if len(s.errors) == 0 {
 		return nil
 	}
-	return errs.NewSemanticError(s.errors, s.file)
+	return errs.NewErrorSet(s.file, s.errors)
 }
 
 func (s Semantics) ScanName() string {
 	return s.ast.Decl.Name.Value
 }
 
-func (s *Semantics) ScanTags() map[string]language.Tag {
+func (s *Semantics) ScanTargets() []ir.Locale {
 	for _, node := range s.ast.Decl.Targets {
-		if err := s.checker.RegisterTarget(node); err != nil {
-			s.error(err)
+		if original, exists := s.targetDefs[node.Name.Value]; exists {
+			s.error(&errs.ReferenceError{
+				Err:      errs.ErrDuplicateDefinition,
+				Node:     node,
+				Original: original,
+				Value:    node.Name.Value,
+			})
+			continue
 		}
+
+		name := node.Name.Value
+		if node.Tag != nil {
+			name = node.Tag.Value
+		}
+
+		tag, err := language.Parse(name)
+		if err != nil {
+			s.error(&errs.ReferenceError{
+				Err:   errs.ErrInvalidTargetTag,
+				Node:  node,
+				Value: name,
+			})
+			continue
+		}
+
+		s.targetDefs[node.Name.Value] = node
+		s.targets = append(s.targets, ir.Locale{
+			Name: node.Name.Value,
+			Tag:  tag,
+		})
 	}
-	return s.checker.tags
+
+	return s.targets
 }
 
 func (s *Semantics) ScanImports() []*pkg.Package {
@@ -48,6 +86,12 @@ func (s *Semantics) ScanImports() []*pkg.Package {
 
 	for _, node := range s.ast.Imports {
 		for _, ident := range node.List {
+			if err := s.pkg.Scope.RegisterImport(ident); err != nil {
+				s.error(err)
+				continue
+			}
+			s.error(s.pkg.TypEnv.RegisterImport(ident))
+
 			// TODO: resolve the import
 			imports = append(imports, pkg.New(ident.Value))
 		}
@@ -56,188 +100,293 @@ func (s *Semantics) ScanImports() []*pkg.Package {
 	return imports
 }
 
-func (s *Semantics) ScanTypes() *types.Environment {
-	defs := map[string]*ast.TypeDefStmt{}
+func (s *Semantics) ScanTypes() []ir.TypeDef {
+	defs := []*ast.TypeDefStmt{}
 
 	for _, node := range s.ast.Stmts {
 		switch node := node.(type) {
 		case *ast.TypeDefStmt:
-			if err := s.checker.RegisterType(node); err != nil {
+			if err := s.pkg.TypEnv.RegisterType(node); err != nil {
 				s.error(err)
 			} else {
-				defs[node.Name.Value] = node
+				s.export(s.exports, node.Name, node)
+				defs = append(defs, node)
 			}
 		}
 	}
 
-	for name, def := range defs {
-		typ, err := s.checker.ResolveType(def.Type)
-		if err != nil {
-			s.error(err)
-		}
-		s.checker.env.Define(name, typ)
+	typeDefs := []ir.TypeDef{}
+	for _, def := range defs {
+		typ, err := s.pkg.TypEnv.ResolveType(def.Type)
+		s.error(err)
+
+		typ = types.NewNamed(s.pkg.Name, def.Name.Value, typ)
+		s.pkg.TypEnv.Define(def.Name.Value, typ)
+		typeDefs = append(typeDefs, ir.TypeDef{
+			Definition: ir.NewDefinition(def.Name.Value, isExported(def.Name.Value)),
+			Type:       typ,
+		})
 	}
 
-	return s.checker.env
+	return typeDefs
 }
 
-func (s *Semantics) ScanFns() *pkg.Scope {
-	defs := map[string]*ast.FnDefStmt{}
+func (s *Semantics) ScanFns() []ir.FnDef {
+	defs := []*ast.FnDefStmt{}
 
 	for _, node := range s.ast.Stmts {
 		switch node := node.(type) {
 		case *ast.FnDefStmt:
-			if err := s.checker.RegisterFn(node); err != nil {
+			if err := s.pkg.Scope.RegisterFn(node); err != nil {
 				s.error(err)
 			} else {
-				defs[node.Name.Value] = node
+				s.export(s.exports, node.Name, node)
+				defs = append(defs, node)
 			}
 		}
 	}
 
-	for name, def := range defs {
+	fnDefs := []ir.FnDef{}
+	for _, def := range defs {
+		scope := pkg.NewSubScope(s.pkg.Scope)
 		in := []types.Type{}
-		s.checker.scope.Push(pkg.NewScope())
+		params := []ir.Param{}
 
 		for _, param := range def.Params {
-			typ, err := s.checker.ResolveType(param.Type)
-			if err != nil {
-				s.error(err)
-			}
+			typ, err := s.pkg.TypEnv.ResolveType(param.Type)
+			s.error(err)
 			in = append(in, typ)
-			s.checker.scope.Last().Define(param.Name.Value, typ)
+			params = append(params, ir.Param{Name: param.Name.Value, Type: typ})
+			scope.Define(param.Name.Value, typ)
 		}
 
-		typ, err := s.checker.ResolveExpr(def.Body)
-		if err != nil {
-			s.error(err)
-		}
-		s.checker.scope.Pop()
-		s.checker.scope.Last().Define(name, &types.Fn{
+		out, err := scope.ResolveExpr(def.Body)
+		s.error(err)
+
+		fn := &types.Fn{
 			In:  in,
-			Out: typ,
+			Out: types.Default(out),
+		}
+		s.pkg.Scope.Define(def.Name.Value, fn)
+		fnDefs = append(fnDefs, ir.FnDef{
+			Definition: ir.NewDefinition(def.Name.Value, isExported(def.Name.Value)),
+			Params:     params,
+			Type:       fn,
+			Body:       s.lower(def.Body),
 		})
 	}
-	return s.checker.scope.Last()
+
+	return fnDefs
 }
 
-func (s *Semantics) extractKeyEntry(entry *ast.KeyEntry) *Key {
-	key := &Key{
-		Name:   entry.Name.Value,
-		Fields: make(map[language.Tag]string),
-	}
+func (s *Semantics) ScanSections() []*ir.Section {
+	sections := []*ir.Section{}
+	defs := map[string]ast.Node{}
 
-	for _, field := range entry.Fields {
-		tag, err := s.checker.LookupTag(field.Tag)
-		if err != nil {
-			s.error(err)
+	for _, node := range s.ast.Stmts {
+		switch node := node.(type) {
+		case *ast.SectionStmt:
+			if s.define(defs, node.Name, node) {
+				s.export(s.exports, node.Name, node)
+				sections = append(sections, s.extractSection(node))
+			}
 		}
-
-		// TODO: validate the expression
-		// key.Fields[tag] = field.Value
-		key.Fields[tag] = ""
 	}
 
-	for name, tag := range s.checker.tags {
-		_, ok := key.Fields[tag]
-		if !ok {
-			s.error(&errs.TargetError{
-				Target:  name,
-				Tag:     tag,
-				Missing: true,
-				Node:    entry,
-			})
-		}
+	return sections
+}
+
+func (s *Semantics) Scan() (*ir.IR, error) {
+	out := &ir.IR{
+		Name:    s.ScanName(),
+		Locales: s.ScanTargets(),
 	}
 
-	return key
+	s.ScanImports()
+	out.TypeDefs = s.ScanTypes()
+	out.FnDefs = s.ScanFns()
+	out.Sections = s.ScanSections()
+
+	return out, s.Errors()
 }
 
-func (s *Semantics) extractTemplateEntry(entry *ast.TemplateEntry) *Template {
-	params := []types.Type{}
-	for _, param := range entry.Params {
-		typ, err := s.checker.ResolveType(param.Name)
-		if err != nil {
-			s.error(err)
+func (s Semantics) lookupTarget(name string) (ir.Locale, bool) {
+	for _, target := range s.targets {
+		if target.Name == name {
+			return target, true
 		}
-		params = append(params, typ)
 	}
+	return ir.Locale{}, false
+}
 
-	template := &Template{
-		Name:   entry.Name.Value,
-		Params: params,
-		Fields: make(map[language.Tag]int),
+func (s *Semantics) define(defs map[string]ast.Node, name *ast.IdentExpr, node ast.Node) bool {
+	if original, exists := defs[name.Value]; exists {
+		s.error(&errs.ReferenceError{
+			Err:      errs.ErrDuplicateDefinition,
+			Node:     node,
+			Original: original,
+			Value:    name.Value,
+		})
+		return false
 	}
 
-	for _, field := range entry.Fields {
-		tag, err := s.checker.LookupTag(field.Tag)
-		if err != nil {
-			s.error(err)
-		}
+	defs[name.Value] = node
+	return true
+}
 
-		// TODO: validate the expression, and figure out what to do with field value
-		template.Fields[tag] = 0
+// export reports a definition whose name is the same as the one of another
+// definition once both are exported, generated code capitalizes names which
+// only differ in their first letter otherwise
+func (s *Semantics) export(defs map[string]ast.Node, name *ast.IdentExpr, node ast.Node) {
+	r, size := utf8.DecodeRuneInString(name.Value)
+	exported := string(unicode.ToUpper(r)) + name.Value[size:]
+
+	if original, exists := defs[exported]; exists {
+		s.error(&errs.ReferenceError{
+			Err:      errs.ErrNameCollision,
+			Node:     node,
+			Original: original,
+			Value:    name.Value,
+		})
+		return
 	}
+	defs[exported] = node
+}
+
+func (s *Semantics) extractFields(entry ast.Node, fields []*ast.Field, scope *pkg.Scope) map[language.Tag]ir.Expr {
+	values := make(map[language.Tag]ir.Expr)
+	defs := map[string]ast.Node{}
 
-	for name, tag := range s.checker.tags {
-		_, ok := template.Fields[tag]
+	for _, field := range fields {
+		target, ok := s.lookupTarget(field.Tag.Value)
 		if !ok {
-			s.error(&errs.TargetError{
-				Target:  name,
-				Tag:     tag,
-				Missing: true,
-				Node:    entry,
+			s.error(&errs.ReferenceError{
+				Err:   errs.ErrUndeclaredTargetTag,
+				Node:  field.Tag,
+				Value: field.Tag.Value,
 			})
+			continue
+		}
+
+		if !s.define(defs, field.Tag, field) {
+			continue
 		}
+
+		_, err := scope.ResolveExpr(field.Value)
+		s.error(err)
+
+		values[target.Tag] = s.lower(field.Value)
 	}
 
-	return template
+	for _, target := range s.targets {
+		if _, ok := values[target.Tag]; !ok {
+			s.error(&errs.ReferenceError{
+				Err:   errs.ErrMissingTargetField,
+				Node:  entry,
+				Value: target.Name,
+			})
+		}
+	}
+
+	return values
 }
 
-func (s *Semantics) extractSection(stmt *ast.SectionStmt) *Section {
-	section := &Section{
-		Name:      stmt.Name.Value,
-		Keys:      make(map[string]*Key),
-		Templates: make(map[string]*Template),
+func (s *Semantics) extractKeyEntry(entry *ast.KeyEntry) *ir.Message {
+	return &ir.Message{
+		Definition: ir.NewDefinition(entry.Name.Value, true),
+		Type:       types.NewTemplate([]types.Type{}),
+		Values:     s.extractFields(entry, entry.Fields, s.pkg.Scope),
+		Comment:    comment(entry.Comments),
+		Pos:        s.pos(entry),
 	}
+}
+
+func (s *Semantics) extractTemplateEntry(entry *ast.TemplateEntry) *ir.Message {
+	scope := pkg.NewSubScope(s.pkg.Scope)
+	in := []types.Type{}
+	params := []ir.Param{}
+	defs := map[string]ast.Node{}
+
+	for _, param := range entry.Params {
+		typ, err := s.pkg.TypEnv.ResolveType(param.Type)
+		s.error(err)
+
+		if s.define(defs, param.Name, param) {
+			scope.Define(param.Name.Value, typ)
+		}
+		in = append(in, typ)
+		params = append(params, ir.Param{Name: param.Name.Value, Type: typ})
+	}
+
+	return &ir.Message{
+		Definition: ir.NewDefinition(entry.Name.Value, true),
+		IsTemplate: true,
+		Params:     params,
+		Type:       types.NewTemplate(in),
+		Values:     s.extractFields(entry, entry.Fields, scope),
+		Comment:    comment(entry.Comments),
+		Pos:        s.pos(entry),
+	}
+}
+
+func (s *Semantics) extractSection(stmt *ast.SectionStmt) *ir.Section {
+	section := &ir.Section{
+		Definition: ir.NewDefinition(stmt.Name.Value, true),
+		Comment:    comment(stmt.Comments()),
+		Pos:        s.pos(stmt),
+	}
+	defs := map[string]ast.Node{}
+	exports := map[string]ast.Node{}
 
 	for _, entry := range stmt.Body {
 		switch entry := entry.(type) {
 		case *ast.KeyEntry:
-			key := s.extractKeyEntry(entry)
-			section.Keys[entry.Name.Value] = key
+			if s.define(defs, entry.Name, entry) {
+				s.export(exports, entry.Name, entry)
+				section.Messages = append(section.Messages, s.extractKeyEntry(entry))
+			}
 		case *ast.TemplateEntry:
-			template := s.extractTemplateEntry(entry)
-			section.Templates[entry.Name.Value] = template
+			if s.define(defs, entry.Name, entry) {
+				s.export(exports, entry.Name, entry)
+				section.Messages = append(section.Messages, s.extractTemplateEntry(entry))
+			}
 		case *ast.SectionStmt:
-			section.Sections = append(section.Sections, s.extractSection(entry))
+			if s.define(defs, entry.Name, entry) {
+				s.export(exports, entry.Name, entry)
+				section.Sections = append(section.Sections, s.extractSection(entry))
+			}
 		}
 	}
 
 	return section
 }
 
-func (s *Semantics) ScanSections() []*Section {
-	sections := []*Section{}
+// pos returns the position a node starts at in the file being checked
+func (s *Semantics) pos(node ast.Node) ir.Pos {
+	start := node.Range().Start
+	return ir.Pos{File: s.file, Line: start.Line, Column: start.Column}
+}
 
-	for _, node := range s.ast.Stmts {
-		switch node := node.(type) {
-		case *ast.SectionStmt:
-			sections = append(sections, s.extractSection(node))
-		}
+// comment joins the lines of comments
+func comment(comments []*ast.CommentStmt) string {
+	lines := []string{}
+	for _, comment := range comments {
+		lines = append(lines, strings.TrimSpace(comment.Literal))
 	}
-
-	return sections
+	return strings.Join(lines, "\n")
 }
 
-func (s *Semantics) Scan() error {
-	return s.Errors()
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
 }
 
-func New(file *parser.File, ast *ast.File, checker *Checker) *Semantics {
+func New(file *parser.File, tree *ast.File, p *pkg.Package) *Semantics {
 	return &Semantics{
-		file:    file.Name,
-		ast:     ast,
-		checker: checker,
+		file:       file.Name,
+		ast:        tree,
+		pkg:        p,
+		targetDefs: make(map[string]*ast.DeclTarget),
+		exports:    make(map[string]ast.Node),
 	}
 }
@@ -2,15 +2,15 @@ package analyzer_test
 
 import (
 	"embed"
-	"fmt"
 	"testing"
 
-	"github.com/CanPacis/go-i18n/analyzer"
-	"github.com/CanPacis/go-i18n/errs"
-	pkg "github.com/CanPacis/go-i18n/package"
-	"github.com/CanPacis/go-i18n/parser"
-	"github.com/CanPacis/go-i18n/test"
-	"github.com/CanPacis/go-i18n/types"
+	"github.com/CanPacis/lcl/analyzer"
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/test"
+	"github.com/CanPacis/lcl/types"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/text/language"
 )
@@ -18,34 +18,45 @@ import (
 //go:embed test/*.lcl
 var tests embed.FS
 
-func semantics(name string, scope *pkg.Scope, env *types.Environment) *analyzer.Semantics {
+func semantics(name string, p *pkg.Package) *analyzer.Semantics {
 	source, _ := tests.Open(name)
 	defer source.Close()
 	file := parser.NewFile(name, source)
 	ast := test.MustParse(test.WithFile(file))
-	if scope == nil {
-		scope = pkg.NewScope()
+	if p == nil {
+		p = pkg.New(ast.Decl.Name.Value)
 	}
-	if env == nil {
-		env = types.NewEnvironment()
+	return analyzer.New(file, ast, p)
+}
+
+func reasons(err error) []error {
+	set, ok := err.(*errs.ErrorSet)
+	if !ok {
+		return nil
 	}
-	return analyzer.New(file, ast, analyzer.NewChecker(scope, env))
+	return set.Errors
 }
 
 func TestDeclare(t *testing.T) {
 	assert := assert.New(t)
-	s := semantics("test/declare.lcl", nil, nil)
+	s := semantics("test/declare.lcl", nil)
 
 	assert.Equal("i18n", s.ScanName())
-	targets := s.ScanTags()
+	targets := s.ScanTargets()
 
 	assert.Equal(4, len(targets))
-	assert.Equal(language.English, targets["en"])
-	assert.Equal(language.French, targets["fr"])
-	assert.Equal(language.German, targets["de"])
-	assert.Equal(language.English, targets["en_au"].Parent().Parent())
-	assert.ErrorIs(s.Errors(), &errs.ResolveError{})
-	assert.ErrorContains(s.Errors(), "invalid")
+	assert.Equal("en", targets[0].Name)
+	assert.Equal(language.English, targets[0].Tag)
+	assert.Equal(language.French, targets[1].Tag)
+	assert.Equal(language.German, targets[2].Tag)
+	assert.Equal("en_au", targets[3].Name)
+	assert.Equal(language.English, targets[3].Tag.Parent().Parent())
+
+	errors := reasons(s.Errors())
+	assert.Equal(2, len(errors))
+	assert.ErrorIs(errors[0], errs.ErrInvalidTargetTag)
+	assert.ErrorContains(errors[0], "invalid")
+	assert.ErrorIs(errors[1], errs.ErrDuplicateDefinition)
 
 	imports := s.ScanImports()
 
@@ -57,37 +68,44 @@ func TestDeclare(t *testing.T) {
 
 func TestTypes(t *testing.T) {
 	assert := assert.New(t)
-	scope := pkg.NewScope()
-	env := types.NewEnvironment()
+	p := pkg.New("i18n")
 
 	time := types.New("time", types.Int)
 
-	env.Define("time", time)
-	scope.Define("year", &types.Fn{
+	p.TypEnv.Define("time", time)
+	p.Scope.Define("year", &types.Fn{
 		In:  []types.Type{time},
 		Out: types.Int,
 	})
-	scope.Define("itoa", &types.Fn{
+	p.Scope.Define("itoa", &types.Fn{
 		In:  []types.Type{types.Int},
 		Out: types.String,
 	})
 
-	s := semantics("test/types.lcl", scope, env)
+	s := semantics("test/types.lcl", p)
 
-	s.ScanTypes()
-	s.ScanFns()
+	typeDefs := s.ScanTypes()
+	fnDefs := s.ScanFns()
+
+	errors := reasons(s.Errors())
 
-	err := s.Errors().(*errs.SemanticError)
+	// Fns are exported like the types of the same name
+	assert.Equal(8, len(errors))
+	assert.ErrorIs(errors[0], errs.ErrDuplicateDefinition)
+	assert.ErrorIs(errors[1], errs.ErrUnresolvedTypeReference)
+	assert.ErrorIs(errors[2], errs.ErrNameCollision)
+	assert.ErrorIs(errors[3], errs.ErrDuplicateDefinition)
+	assert.ErrorIs(errors[4], errs.ErrNameCollision)
+	assert.ErrorIs(errors[5], errs.ErrUnresolvedConstReference)
+	assert.ErrorIs(errors[6], errs.ErrInvalidType)
+	assert.ErrorIs(errors[7], errs.ErrInvalidType)
 
-	assert.Equal(6, len(err.Reasons))
-	assert.ErrorIs(err.Reasons[0], &errs.DuplicateError{})
-	assert.ErrorIs(err.Reasons[1], &errs.ResolveError{})
-	assert.ErrorIs(err.Reasons[2], &errs.DuplicateError{})
-	assert.ErrorIs(err.Reasons[3], &errs.ResolveError{})
-	assert.ErrorIs(err.Reasons[4], &errs.TypeError{})
-	assert.ErrorIs(err.Reasons[5], &errs.TypeError{})
+	assert.Equal(2, len(typeDefs))
+	assert.Equal("Duplicate", typeDefs[0].Type.String())
+	assert.True(typeDefs[0].Exported)
+	assert.Equal(6, len(fnDefs))
 
-	exports := scope.Exports()
+	exports := p.Scope.Exports()
 
 	fn := &types.Fn{}
 	assert.IsType(fn, exports["Fn1"])
@@ -95,32 +113,77 @@ func TestTypes(t *testing.T) {
 
 	fn1 := exports["Fn1"].(*types.Fn)
 	assert.ElementsMatch(fn1.In, []types.Type{time})
-	assert.IsType(fn1.Out, types.Int)
+	assert.Equal(types.Int, fn1.Out)
 
 	fn2 := exports["Fn2"].(*types.Fn)
 	assert.ElementsMatch(fn2.In, []types.Type{time})
-	assert.IsType(fn2.Out, types.String)
+	assert.Equal(types.String, fn2.Out)
 }
 
 func TestImports(t *testing.T) {
 	assert := assert.New(t)
-	s := semantics("test/imports.lcl", nil, nil)
+	s := semantics("test/imports.lcl", nil)
 
-	s.ScanTags()
-	s.ScanImports()
+	s.ScanTargets()
+	imports := s.ScanImports()
 	s.ScanTypes()
 	s.ScanFns()
 
-	fmt.Println(s.Errors(), assert)
+	assert.Equal(1, len(imports))
+	errors := reasons(s.Errors())
+	assert.Equal(1, len(errors))
+	assert.ErrorIs(errors[0], errs.ErrUnresolvedImportReference)
 }
 
 func TestSections(t *testing.T) {
 	assert := assert.New(t)
-	s := semantics("test/sections.lcl", nil, nil)
+	s := semantics("test/sections.lcl", nil)
+
+	out, err := s.Scan()
+
+	errors := reasons(err)
+	assert.Equal(5, len(errors))
+	assert.ErrorIs(errors[0], errs.ErrUnresolvedConstReference)
+	assert.ErrorIs(errors[1], errs.ErrDuplicateDefinition)
+	assert.ErrorIs(errors[2], errs.ErrUndeclaredTargetTag)
+	assert.ErrorIs(errors[3], errs.ErrDuplicateDefinition)
+	assert.ErrorIs(errors[4], errs.ErrMissingTargetField)
+	assert.ErrorContains(errors[4], "tr")
+
+	assert.Equal("i18n", out.Name)
+	assert.Equal(1, len(out.Sections))
+
+	section := out.Sections[0]
+	assert.Equal("S", section.Name)
+	assert.Equal("the messages", section.Comment)
+	assert.Equal(ir.Pos{File: "test/sections.lcl", Line: 4, Column: 1}, section.Pos)
+	assert.Equal(3, len(section.Messages))
+	assert.Equal(1, len(section.Sections))
+
+	key := section.Messages[0]
+	assert.Equal("K", key.Name)
+	assert.False(key.IsTemplate)
+	assert.Equal(2, len(key.Values))
+	assert.Contains(key.Values, language.Turkish)
+	assert.Equal("a key\nof the section", key.Comment)
+	assert.Equal(ir.Pos{File: "test/sections.lcl", Line: 7, Column: 3}, key.Pos)
+
+	template := section.Messages[1]
+	assert.Equal("T1", template.Name)
+	assert.True(template.IsTemplate)
+	assert.Equal(types.NewTemplate([]types.Type{types.String}), template.Type)
+}
 
-	s.ScanTags()
-	s.ScanTypes()
-	s.ScanFns()
-	s.ScanSections()
-	fmt.Println(s.Errors(), assert)
+func TestNames(t *testing.T) {
+	assert := assert.New(t)
+	s := semantics("test/names.lcl", nil)
+
+	_, err := s.Scan()
+
+	errors := reasons(err)
+	assert.Equal(2, len(errors))
+	assert.ErrorIs(errors[0], errs.ErrNameCollision)
+	assert.ErrorContains(errors[0], "user is exported like the name defined here 3:1")
+	assert.ErrorIs(errors[1], errs.ErrNameCollision)
+	assert.ErrorContains(errors[1], "Title is exported like the name defined here 6:3")
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	gogen "github.com/CanPacis/lcl/gen/go"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser"
+)
+
+// pseudoLocale is the name of the locale a pseudo build adds
+const pseudoLocale = "pseudo"
+
+// ErrStale is returned by a checked build if generated code does not match
+// its sources
+var ErrStale = errors.New("generated code is out of date, run lcl build")
+
+func build(args []string) error {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	check := flags.Bool("check", false, "fail if generated code is out of date instead of writing it")
+	force := flags.Bool("force", false, "generate code even if its sources are unchanged")
+	pseudo := flags.Bool("pseudo", false, "add the pseudo locale "+ir.PseudoTag.String()+" derived from the source locale")
+	flags.Parse(args)
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	inputs, err := sources(paths)
+	if err != nil {
+		return err
+	}
+
+	b := &builder{check: *check, force: *force, pseudo: *pseudo, out: os.Stderr}
+	for _, input := range inputs {
+		if err := b.build(input); err != nil {
+			return err
+		}
+	}
+
+	if b.stale > 0 {
+		return ErrStale
+	}
+	return nil
+}
+
+// sources returns the catalogs at paths, a directory stands for the catalogs
+// it directly contains
+func sources(paths []string) ([]string, error) {
+	inputs := []string{}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			inputs = append(inputs, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && filepath.Ext(entry.Name()) == ".lcl" {
+				inputs = append(inputs, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+
+	return inputs, nil
+}
+
+// output returns the path of the go code generated for a catalog
+func output(input string) string {
+	return input + ".go"
+}
+
+type builder struct {
+	check bool
+	force bool
+	// pseudo adds the pseudo locale to every catalog
+	pseudo bool
+	// out receives the names of stale files in check mode
+	out   io.Writer
+	stale int
+}
+
+// build generates the code of a catalog. The hash of the catalog is recorded
+// in the generated code, so the catalog is not even parsed when the hash of
+// the existing code matches.
+func (b *builder) build(input string) error {
+	src, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+
+	options := []string{}
+	if b.pseudo {
+		options = append(options, "pseudo")
+	}
+	hash := sourceHash(src, options...)
+	existing, err := os.ReadFile(output(input))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if existing != nil && !isGenerated(existing) {
+		return fmt.Errorf("%s was not generated by lcl, refusing to replace it", output(input))
+	}
+
+	if !b.check && !b.force && existing != nil && gogen.Hash(existing) == hash {
+		return nil
+	}
+
+	c, err := check(parser.NewFile(input, bytes.NewReader(src)))
+	if err != nil {
+		return err
+	}
+
+	if b.pseudo {
+		for _, name := range []string{pseudoLocale, ir.PseudoTag.String()} {
+			if _, err := c.locale(name); err == nil {
+				return fmt.Errorf("%s already declares the pseudo locale '%s'", input, name)
+			}
+		}
+		ir.Pseudo(c.ir, pseudoLocale)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := gogen.New(c.ir, gogen.WithHash(hash)).Generate(buf); err != nil {
+		return err
+	}
+
+	if b.check {
+		// The code is compared as a whole so that hand edits are reported as
+		// well as changed sources
+		if !bytes.Equal(existing, buf.Bytes()) {
+			b.stale++
+			fmt.Fprintf(b.out, "%s is out of date with %s\n", output(input), input)
+		}
+		return nil
+	}
+
+	return os.WriteFile(output(input), buf.Bytes(), 0o644)
+}
+
+// sourceHash returns the hash of a catalog, it covers the version of lcl so
+// that released versions regenerate code built by older ones and the options
+// of the build so that code is regenerated once they change
+func sourceHash(src []byte, options ...string) string {
+	h := sha256.New()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		h.Write([]byte(info.Main.Version + "\n"))
+	}
+	for _, option := range options {
+		h.Write([]byte(option + "\n"))
+	}
+	h.Write(src)
+
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// isGenerated reports whether src is code generated by lcl
+func isGenerated(src []byte) bool {
+	return strings.HasPrefix(string(src), "// Code generated by lcl. DO NOT EDIT.")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gogen "github.com/CanPacis/lcl/gen/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "shop.lcl")
+	assert.NoError(os.WriteFile(input, []byte(catalogSource), 0o644))
+
+	run := func(check, force bool) (int, error) {
+		b := &builder{check: check, force: force, out: io.Discard}
+		err := b.build(input)
+		return b.stale, err
+	}
+	read := func() []byte {
+		src, _ := os.ReadFile(output(input))
+		return src
+	}
+
+	inputs, err := sources([]string{dir})
+	assert.NoError(err)
+	assert.Equal([]string{input}, inputs)
+
+	// Missing code is stale
+	stale, err := run(true, false)
+	assert.NoError(err)
+	assert.Equal(1, stale)
+
+	_, err = run(false, false)
+	assert.NoError(err)
+	generated := read()
+	assert.Equal(sourceHash([]byte(catalogSource)), gogen.Hash(generated))
+
+	stale, err = run(true, false)
+	assert.NoError(err)
+	assert.Equal(0, stale)
+
+	// Unchanged sources are skipped even if the code was edited, unless the
+	// build is forced
+	edited := append(generated, []byte("// edited\n")...)
+	assert.NoError(os.WriteFile(output(input), edited, 0o644))
+	_, err = run(false, false)
+	assert.NoError(err)
+	assert.Equal(edited, read())
+
+	stale, err = run(true, false)
+	assert.NoError(err)
+	assert.Equal(1, stale)
+
+	_, err = run(false, true)
+	assert.NoError(err)
+	assert.Equal(generated, read())
+
+	// Changed sources make the code stale
+	assert.NoError(os.WriteFile(input, []byte(catalogSource+"\n"), 0o644))
+	stale, err = run(true, false)
+	assert.NoError(err)
+	assert.Equal(1, stale)
+
+	_, err = run(false, false)
+	assert.NoError(err)
+	assert.NotEqual(generated, read())
+
+	// Pseudo builds add the pseudo locale and make the code stale
+	built := read()
+	b := &builder{pseudo: true, out: io.Discard}
+	assert.NoError(b.build(input))
+	assert.NotEqual(built, read())
+	assert.Contains(string(read()), `language.MustParse("en-XA")`)
+	assert.Contains(string(read()), `"[Çáŕţ~~]"`)
+	b = &builder{check: true, pseudo: true, out: io.Discard}
+	assert.NoError(b.build(input))
+	assert.Equal(0, b.stale)
+
+	stale, err = run(true, false)
+	assert.NoError(err)
+	assert.Equal(1, stale)
+
+	// Files lcl did not generate are not replaced
+	assert.NoError(os.WriteFile(output(input), []byte("package shop\n"), 0o644))
+	_, err = run(false, true)
+	assert.Error(err)
+	assert.Equal([]byte("package shop\n"), read())
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/CanPacis/lcl/ftl"
+	androidgen "github.com/CanPacis/lcl/gen/android"
+	arbgen "github.com/CanPacis/lcl/gen/arb"
+	iosgen "github.com/CanPacis/lcl/gen/ios"
+	jsongen "github.com/CanPacis/lcl/gen/json"
+	"github.com/CanPacis/lcl/po"
+	"github.com/CanPacis/lcl/xliff"
+)
+
+// exporters return the files of a format by their names, the files are
+// returned along with the error if only some messages could not be exported
+var exporters = map[string]func(c *catalog) (map[string][]byte, error){
+	"android": func(c *catalog) (map[string][]byte, error) {
+		return androidgen.New(c.ir).Files()
+	},
+	"arb": func(c *catalog) (map[string][]byte, error) {
+		return arbgen.New(c.ir).Files()
+	},
+	"ftl": func(c *catalog) (map[string][]byte, error) {
+		return ftl.New(c.ir).Files()
+	},
+	"ios": func(c *catalog) (map[string][]byte, error) {
+		return iosgen.New(c.ir).Files()
+	},
+	"json": func(c *catalog) (map[string][]byte, error) {
+		return jsongen.New(c.ir).Files()
+	},
+	"po": func(c *catalog) (map[string][]byte, error) {
+		return po.New(c.ir).Files()
+	},
+	"xliff": func(c *catalog) (map[string][]byte, error) {
+		return xliff.New(c.ir, xliff.WithOriginal(filepath.Base(c.file))).Files()
+	},
+	"xliff2": func(c *catalog) (map[string][]byte, error) {
+		return xliff.New(c.ir, xliff.WithOriginal(filepath.Base(c.file)), xliff.WithVersion(xliff.Version20)).Files()
+	},
+}
+
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "json", "format to export to, one of "+strings.Join(formats(), ", "))
+	dir := flags.String("o", ".", "directory to write the files to")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: lcl export [-format name] [-o dir] <file>")
+	}
+
+	exporter, ok := exporters[*format]
+	if !ok {
+		return fmt.Errorf("unknown format '%s', expected one of %s", *format, strings.Join(formats(), ", "))
+	}
+
+	c, err := load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	files, failure := exporter(c)
+	if files == nil {
+		return failure
+	}
+
+	for name, content := range files {
+		path := filepath.Join(*dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return failure
+}
+
+func formats() []string {
+	names := []string{}
+	for name := range exporters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
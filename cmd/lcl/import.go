@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/CanPacis/lcl/ftl"
+	"github.com/CanPacis/lcl/goi18n"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/parser/printer"
+	"github.com/CanPacis/lcl/po"
+	"github.com/CanPacis/lcl/xliff"
+	"golang.org/x/text/language"
+)
+
+// importers read the files of a format into catalogs, they are given the
+// arguments after the name of the format
+var importers = map[string]func(args []string) error{
+	"ftl":     importFTL,
+	"go-i18n": importGoI18n,
+	"gotext":  importGotext,
+	"po":      importPO,
+	"xliff":   importXLIFF,
+}
+
+func importFiles(args []string) error {
+	names := []string{}
+	for name := range importers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	if len(args) == 0 {
+		return fmt.Errorf("usage: lcl import <format> [arguments], format is one of %s", strings.Join(names, ", "))
+	}
+
+	importer, ok := importers[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown format '%s', expected one of %s", args[0], strings.Join(names, ", "))
+	}
+	return importer(args[1:])
+}
+
+func importFTL(args []string) error {
+	return translations("ftl", args, func(c *catalog, src []byte) (string, func(ir.Locale) error, error) {
+		resource, err := ftl.Parse(string(src))
+		if err != nil {
+			return "", nil, err
+		}
+		return "", func(locale ir.Locale) error {
+			return ftl.Import(c.tree, c.ir, locale, resource)
+		}, nil
+	})
+}
+
+func importPO(args []string) error {
+	return translations("po", args, func(c *catalog, src []byte) (string, func(ir.Locale) error, error) {
+		file, err := po.Parse(bytes.NewReader(src))
+		if err != nil {
+			return "", nil, err
+		}
+		return file.Field("Language"), func(locale ir.Locale) error {
+			return po.Import(c.tree, c.ir, locale, file)
+		}, nil
+	})
+}
+
+func importXLIFF(args []string) error {
+	return translations("xliff", args, func(c *catalog, src []byte) (string, func(ir.Locale) error, error) {
+		file, err := xliff.Parse(bytes.NewReader(src))
+		if err != nil {
+			return "", nil, err
+		}
+		return file.TargetLanguage, func(locale ir.Locale) error {
+			return xliff.Import(c.tree, locale, file)
+		}, nil
+	})
+}
+
+// translations imports files of a format that translate a single locale of a
+// catalog each. read parses a file and returns the language it declares and
+// the function that imports it into a locale.
+func translations(format string, args []string, read func(c *catalog, src []byte) (string, func(ir.Locale) error, error)) error {
+	flags := flag.NewFlagSet("import "+format, flag.ExitOnError)
+	name := flags.String("locale", "", "locale to import into, by default the language of every file")
+	flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		return fmt.Errorf("usage: lcl import %s [-locale name] <file> <translation ...>", format)
+	}
+
+	c, err := load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	failures := []error{}
+	for _, path := range flags.Args()[1:] {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		language, apply, err := read(c, src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		target := *name
+		if len(target) == 0 {
+			target = language
+		}
+		if len(target) == 0 {
+			target = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		locale, err := c.locale(target)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := apply(locale); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+
+	if err := c.save(); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+func importGoI18n(args []string) error {
+	return generate("go-i18n", args, goi18n.Parse)
+}
+
+func importGotext(args []string) error {
+	return generate("gotext", args, func(name string, src []byte) (*goi18n.Catalog, error) {
+		return goi18n.ParseGotext(src)
+	})
+}
+
+// generate writes a new catalog generated from the files of a format, the
+// parts of messages that need review are written to stderr
+func generate(format string, args []string, parse func(name string, src []byte) (*goi18n.Catalog, error)) error {
+	flags := flag.NewFlagSet("import "+format, flag.ExitOnError)
+	name := flags.String("name", "messages", "name of the package")
+	source := flags.String("source", "", "language the messages are written in, by default the language of the first file")
+	output := flags.String("o", "", "file to write the catalog to, by default the name of the package")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: lcl import %s [-name name] [-source tag] [-o file] <file ...>", format)
+	}
+	if len(*output) == 0 {
+		*output = *name + ".lcl"
+	}
+	if _, err := os.Stat(*output); err == nil {
+		return fmt.Errorf("%s already exists", *output)
+	}
+
+	catalogs := []*goi18n.Catalog{}
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		catalog, err := parse(path, src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		catalogs = append(catalogs, catalog)
+	}
+
+	tree, notes, err := goi18n.Generate(catalogs, goi18n.WithName(*name), goi18n.WithSource(*source))
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		fmt.Fprintf(os.Stderr, "review: %s\n", note)
+	}
+
+	src := []byte(printer.String(tree))
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		return err
+	}
+	if _, err := check(parser.NewFile(*output, bytes.NewReader(src))); err != nil {
+		return fmt.Errorf("%s is written but does not check: %w", *output, err)
+	}
+	return nil
+}
+
+// locale returns the locale of a catalog by its name or its tag, gettext
+// separates the parts of tags by underscores
+func (c *catalog) locale(name string) (ir.Locale, error) {
+	for _, locale := range c.ir.Locales {
+		if locale.Name == name {
+			return locale, nil
+		}
+	}
+
+	tag, err := language.Parse(strings.ReplaceAll(name, "_", "-"))
+	if err == nil {
+		for _, locale := range c.ir.Locales {
+			if locale.Tag == tag {
+				return locale, nil
+			}
+		}
+	}
+
+	return ir.Locale{}, fmt.Errorf("catalog %s declares no locale '%s'", c.ir.Name, name)
+}
+
+// save prints the tree of a catalog back to its file, the printed source is
+// checked first so that a failed import leaves the file as it was
+func (c *catalog) save() error {
+	src := []byte(printer.String(c.tree))
+
+	if _, err := check(parser.NewFile(c.file, bytes.NewReader(src))); err != nil {
+		return fmt.Errorf("imported messages do not check, %s is left as it was: %w", c.file, err)
+	}
+
+	return os.WriteFile(c.file, src, 0o644)
+}
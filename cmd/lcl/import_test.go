@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportPO(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "shop.lcl")
+	assert.NoError(os.WriteFile(input, []byte(catalogSource), 0o644))
+
+	catalog := filepath.Join(dir, "tr_TR.po")
+	assert.NoError(os.WriteFile(catalog, []byte(`msgid ""
+msgstr "Language: tr\n"
+
+msgctxt "cart.title"
+msgid "Cart"
+msgstr "Alışveriş sepeti"
+
+msgctxt "cart.stock.empty"
+msgid "Out of stock"
+msgstr ""
+`), 0o644))
+
+	assert.NoError(importFiles([]string{"po", input, catalog}))
+	src, _ := os.ReadFile(input)
+	assert.Equal(strings.Replace(catalogSource, `"Sepet"`, `"Alışveriş sepeti"`, 1), string(src))
+
+	// Catalogs of undeclared locales are rejected before anything is written
+	assert.ErrorContains(importFiles([]string{"po", "-locale", "de", input, catalog}), "declares no locale 'de'")
+	assert.Error(importFiles([]string{"xml", input}))
+
+	// Placeholders are read back from the source of the document
+	document := filepath.Join(dir, "tr.xlf")
+	assert.NoError(os.WriteFile(document, []byte(`<xliff version="1.2"><file source-language="en" target-language="tr"><body>
+<trans-unit id="cart.summary">
+<source><x id="1" equiv-text="{n}"/> <x id="2" equiv-text="{plural(n)}"/></source>
+<target><x id="1"/> tane ürün</target>
+</trans-unit>
+</body></file></xliff>`), 0o644))
+
+	err := importFiles([]string{"xliff", input, document})
+	assert.ErrorContains(err, "missing placeholder, {plural(n)}")
+
+	assert.NoError(os.WriteFile(document, []byte(`<xliff version="2.0" srcLang="en" trgLang="tr"><file id="f1">
+<unit id="cart.summary"><originalData><data id="d1">{n}</data><data id="d2">{plural(n)}</data></originalData>
+<segment><source><ph id="1" dataRef="d1"/> <ph id="2" dataRef="d2"/></source><target><ph id="2" dataRef="d2"/>: <ph id="1" dataRef="d1"/></target></segment>
+</unit>
+</file></xliff>`), 0o644))
+
+	assert.NoError(importFiles([]string{"xliff", input, document}))
+	src, _ = os.ReadFile(input)
+	assert.Contains(string(src), "    tr `{plural(n)}: {n}`\n")
+}
+
+func TestImportGoI18n(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	en := filepath.Join(dir, "active.en.toml")
+	assert.NoError(os.WriteFile(en, []byte(`[Cats]
+one = "{{.Name}} has a cat"
+other = "{{.Name}} has {{.PluralCount}} cats"
+`), 0o644))
+	tr := filepath.Join(dir, "active.tr.json")
+	assert.NoError(os.WriteFile(tr, []byte(`{"Cats": {"other": "{{.Name}} kedisi var"}}`), 0o644))
+
+	output := filepath.Join(dir, "pets.lcl")
+	assert.NoError(importFiles([]string{"go-i18n", "-name", "pets", "-o", output, en, tr}))
+	src, _ := os.ReadFile(output)
+	assert.Contains(string(src), "declare pets (en tr)\n")
+	assert.Contains(string(src), "  Cats(PluralCount:int Name:string) {\n")
+
+	// Catalogs are never overwritten
+	assert.ErrorContains(importFiles([]string{"go-i18n", "-o", output, en}), "already exists")
+}
+
+func TestImportFTL(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "shop.lcl")
+	assert.NoError(os.WriteFile(input, []byte(catalogSource), 0o644))
+
+	// The locale is named after the file
+	resource := filepath.Join(dir, "tr.ftl")
+	assert.NoError(os.WriteFile(resource, []byte(`-brand = Dükkan
+cart-title = { -brand } sepeti
+cart-summary =
+    { $n ->
+        [0] hiç ürün yok
+       *[other] { $n } ürün
+    }
+cart-stock-empty = { DATETIME($n) }
+`), 0o644))
+
+	// The rest is imported even if some messages could not be
+	err := importFiles([]string{"ftl", input, resource})
+	assert.ErrorContains(err, "import error: cart.summary (tr)")
+	assert.ErrorContains(err, "import error: cart.stock.empty (tr), DATETIME($n) in fluent cannot be represented in lcl")
+	src, _ := os.ReadFile(input)
+	assert.Contains(string(src), "    tr \"Dükkan sepeti\"\n")
+}
@@ -0,0 +1,112 @@
+// Command lcl works with lcl catalogs.
+//
+// Usage:
+//
+//	lcl build [-check] [-force] [-pseudo] [path ...]
+//	lcl export [-format name] [-o dir] <file>
+//	lcl import <format> [arguments]
+//	lcl repl [-locale name] <file>
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/CanPacis/lcl/analyzer"
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/parser/token"
+)
+
+var commands = map[string]func(args []string) error{
+	"build":  build,
+	"export": export,
+	"import": importFiles,
+	"repl":   repl,
+}
+
+var errUsage = errors.New("usage: lcl <command> [arguments]\n\ncommands:\n  build [path ...]  generate go code for catalogs\n  export <file>     export the messages of a catalog to another format\n  import <format>   import messages of another format into a catalog\n  repl <file>       evaluate expressions and messages of a catalog")
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, errUsage)
+		os.Exit(2)
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintln(os.Stderr, errUsage)
+		os.Exit(2)
+	}
+
+	if err := command(os.Args[2:]); err != nil {
+		report(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// catalog is a checked source file
+type catalog struct {
+	file      string
+	tree      *ast.File
+	ir        *ir.IR
+	pkg       *pkg.Package
+	semantics *analyzer.Semantics
+}
+
+// load parses and checks the catalog at path
+func load(path string) (*catalog, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return check(parser.NewFile(path, bytes.NewReader(src)))
+}
+
+func check(file *parser.File) (c *catalog, err error) {
+	// The parser panics on some malformed input
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %v", file.Name, r)
+		}
+	}()
+
+	tree, err := parser.Parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := pkg.New(tree.Decl.Name.Value)
+	semantics := analyzer.New(file, tree, p)
+	out, err := semantics.Scan()
+	if err != nil {
+		return nil, err
+	}
+
+	return &catalog{file: file.Name, tree: tree, ir: out, pkg: p, semantics: semantics}, nil
+}
+
+// report writes an error, every error of an error set is written on its own
+// line with its position
+func report(w io.Writer, err error) {
+	set, ok := err.(*errs.ErrorSet)
+	if !ok {
+		fmt.Fprintln(w, err)
+		return
+	}
+
+	for _, e := range set.Errors {
+		if r, ok := e.(interface{ Range() token.Range }); ok {
+			fmt.Fprintf(w, "%s:%s: %s\n", set.File(), r.Range().Start, e)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s\n", set.File(), e)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/interp"
+	"github.com/CanPacis/lcl/ir"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/types"
+	"golang.org/x/text/language"
+)
+
+func repl(args []string) error {
+	flags := flag.NewFlagSet("repl", flag.ExitOnError)
+	locale := flags.String("locale", "", "locale to render messages for, defaults to the first target")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: lcl repl [-locale name] <file>")
+	}
+
+	c, err := load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	r := NewREPL(c, os.Stdin, os.Stdout)
+	if *locale != "" {
+		if err := r.SetLocale(*locale); err != nil {
+			return err
+		}
+	}
+	return r.Run()
+}
+
+// REPL evaluates expressions in the scope of a catalog, the sections of the
+// catalog can be referred to by name to render their messages.
+type REPL struct {
+	catalog     *catalog
+	interpreter *interp.Interpreter
+	sections    map[string]types.Type
+
+	in  io.Reader
+	out io.Writer
+}
+
+// SetLocale changes the locale messages are rendered for, name is either the
+// name of a target or a language tag.
+func (r *REPL) SetLocale(name string) error {
+	for _, locale := range r.catalog.ir.Locales {
+		if locale.Name == name {
+			r.interpreter = interp.New(r.catalog.ir, locale.Tag)
+			return nil
+		}
+	}
+
+	tag, err := language.Parse(name)
+	if err != nil {
+		return fmt.Errorf("unknown locale '%s'", name)
+	}
+	r.interpreter = interp.New(r.catalog.ir, tag)
+	return nil
+}
+
+// Run reads lines until the input ends or :quit is entered. Lines starting
+// with a colon are commands, the others are evaluated as expressions.
+func (r *REPL) Run() error {
+	scanner := bufio.NewScanner(r.in)
+
+	for {
+		fmt.Fprint(r.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, ":"):
+			if r.command(strings.Fields(line[1:])) {
+				return nil
+			}
+		default:
+			r.eval(line)
+		}
+	}
+}
+
+// command runs a repl command and reports whether the repl should stop
+func (r *REPL) command(fields []string) bool {
+	if len(fields) == 0 {
+		fields = []string{"help"}
+	}
+
+	switch fields[0] {
+	case "quit", "q":
+		return true
+	case "locale":
+		if len(fields) == 1 {
+			locale := r.interpreter.Locale()
+			fmt.Fprintf(r.out, "%s (%s)\n", locale.Name, locale.Tag)
+			return false
+		}
+		if err := r.SetLocale(fields[1]); err != nil {
+			fmt.Fprintln(r.out, err)
+			return false
+		}
+		locale := r.interpreter.Locale()
+		fmt.Fprintf(r.out, "%s (%s)\n", locale.Name, locale.Tag)
+	case "help":
+		fmt.Fprintln(r.out, ":locale [name]  show or change the locale messages are rendered for")
+		fmt.Fprintln(r.out, ":quit           leave the repl")
+	default:
+		fmt.Fprintf(r.out, "unknown command ':%s'\n", fields[0])
+	}
+
+	return false
+}
+
+func (r *REPL) eval(line string) {
+	expr, err := parse(line)
+	if err != nil {
+		report(r.out, err)
+		return
+	}
+
+	scope := pkg.NewSubScope(r.catalog.pkg.Scope)
+	for name, typ := range r.sections {
+		scope.Define(name, typ)
+	}
+
+	typ, err := scope.ResolveExpr(expr)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+
+	v, err := r.interpreter.Eval(r.catalog.semantics.Lower(expr))
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+
+	fmt.Fprintf(r.out, "%s = %s\n", typ, show(v, typ))
+}
+
+func parse(line string) (expr ast.Expr, err error) {
+	// The parser panics on some malformed input
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("syntax error: %v", r)
+		}
+	}()
+
+	return parser.ParseExpr(parser.NewFile("repl", strings.NewReader(line)))
+}
+
+// show returns the text of a value like it would be written in a source file
+func show(v any, typ types.Type) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case *ir.Message:
+		return "template " + v.Name
+	case *ir.Section:
+		return "section " + v.Name
+	}
+
+	if _, ok := typ.(*types.Fn); ok {
+		return "fn"
+	}
+	return interp.Format(v, types.Default(typ))
+}
+
+// sectionType returns the type a section has in the repl, keys are strings
+// and templates are fns that return strings
+func sectionType(section *ir.Section) types.Type {
+	pairs := []types.TypePair{}
+
+	for _, message := range section.Messages {
+		var typ types.Type = types.String
+		if message.IsTemplate {
+			typ = &types.Fn{In: message.Type.In, Out: types.String}
+		}
+		pairs = append(pairs, types.TypePair{Index: len(pairs), Name: message.Name, Type: typ})
+	}
+
+	for _, sub := range section.Sections {
+		pairs = append(pairs, types.TypePair{Index: len(pairs), Name: sub.Name, Type: sectionType(sub)})
+	}
+
+	return types.NewStruct(pairs...)
+}
+
+// NewREPL returns a repl for a catalog that renders messages for its first
+// target.
+func NewREPL(c *catalog, in io.Reader, out io.Writer) *REPL {
+	r := &REPL{
+		catalog:  c,
+		sections: map[string]types.Type{},
+		in:       in,
+		out:      out,
+	}
+
+	var tag language.Tag
+	if len(c.ir.Locales) > 0 {
+		tag = c.ir.Locales[0].Tag
+	}
+	r.interpreter = interp.New(c.ir, tag)
+
+	for _, section := range c.ir.Sections {
+		r.sections[section.Name] = sectionType(section)
+	}
+
+	return r
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CanPacis/lcl/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const catalogSource = `declare shop (en tr)
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+section cart {
+  title {
+    en "Cart"
+    tr "Sepet"
+  }
+
+  summary(n:int) {
+    en ` + "`{n} {plural(n)}`" + `
+    tr ` + "`{n} ürün`" + `
+  }
+
+  section stock {
+    empty {
+      en "Out of stock"
+      tr "Tükendi"
+    }
+  }
+}
+`
+
+func TestREPL(t *testing.T) {
+	c, err := check(parser.NewFile("mock.lcl", bytes.NewBufferString(catalogSource)))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	in := strings.Join([]string{
+		"1 + 2",
+		"2.5 * 1.4",
+		"plural(2)",
+		"cart.title",
+		"cart.summary(1)",
+		":locale tr",
+		"cart.summary(3)",
+		"cart.stock.empty",
+		"cart.missing",
+		":quit",
+		"cart.title",
+	}, "\n")
+
+	out := bytes.Buffer{}
+	assert.NoError(t, NewREPL(c, strings.NewReader(in), &out).Run())
+
+	expected := strings.Join([]string{
+		"> untyped int = 3",
+		"> untyped float = 3.5",
+		"> string = \"items\"",
+		"> string = \"Cart\"",
+		"> string = \"1 item\"",
+		"> tr (tr)",
+		"> string = \"3 ürün\"",
+		"> string = \"Tükendi\"",
+		"> type error: invalid index, cannot index {(0 title string) (1 summary fn (int) -> string) (2 stock {(0 empty string)})} with a missing",
+		"> ",
+	}, "\n")
+
+	assert.Equal(t, expected, out.String())
+}
@@ -47,8 +47,7 @@ func join(elems any, sep string) string {
 
 	list := []string{}
 	for i := range rv.Len() {
-		val := rv.Index(i).Elem()
-		str, ok := val.Interface().(interface {
+		str, ok := rv.Index(i).Interface().(interface {
 			String() string
 		})
 		if ok {
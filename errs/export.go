@@ -0,0 +1,119 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// Export errors
+
+	ErrUnrepresentable = errors.New("cannot be represented")
+
+	// Import errors
+
+	ErrMalformedPattern   = errors.New("malformed pattern")
+	ErrMalformedFile      = errors.New("malformed file")
+	ErrUnknownArgument    = errors.New("unknown argument")
+	ErrMissingPlaceholder = errors.New("missing placeholder")
+	ErrUnknownPlaceholder = errors.New("unknown placeholder")
+)
+
+// ExportError is an error that occurs while converting a message to another
+// format. It refers to the message by its path and locale, the names the
+// message is known by in the exported files.
+type ExportError struct {
+	Err    error
+	Format string
+	Path   string
+	Locale string
+	Value  string
+}
+
+func (e *ExportError) Error() string {
+	var message string
+
+	switch {
+	case errors.Is(e.Err, ErrUnrepresentable):
+		message = fmt.Sprintf("%s %s in %s", e.Value, e.Err.Error(), e.Format)
+	case errors.Is(e.Err, ErrNameCollision):
+		message = fmt.Sprintf("%s with %s in %s", e.Err.Error(), e.Value, e.Format)
+	default:
+		message = e.Err.Error()
+	}
+
+	return e.Name() + ": " + subject(e.Path, e.Locale) + message
+}
+
+func (e *ExportError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ExportError) Name() string {
+	return "export error"
+}
+
+// Locate fills in the message an export error occurred in, other errors are
+// returned as they are
+func Locate(err error, path, locale string) error {
+	var e *ExportError
+	if errors.As(err, &e) {
+		e.Path = path
+		e.Locale = locale
+	}
+	return err
+}
+
+// IsExportError reports whether an error is or wraps an export error, the
+// ones that leave the rest of a file intact
+func IsExportError(err error) bool {
+	var e *ExportError
+	return errors.As(err, &e)
+}
+
+// ImportError is an error that occurs while converting a message of another
+// format to lcl.
+type ImportError struct {
+	Err    error
+	Format string
+	Path   string
+	Locale string
+	Value  string
+}
+
+func (e *ImportError) Error() string {
+	var message string
+
+	switch {
+	case errors.Is(e.Err, ErrUnrepresentable):
+		message = fmt.Sprintf("%s in %s %s in lcl", e.Value, e.Format, e.Err.Error())
+	case errors.Is(e.Err, ErrUnknownArgument):
+		message = fmt.Sprintf("%s '%s'", e.Err.Error(), e.Value)
+	case len(e.Value) > 0:
+		message = fmt.Sprintf("%s, %s", e.Err.Error(), e.Value)
+	default:
+		message = e.Err.Error()
+	}
+
+	return e.Name() + ": " + subject(e.Path, e.Locale) + message
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ImportError) Name() string {
+	return "import error"
+}
+
+// subject names the message an error is about, it is empty if the message is
+// not known
+func subject(path, locale string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	if len(locale) > 0 {
+		path += " (" + locale + ")"
+	}
+	return path + ", "
+}
@@ -0,0 +1,49 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// Runtime errors
+
+	ErrUnknownMessage   = errors.New("unknown message")
+	ErrMissingArgument  = errors.New("missing argument")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	ErrIndexOutOfRange  = errors.New("index out of range")
+	ErrUnresolvedImport = errors.New("unresolved import")
+)
+
+// RuntimeError is an error that occurs while evaluating a package rather than
+// checking it, so it has no position in the source.
+type RuntimeError struct {
+	Err   error
+	Type  Type
+	Value string
+	N     int
+	M     int
+}
+
+func (e *RuntimeError) Error() string {
+	switch {
+	case errors.Is(e.Err, ErrUnknownMessage),
+		errors.Is(e.Err, ErrUnresolvedImport),
+		errors.Is(e.Err, ErrUnresolvedConstReference):
+		return fmt.Sprintf("%s: %s '%s'", e.Name(), e.Err.Error(), e.Value)
+	case errors.Is(e.Err, ErrMissingArgument), errors.Is(e.Err, ErrInvalidArgument):
+		return fmt.Sprintf("%s: %s '%s', expected a %s", e.Name(), e.Err.Error(), e.Value, e.Type.String())
+	case errors.Is(e.Err, ErrIndexOutOfRange):
+		return fmt.Sprintf("%s: %s, index %d with length %d", e.Name(), e.Err.Error(), e.N, e.M)
+	default:
+		return fmt.Sprintf("%s: %s", e.Name(), e.Err.Error())
+	}
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RuntimeError) Name() string {
+	return "runtime error"
+}
@@ -27,11 +27,19 @@ var (
 	ErrNonBoolPredicate = errors.New("non bool predicate")
 	ErrMultipleTypes    = errors.New("both sides of this expression must be the same type")
 	ErrBuiltinOverride  = errors.New("is a builtin type you cannot override")
+	ErrInvalidMapKey    = errors.New("invalid map key type")
+	ErrInvalidOperation = errors.New("invalid operation")
+	ErrConstantOverflow = errors.New("constant overflow")
+	ErrDivisionByZero   = errors.New("division by zero")
+	ErrNotOptional      = errors.New("expression is not optional")
+	ErrOptionalValue    = errors.New("optional value needs a default")
+	ErrNotFormattable   = errors.New("expression cannot be interpolated")
 
 	// Reference errors
 
 	ErrInvalidDeclName           = errors.New("invalid declaration name")
 	ErrDuplicateDefinition       = errors.New("duplicate definition")
+	ErrNameCollision             = errors.New("name collision")
 	ErrInvalidTargetTag          = errors.New("invalid target tag")
 	ErrUndeclaredTargetTag       = errors.New("undeclared target tag")
 	ErrMissingTargetField        = errors.New("missing target field")
@@ -63,13 +71,25 @@ func (e *TypeError) Error() string {
 	case errors.Is(e.Err, ErrNotCallable):
 		return fmt.Sprintf("%s: %s, %s is not a function", e.Name(), e.Err.Error(), e.Type.String())
 	case errors.Is(e.Err, ErrNotIndexable):
-		return fmt.Sprintf("%s: %s, %s is not a list or a struct", e.Name(), e.Err.Error(), e.Type.String())
+		return fmt.Sprintf("%s: %s, %s is not a list, a map or a struct", e.Name(), e.Err.Error(), e.Type.String())
 	case errors.Is(e.Err, ErrInvalidIndex):
 		return fmt.Sprintf("%s: %s, cannot index %s with a %s", e.Name(), e.Err.Error(), e.Type.String(), e.Value)
 	case errors.Is(e.Err, ErrTooManyArguments), errors.Is(e.Err, ErrTooFewArguments):
 		return fmt.Sprintf("%s: %s, %s expects %d arguments but got %d", e.Name(), e.Err.Error(), e.Type.String(), e.N, e.M)
 	case errors.Is(e.Err, ErrNonBoolPredicate):
 		return fmt.Sprintf("%s: %s, this expression should be a bool not a %s", e.Name(), e.Err.Error(), e.Type.String())
+	case errors.Is(e.Err, ErrInvalidOperation):
+		return fmt.Sprintf("%s: %s, operator %s is not defined on %s", e.Name(), e.Err.Error(), e.Value, e.Type.String())
+	case errors.Is(e.Err, ErrConstantOverflow):
+		return fmt.Sprintf("%s: %s, %s cannot be represented by %s", e.Name(), e.Err.Error(), e.Value, e.Type.String())
+	case errors.Is(e.Err, ErrInvalidMapKey):
+		return fmt.Sprintf("%s: %s, %s cannot be used as a map key", e.Name(), e.Err.Error(), e.Type.String())
+	case errors.Is(e.Err, ErrNotOptional):
+		return fmt.Sprintf("%s: %s, %s cannot be absent so it does not need a default", e.Name(), e.Err.Error(), e.Type.String())
+	case errors.Is(e.Err, ErrOptionalValue):
+		return fmt.Sprintf("%s: %s, %s may be absent, provide one with the ?? operator", e.Name(), e.Err.Error(), e.Type.String())
+	case errors.Is(e.Err, ErrNotFormattable):
+		return fmt.Sprintf("%s: %s, %s is not a string, a number or a bool", e.Name(), e.Err.Error(), e.Type.String())
 	case errors.Is(e.Err, ErrBuiltinOverride):
 		return fmt.Sprintf("%s: %s %s", e.Name(), e.Type.String(), e.Err.Error())
 	default:
@@ -102,6 +122,8 @@ func (e *ReferenceError) Error() string {
 		return fmt.Sprintf("%s: %s '%s'", e.Name(), e.Err.Error(), e.Value)
 	case errors.Is(e.Err, ErrDuplicateDefinition):
 		return fmt.Sprintf("%s: %s, %s is already defined here %s", e.Name(), e.Err.Error(), e.Value, e.Original.Range())
+	case errors.Is(e.Err, ErrNameCollision):
+		return fmt.Sprintf("%s: %s, %s is exported like the name defined here %s", e.Name(), e.Err.Error(), e.Value, e.Original.Range())
 	case errors.Is(e.Err, ErrInvalidTargetTag):
 		return fmt.Sprintf("%s: %s '%s'", e.Name(), e.Err.Error(), e.Value)
 	case errors.Is(e.Err, ErrUndeclaredTargetTag):
@@ -0,0 +1,379 @@
+package ftl
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+// depth bounds the calls of fns that are inlined into each other
+const depth = 16
+
+type Config struct{}
+
+type Generator struct {
+	config *Config
+	ir     *ir.IR
+}
+
+// Files returns the resource of every locale named after its tag. Fns are
+// checked first, the ones that have no Fluent equivalent are reported along
+// with the messages that could not be exported. The files are returned even
+// if some of them failed.
+func (g *Generator) Files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	failures := []error{g.Check()}
+
+	for _, locale := range g.ir.Locales {
+		buf := &bytes.Buffer{}
+		if err := g.Generate(buf, locale); err != nil {
+			if !errs.IsExportError(err) {
+				return nil, err
+			}
+			failures = append(failures, err)
+		}
+		files[locale.Tag.String()+".ftl"] = buf.Bytes()
+	}
+
+	return files, errors.Join(failures...)
+}
+
+// Check reports the fns of the package that have no Fluent equivalent. Fluent
+// has no fns of its own, calls are inlined into the messages that make them
+// so a fn is only exported if its body can be written as a pattern.
+func (g *Generator) Check() error {
+	ir.Optimize(g.ir)
+	failures := []error{}
+
+	for _, fn := range g.ir.FnDefs {
+		c := g.converter(fn.Params)
+		if _, err := c.pattern(fn.Body, nil, 0); err != nil {
+			failures = append(failures, errs.Locate(err, "fn "+fn.Name, ""))
+		}
+	}
+
+	return errors.Join(failures...)
+}
+
+// Generate writes the resource of a locale. Keys become plain messages and
+// templates messages whose params are variables, ternaries that compare a
+// param against constants become select expressions. Messages that cannot
+// be represented are left out and reported together once the rest is
+// written.
+func (g *Generator) Generate(w io.Writer, locale ir.Locale) error {
+	ir.Optimize(g.ir)
+	failures := []error{}
+
+	resource := &Resource{}
+
+	var section func(s *ir.Section, path string)
+	section = func(s *ir.Section, path string) {
+		for _, message := range s.Messages {
+			value := message.Values[locale.Tag]
+			if value == nil {
+				continue
+			}
+
+			var pattern Pattern
+			var err error
+			if lit, ok := value.(*ir.Literal); ok && !message.IsTemplate {
+				pattern = Pattern{Text(lit.Text())}
+			} else {
+				pattern, err = g.converter(message.Params).pattern(value, nil, 0)
+			}
+			if err != nil {
+				failures = append(failures, errs.Locate(err, path+message.Name, locale.Name))
+				continue
+			}
+
+			m := &Message{ID: ID(path + message.Name), Value: join(pattern)}
+			if len(message.Comment) > 0 {
+				m.Comments = strings.Split(message.Comment, "\n")
+			}
+			resource.Messages = append(resource.Messages, m)
+		}
+
+		for _, sub := range s.Sections {
+			section(sub, path+sub.Name+".")
+		}
+	}
+
+	for _, s := range g.ir.Sections {
+		section(s, s.Name+".")
+	}
+
+	if err := resource.Write(w); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+// ID returns the id of a message by its path, cart.stock.empty is
+// cart-stock-empty
+func ID(path string) string {
+	return strings.ReplaceAll(path, ".", "-")
+}
+
+func (g *Generator) converter(params []ir.Param) *converter {
+	c := &converter{params: map[string]types.Type{}, fns: map[string]*ir.FnDef{}}
+	for _, param := range params {
+		c.params[param.Name] = param.Type
+	}
+	for i := range g.ir.FnDefs {
+		c.fns[g.ir.FnDefs[i].Name] = &g.ir.FnDefs[i]
+	}
+	return c
+}
+
+// converter writes expressions as patterns, params are variables
+type converter struct {
+	params map[string]types.Type
+	fns    map[string]*ir.FnDef
+}
+
+// scope binds the params of an inlined fn to the arguments of its call
+type scope map[string]binding
+
+type binding struct {
+	expr  ir.Expr
+	scope scope
+}
+
+// pattern returns the pattern of an expression, calls are inlined up to a
+// depth
+func (c *converter) pattern(expr ir.Expr, s scope, calls int) (Pattern, error) {
+	switch expr := expr.(type) {
+	case *ir.Literal:
+		return Pattern{Text(expr.Text())}, nil
+	case *ir.Template:
+		p := Pattern{}
+		for _, segment := range expr.Segments {
+			if segment.Value == nil {
+				p = append(p, Text(segment.Text))
+				continue
+			}
+			value, err := c.pattern(segment.Value, s, calls)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, value...)
+		}
+		return p, nil
+	case *ir.Ref:
+		if b, ok := s[expr.Name]; ok {
+			return c.pattern(b.expr, b.scope, calls)
+		}
+		if _, ok := c.params[expr.Name]; ok {
+			return Pattern{&Placeable{Expr: &VariableRef{Name: expr.Name}}}, nil
+		}
+		return nil, unrepresentable("reference to " + expr.Name)
+	case *ir.Binary:
+		if expr.Op != ir.Add || !types.IsString(expr.Typ) {
+			return nil, unrepresentable(ir.Describe(expr))
+		}
+		left, err := c.pattern(expr.Left, s, calls)
+		if err != nil {
+			return nil, err
+		}
+		right, err := c.pattern(expr.Right, s, calls)
+		if err != nil {
+			return nil, err
+		}
+		return append(left, right...), nil
+	case *ir.Ternary:
+		return c.choice(expr, s, calls)
+	case *ir.Call:
+		ref, ok := expr.Fn.(*ir.Ref)
+		fn := c.fns[ref.Name]
+		if !ok || fn == nil || len(fn.Params) != len(expr.Args) {
+			return nil, unrepresentable(ir.Describe(expr))
+		}
+		if calls == depth {
+			return nil, unrepresentable("recursive call of " + ref.Name)
+		}
+
+		inner := scope{}
+		for i, param := range fn.Params {
+			inner[param.Name] = binding{expr.Args[i], s}
+		}
+		return c.pattern(fn.Body, inner, calls+1)
+	default:
+		return nil, unrepresentable(ir.Describe(expr))
+	}
+}
+
+// choice returns the select of a chain of ternaries on the same selector,
+// a ternary on something else ends the chain and is its default variant
+func (c *converter) choice(expr *ir.Ternary, s scope, calls int) (Pattern, error) {
+	var subject any
+	variants := []*Variant{}
+	var other ir.Expr = expr
+
+	for {
+		ternary, ok := other.(*ir.Ternary)
+		if !ok {
+			break
+		}
+
+		selector, variant, negated, ok := c.selector(ternary.Cond, s)
+		if !ok {
+			if len(variants) == 0 {
+				return nil, unrepresentable("ternary on " + ir.Describe(ternary.Cond))
+			}
+			break
+		}
+		if subject != nil && expression(selector) != expression(subject) {
+			break
+		}
+		subject = selector
+
+		then, otherwise := ternary.Then, ternary.Else
+		if negated {
+			then, otherwise = otherwise, then
+		}
+
+		value, err := c.pattern(then, s, calls)
+		if err != nil {
+			return nil, err
+		}
+		variant.Value = join(value)
+
+		duplicate := false
+		for _, v := range variants {
+			duplicate = duplicate || v.Key == variant.Key
+		}
+		if !duplicate {
+			variants = append(variants, variant)
+		}
+
+		other = otherwise
+		if negated {
+			break
+		}
+	}
+
+	value, err := c.pattern(other, s, calls)
+	if err != nil {
+		return nil, err
+	}
+	variants = append(variants, &Variant{Key: "other", IsDefault: true, Value: join(value)})
+
+	return Pattern{&Placeable{Expr: &Select{Selector: subject, Variants: variants}}}, nil
+}
+
+// selector returns the selector a condition tests and the variant it holds
+// for, negated is set if the variant is the else branch
+func (c *converter) selector(cond ir.Expr, s scope) (selector any, variant *Variant, negated, ok bool) {
+	switch cond := cond.(type) {
+	case *ir.Ref:
+		if types.Identical(types.Default(cond.Typ), types.Bool) {
+			selector, ok = c.resolve(cond, s)
+			return selector, &Variant{Key: "true"}, false, ok
+		}
+	case *ir.Unary:
+		if ref, isRef := cond.X.(*ir.Ref); isRef && cond.Op == ir.Not {
+			selector, ok = c.resolve(ref, s)
+			return selector, &Variant{Key: "false"}, false, ok
+		}
+	case *ir.Binary:
+		if cond.Op != ir.Eq && cond.Op != ir.Neq {
+			return
+		}
+
+		ref, isRef := cond.Left.(*ir.Ref)
+		lit, isLit := cond.Right.(*ir.Literal)
+		if !isRef || !isLit {
+			ref, isRef = cond.Right.(*ir.Ref)
+			lit, isLit = cond.Left.(*ir.Literal)
+		}
+		if !isRef || !isLit {
+			return
+		}
+
+		switch value := lit.Value.(type) {
+		case float64:
+			variant = &Variant{Key: lit.Text(), IsNumeric: true}
+		case string:
+			if !isIdentifier(value) || value == "other" {
+				return
+			}
+			variant = &Variant{Key: value}
+		default:
+			return
+		}
+
+		selector, ok = c.resolve(ref, s)
+		return selector, variant, cond.Op == ir.Neq, ok
+	}
+
+	return
+}
+
+// resolve returns the variable or the literal a reference stands for once
+// the params of inlined fns are bound
+func (c *converter) resolve(ref *ir.Ref, s scope) (any, bool) {
+	if b, ok := s[ref.Name]; ok {
+		switch expr := b.expr.(type) {
+		case *ir.Ref:
+			return c.resolve(expr, b.scope)
+		case *ir.Literal:
+			if value, ok := expr.Value.(string); ok {
+				return &StringLiteral{Value: value}, true
+			}
+			return &NumberLiteral{Value: expr.Text()}, true
+		}
+		return nil, false
+	}
+
+	if _, ok := c.params[ref.Name]; ok {
+		return &VariableRef{Name: ref.Name}, true
+	}
+	return nil, false
+}
+
+// join merges the adjacent text of a pattern
+func join(p Pattern) Pattern {
+	joined := Pattern{}
+	for _, element := range p {
+		if text, ok := element.(Text); ok {
+			if len(text) == 0 {
+				continue
+			}
+			if n := len(joined); n > 0 {
+				if prev, ok := joined[n-1].(Text); ok {
+					joined[n-1] = prev + text
+					continue
+				}
+			}
+		}
+		joined = append(joined, element)
+	}
+	return joined
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !isAlpha(r) && (i == 0 || !(r >= '0' && r <= '9' || r == '_' || r == '-')) {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func unrepresentable(value string) error {
+	return &errs.ExportError{Err: errs.ErrUnrepresentable, Format: "fluent", Value: value}
+}
+
+func New(out *ir.IR, options ...func(*Config)) *Generator {
+	config := &Config{}
+	for _, option := range options {
+		option(config)
+	}
+
+	return &Generator{config: config, ir: out}
+}
@@ -0,0 +1,677 @@
+// Package ftl reads and writes the resources of Project Fluent. Sections
+// become the dashed ids of messages, params become variables and ternaries
+// over params become select expressions.
+package ftl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/CanPacis/lcl/errs"
+)
+
+// Resource is a Fluent file, terms are kept apart from messages since they
+// are only referred to by other patterns
+type Resource struct {
+	Messages []*Message
+	Terms    []*Message
+}
+
+// Message is a message or a term, Value is nil if it has only attributes
+type Message struct {
+	ID         string
+	Comments   []string
+	Value      Pattern
+	Attributes []*Attribute
+}
+
+type Attribute struct {
+	Name  string
+	Value Pattern
+}
+
+// Pattern is the value of a message, its elements are Text and *Placeable
+type Pattern []any
+
+type Text string
+
+type Placeable struct {
+	Expr any
+}
+
+type StringLiteral struct {
+	Value string
+}
+
+type NumberLiteral struct {
+	Value string
+}
+
+type VariableRef struct {
+	Name string
+}
+
+// MessageRef refers to a message, or to a term if IsTerm is set
+type MessageRef struct {
+	Name      string
+	Attribute string
+	IsTerm    bool
+	// Args are the named arguments of a term
+	Args []*NamedArg
+}
+
+type FunctionRef struct {
+	Name  string
+	Args  []any
+	Named []*NamedArg
+}
+
+type NamedArg struct {
+	Name  string
+	Value any
+}
+
+type Select struct {
+	Selector any
+	Variants []*Variant
+}
+
+// Variant is an option of a select, the key of a numeric one is a number
+type Variant struct {
+	Key       string
+	IsNumeric bool
+	IsDefault bool
+	Value     Pattern
+}
+
+// Term returns a term of the resource by its id without the dash
+func (r *Resource) Term(id string) *Message {
+	for _, term := range r.Terms {
+		if term.ID == id {
+			return term
+		}
+	}
+	return nil
+}
+
+// Message returns a message of the resource by its id
+func (r *Resource) Message(id string) *Message {
+	for _, message := range r.Messages {
+		if message.ID == id {
+			return message
+		}
+	}
+	return nil
+}
+
+// Parse reads a Fluent resource. Comments directly above a message are kept
+// along with it, group and resource comments are skipped.
+func Parse(src string) (*Resource, error) {
+	p := &parser{src: []rune(strings.ReplaceAll(src, "\r\n", "\n")), line: 1}
+	resource := &Resource{}
+	comments := []string{}
+
+	for !p.done() {
+		switch r := p.peek(); {
+		case r == '\n':
+			p.next()
+			comments = nil
+		case r == '#':
+			level := 0
+			for p.peek() == '#' {
+				p.next()
+				level++
+			}
+			if p.peek() != ' ' && p.peek() != '\n' && !p.done() {
+				return nil, p.errorf("expected a space after the comment sign")
+			}
+			line := strings.TrimPrefix(p.rest(), " ")
+			if level == 1 {
+				comments = append(comments, line)
+			} else {
+				comments = nil
+			}
+		case r == '-' || isAlpha(r):
+			isTerm := r == '-'
+			if isTerm {
+				p.next()
+			}
+
+			message, err := p.message()
+			if err != nil {
+				return nil, err
+			}
+			message.Comments = comments
+			comments = nil
+
+			if isTerm {
+				if message.Value == nil {
+					return nil, p.errorf("term -%s has no value", message.ID)
+				}
+				resource.Terms = append(resource.Terms, message)
+			} else {
+				resource.Messages = append(resource.Messages, message)
+			}
+		default:
+			return nil, p.errorf("expected a message, a term or a comment")
+		}
+	}
+
+	return resource, nil
+}
+
+type parser struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() rune {
+	return p.at(p.pos)
+}
+
+func (p *parser) at(pos int) rune {
+	if pos >= len(p.src) {
+		return 0
+	}
+	return p.src[pos]
+}
+
+func (p *parser) next() rune {
+	r := p.peek()
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	value := fmt.Sprintf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+	return &errs.ImportError{Err: errs.ErrMalformedFile, Format: "fluent", Value: value}
+}
+
+func (p *parser) expect(r rune) error {
+	if p.peek() != r {
+		return p.errorf("expected '%c'", r)
+	}
+	p.next()
+	return nil
+}
+
+// rest returns the rest of the line and skips its end
+func (p *parser) rest() string {
+	start := p.pos
+	for !p.done() && p.peek() != '\n' {
+		p.next()
+	}
+	line := string(p.src[start:p.pos])
+	if !p.done() {
+		p.next()
+	}
+	return line
+}
+
+// inline skips the spaces on the line
+func (p *parser) inline() {
+	for p.peek() == ' ' {
+		p.next()
+	}
+}
+
+// blank skips spaces and new lines
+func (p *parser) blank() {
+	for p.peek() == ' ' || p.peek() == '\n' {
+		p.next()
+	}
+}
+
+func (p *parser) identifier() (string, error) {
+	if !isAlpha(p.peek()) {
+		return "", p.errorf("expected an identifier")
+	}
+	start := p.pos
+	for r := p.peek(); isAlpha(r) || r >= '0' && r <= '9' || r == '_' || r == '-'; r = p.peek() {
+		p.next()
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+func (p *parser) message() (*Message, error) {
+	id, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	message := &Message{ID: id}
+
+	p.inline()
+	if err := p.expect('='); err != nil {
+		return nil, err
+	}
+	p.inline()
+
+	if message.Value, err = p.pattern(); err != nil {
+		return nil, err
+	}
+
+	for {
+		// Attributes are on indented lines of their own
+		pos, line := p.pos, p.line
+		p.blank()
+		if p.peek() != '.' {
+			p.pos, p.line = pos, line
+			break
+		}
+		p.next()
+
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		p.inline()
+		if err := p.expect('='); err != nil {
+			return nil, err
+		}
+		p.inline()
+
+		value, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, p.errorf("attribute .%s has no value", name)
+		}
+		message.Attributes = append(message.Attributes, &Attribute{Name: name, Value: value})
+	}
+
+	if message.Value == nil && len(message.Attributes) == 0 {
+		return nil, p.errorf("message %s has no value", id)
+	}
+	if !p.done() && p.next() != '\n' {
+		return nil, p.errorf("unexpected '%c' after the pattern of %s", p.src[p.pos-1], id)
+	}
+	return message, nil
+}
+
+// indent marks the start of an indented line of a pattern, the indentation
+// all lines share is removed once the pattern is read
+type indent int
+
+// pattern reads a pattern up to the end of its last line or up to the brace
+// that closes the select it is a variant of. It is nil if there is no
+// pattern.
+func (p *parser) pattern() (Pattern, error) {
+	elements := []any{}
+
+	for !p.done() {
+		switch r := p.peek(); r {
+		case '{':
+			placeable, err := p.placeable()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, placeable)
+		case '}':
+			return dedent(elements), nil
+		case '\n':
+			// A pattern goes on with the next indented line that does not
+			// start a variant, an attribute or the end of a select
+			pos, line := p.pos, p.line
+			lines := 0
+			for p.peek() == '\n' {
+				p.next()
+				lines++
+				start := p.pos
+				p.inline()
+				if p.peek() != '\n' {
+					p.pos = start
+				}
+			}
+
+			width := 0
+			for p.at(p.pos+width) == ' ' {
+				width++
+			}
+			if width == 0 || strings.ContainsRune("[*.}", p.at(p.pos+width)) || p.pos+width >= len(p.src) {
+				p.pos, p.line = pos, line
+				return dedent(elements), nil
+			}
+
+			p.pos += width
+			if len(elements) > 0 {
+				elements = append(elements, Text(strings.Repeat("\n", lines)))
+			}
+			elements = append(elements, indent(width))
+		default:
+			p.next()
+			if n := len(elements); n > 0 {
+				if text, ok := elements[n-1].(Text); ok {
+					elements[n-1] = text + Text(r)
+					continue
+				}
+			}
+			elements = append(elements, Text(r))
+		}
+	}
+
+	return dedent(elements), nil
+}
+
+// dedent removes the indentation the lines of a pattern share, joins its
+// text and trims the white space it ends with
+func dedent(elements []any) Pattern {
+	shared := -1
+	for _, element := range elements {
+		if width, ok := element.(indent); ok && (shared < 0 || int(width) < shared) {
+			shared = int(width)
+		}
+	}
+
+	pattern := Pattern{}
+	for _, element := range elements {
+		if width, ok := element.(indent); ok {
+			element = Text(strings.Repeat(" ", int(width)-shared))
+		}
+
+		if text, ok := element.(Text); ok {
+			if len(text) == 0 {
+				continue
+			}
+			if n := len(pattern); n > 0 {
+				if prev, ok := pattern[n-1].(Text); ok {
+					pattern[n-1] = prev + text
+					continue
+				}
+			}
+		}
+		pattern = append(pattern, element)
+	}
+
+	if n := len(pattern); n > 0 {
+		if text, ok := pattern[n-1].(Text); ok {
+			pattern[n-1] = Text(strings.TrimRight(string(text), " \n"))
+			if len(pattern[n-1].(Text)) == 0 {
+				pattern = pattern[:n-1]
+			}
+		}
+	}
+
+	if len(pattern) == 0 {
+		return nil
+	}
+	return pattern
+}
+
+func (p *parser) placeable() (*Placeable, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	p.blank()
+
+	var expr any
+	var err error
+	if p.peek() == '{' {
+		expr, err = p.placeable()
+	} else {
+		expr, err = p.expression()
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.blank()
+
+	if p.peek() == '-' && p.at(p.pos+1) == '>' {
+		p.pos += 2
+		if expr, err = p.select_(expr); err != nil {
+			return nil, err
+		}
+		p.blank()
+	}
+
+	if err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	return &Placeable{Expr: expr}, nil
+}
+
+func (p *parser) select_(selector any) (*Select, error) {
+	s := &Select{Selector: selector}
+	defaults := 0
+
+	for {
+		p.blank()
+		if p.peek() == '}' {
+			break
+		}
+
+		variant := &Variant{}
+		if p.peek() == '*' {
+			p.next()
+			variant.IsDefault = true
+			defaults++
+		}
+		if err := p.expect('['); err != nil {
+			return nil, err
+		}
+		p.inline()
+
+		if r := p.peek(); r == '-' || unicode.IsDigit(r) {
+			number, err := p.number()
+			if err != nil {
+				return nil, err
+			}
+			variant.Key, variant.IsNumeric = number, true
+		} else {
+			key, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			variant.Key = key
+		}
+
+		p.inline()
+		if err := p.expect(']'); err != nil {
+			return nil, err
+		}
+		p.inline()
+
+		value, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+		variant.Value = value
+		s.Variants = append(s.Variants, variant)
+	}
+
+	if defaults != 1 {
+		return nil, p.errorf("a select expression needs exactly one default variant")
+	}
+	return s, nil
+}
+
+func (p *parser) expression() (any, error) {
+	switch r := p.peek(); {
+	case r == '"':
+		return p.string_()
+	case unicode.IsDigit(r) || r == '-' && unicode.IsDigit(p.at(p.pos+1)):
+		number, err := p.number()
+		return &NumberLiteral{Value: number}, err
+	case r == '$':
+		p.next()
+		name, err := p.identifier()
+		return &VariableRef{Name: name}, err
+	case r == '-':
+		p.next()
+		ref, err := p.reference()
+		if err != nil {
+			return nil, err
+		}
+		ref.IsTerm = true
+
+		p.blank()
+		if p.peek() == '(' {
+			_, named, err := p.arguments()
+			if err != nil {
+				return nil, err
+			}
+			ref.Args = named
+		}
+		return ref, nil
+	case isAlpha(r):
+		ref, err := p.reference()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != '(' {
+			return ref, nil
+		}
+		if len(ref.Attribute) > 0 {
+			return nil, p.errorf("expected a function")
+		}
+		args, named, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		return &FunctionRef{Name: ref.Name, Args: args, Named: named}, nil
+	default:
+		return nil, p.errorf("expected an expression")
+	}
+}
+
+func (p *parser) reference() (*MessageRef, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	ref := &MessageRef{Name: name}
+
+	if p.peek() == '.' {
+		p.next()
+		if ref.Attribute, err = p.identifier(); err != nil {
+			return nil, err
+		}
+	}
+	return ref, nil
+}
+
+func (p *parser) arguments() ([]any, []*NamedArg, error) {
+	if err := p.expect('('); err != nil {
+		return nil, nil, err
+	}
+
+	args := []any{}
+	named := []*NamedArg{}
+	for {
+		p.blank()
+		if p.peek() == ')' {
+			p.next()
+			return args, named, nil
+		}
+
+		arg, err := p.expression()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.blank()
+
+		if ref, ok := arg.(*MessageRef); ok && p.peek() == ':' && !ref.IsTerm && len(ref.Attribute) == 0 {
+			p.next()
+			p.blank()
+			value, err := p.expression()
+			if err != nil {
+				return nil, nil, err
+			}
+			named = append(named, &NamedArg{Name: ref.Name, Value: value})
+			p.blank()
+		} else {
+			args = append(args, arg)
+		}
+
+		if p.peek() == ',' {
+			p.next()
+		} else if p.peek() != ')' {
+			return nil, nil, p.errorf("expected ',' or ')'")
+		}
+	}
+}
+
+func (p *parser) string_() (*StringLiteral, error) {
+	p.next()
+	b := strings.Builder{}
+
+	for {
+		switch r := p.next(); r {
+		case '"':
+			return &StringLiteral{Value: b.String()}, nil
+		case 0, '\n':
+			return nil, p.errorf("unterminated string")
+		case '\\':
+			switch e := p.next(); e {
+			case '"', '\\':
+				b.WriteRune(e)
+			case 'u', 'U':
+				size := 4
+				if e == 'U' {
+					size = 6
+				}
+				var code rune
+				for i := 0; i < size; i++ {
+					digit := p.next()
+					value, ok := hex(digit)
+					if !ok {
+						return nil, p.errorf("invalid escape")
+					}
+					code = code*16 + value
+				}
+				b.WriteRune(code)
+			default:
+				return nil, p.errorf("invalid escape \\%c", e)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func (p *parser) number() (string, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.next()
+	}
+	if !unicode.IsDigit(p.peek()) {
+		return "", p.errorf("expected a number")
+	}
+	for unicode.IsDigit(p.peek()) {
+		p.next()
+	}
+	if p.peek() == '.' {
+		p.next()
+		for unicode.IsDigit(p.peek()) {
+			p.next()
+		}
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+func hex(r rune) (rune, bool) {
+	switch {
+	case r >= '0' && r <= '9':
+		return r - '0', true
+	case r >= 'a' && r <= 'f':
+		return r - 'a' + 10, true
+	case r >= 'A' && r <= 'F':
+		return r - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
+
+func isAlpha(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
@@ -0,0 +1,229 @@
+package ftl_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ftl"
+	"github.com/CanPacis/lcl/parser/printer"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `declare shop (en tr)
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+fn(n:int) half n / 2
+
+section cart {
+  # The heading
+  # of the page
+  title {
+    en "Your {cart}"
+    tr "Sepetiniz"
+  }
+
+  summary(name:string n:int) {
+    en ` + "`{name}, {n == 0 ? \"no items\" : \"some \" + plural(n)}`" + `
+    tr ` + "`{name}, {n} ürün`" + `
+  }
+
+  total(n:int) {
+    en ` + "`{half(n)}`" + `
+    tr ` + "`{n}`" + `
+  }
+
+  section stock {
+    empty(ok:bool) {
+      en ` + "`Out of stock\n  for now{ok ? \"!\" : \"\"}`" + `
+      tr "Stokta yok"
+    }
+  }
+}
+`
+
+func TestFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	out := test.MustScan(source)
+	files, err := ftl.New(out).Files()
+	assert.ErrorIs(err, errs.ErrUnrepresentable)
+	assert.ErrorContains(err, "export error: fn half")
+	assert.ErrorContains(err, "export error: cart.total (en)")
+
+	// Selects are nested where ternaries are and calls are inlined
+	assert.Equal(`# The heading
+# of the page
+cart-title = Your { "{" }cart{ "}" }
+
+cart-summary =
+    { $name }, { $n ->
+        [0] no items
+       *[other] some { $n ->
+                [1] item
+               *[other] items
+            }
+    }
+
+cart-stock-empty =
+    Out of stock
+    { "  " }for now{ $ok ->
+        [true] !
+       *[other] { "" }
+    }
+`, string(files["en.ftl"]))
+
+	assert.Equal(`# The heading
+# of the page
+cart-title = Sepetiniz
+
+cart-summary = { $name }, { $n } ürün
+
+cart-total = { $n }
+
+cart-stock-empty = Stokta yok
+`, string(files["tr.ftl"]))
+
+	resource, err := ftl.Parse(string(files["en.ftl"]))
+	assert.NoError(err)
+	assert.Len(resource.Messages, 3)
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	resource, err := ftl.Parse(`## Group comments are skipped
+
+-brand = Shop
+    .gender = neuter
+
+# Shown on top
+#
+# of the page
+cart-title = Your { -brand } cart
+cart-summary =
+    Hello
+      { $name },
+    { NUMBER($n, minimumFractionDigits: 2) ->
+        [0] no items
+        [one] { $n } item
+       *[other] { $n } items
+    }
+cart-empty = { "{" }literal{ "A" }
+    .title = Empty
+`)
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.Len(resource.Terms, 1)
+	assert.Equal(ftl.Pattern{ftl.Text("Shop")}, resource.Term("brand").Value)
+	assert.Equal("gender", resource.Term("brand").Attributes[0].Name)
+
+	title := resource.Message("cart-title")
+	assert.Equal([]string{"Shown on top", "", "of the page"}, title.Comments)
+	assert.Equal(ftl.Pattern{
+		ftl.Text("Your "),
+		&ftl.Placeable{Expr: &ftl.MessageRef{Name: "brand", IsTerm: true}},
+		ftl.Text(" cart"),
+	}, title.Value)
+
+	summary := resource.Message("cart-summary")
+	assert.Equal(ftl.Text("Hello\n  "), summary.Value[0])
+	assert.Equal(&ftl.Placeable{Expr: &ftl.VariableRef{Name: "name"}}, summary.Value[1])
+	assert.Equal(ftl.Text(",\n"), summary.Value[2])
+	selector := summary.Value[3].(*ftl.Placeable).Expr.(*ftl.Select)
+	assert.Equal("NUMBER", selector.Selector.(*ftl.FunctionRef).Name)
+	assert.Len(selector.Variants, 3)
+	assert.True(selector.Variants[0].IsNumeric)
+	assert.True(selector.Variants[2].IsDefault)
+
+	empty := resource.Message("cart-empty")
+	assert.Equal("title", empty.Attributes[0].Name)
+	assert.Nil(resource.Message("missing"))
+
+	_, err = ftl.Parse("cart = { $n ->\n    [one] item\n}\n")
+	assert.ErrorIs(err, errs.ErrMalformedFile)
+	_, err = ftl.Parse("cart = {\n")
+	assert.ErrorIs(err, errs.ErrMalformedFile)
+	_, err = ftl.Parse("-term =\n    .a = b\n")
+	assert.ErrorContains(err, "line 3: term -term has no value")
+}
+
+func TestWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	resource := &ftl.Resource{Messages: []*ftl.Message{
+		{ID: "a", Value: ftl.Pattern{ftl.Text("[x] {braces} ")}},
+		{ID: "b", Value: ftl.Pattern{ftl.Text("\n  two\n* lines\n")}},
+		{ID: "c", Value: ftl.Pattern{}},
+	}}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(resource.Write(buf))
+
+	parsed, err := ftl.Parse(buf.String())
+	if !assert.NoError(err) {
+		return
+	}
+	for i, message := range resource.Messages {
+		value := ftl.Pattern{}
+		for _, element := range parsed.Messages[i].Value {
+			if p, ok := element.(*ftl.Placeable); ok {
+				element = ftl.Text(p.Expr.(*ftl.StringLiteral).Value)
+			}
+			if element == ftl.Text("") {
+				continue
+			}
+			if n := len(value); n > 0 {
+				value[n-1] = value[n-1].(ftl.Text) + element.(ftl.Text)
+				continue
+			}
+			value = append(value, element)
+		}
+		assert.Equal(message.Value, value, buf.String())
+	}
+}
+
+func TestImport(t *testing.T) {
+	assert := assert.New(t)
+
+	tree, out := test.MustScanFile(test.WithSourceString(source))
+	resource, err := ftl.Parse(`-shop = Dükkan
+cart-title = { -shop } { "{" }sepeti{ "}" }
+    .tooltip = Sepet
+cart-summary =
+    { $name }, { $n ->
+        [one] { NUMBER($n) } ürün
+       *[other] { $n } ürünler
+    }
+cart-total = { DATETIME($n) }
+cart-stock-empty = { $ok ->
+        [true] Stokta yok
+       *[false] Stokta yok!
+    }
+cart-missing = Eksik
+`)
+	if !assert.NoError(err) {
+		return
+	}
+
+	err = ftl.Import(tree, out, out.Locales[1], resource)
+	assert.ErrorIs(err, errs.ErrUnknownMessage)
+	assert.ErrorContains(err, "import error: cart-missing (tr), unknown message")
+	assert.ErrorContains(err, "import error: cart.title (tr), attribute .tooltip in fluent cannot be represented in lcl")
+	assert.ErrorContains(err, "import error: cart.total (tr), DATETIME($n) in fluent cannot be represented in lcl")
+
+	// Terms are inlined and the value of a message is imported even if its
+	// attributes are not
+	printed := printer.String(tree)
+	assert.Contains(printed, "    tr \"Dükkan {sepeti}\"\n")
+	assert.Contains(printed, "    tr `{name}, {n}{n == 1 ? \" ürün\" : \" ürünler\"}`\n")
+	assert.Contains(printed, "    tr `{n}`\n")
+	assert.Contains(printed, "      tr `{ok ? \"Stokta yok\" : \"Stokta yok!\"}`\n")
+
+	out = test.MustScan(printed)
+	assert.Len(out.Locales, 2)
+}
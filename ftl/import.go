@@ -0,0 +1,243 @@
+package ftl
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/icu"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser/ast"
+)
+
+// Import sets the fields of a locale in the source of a package from the
+// messages of a resource. Ids are matched against the dashed paths of
+// messages and terms and other messages are inlined. Templates are read
+// through their ICU MessageFormat equivalent so selects on numbers become
+// plurals. Messages that cannot be imported are reported together once the
+// rest is set.
+func Import(tree *ast.File, out *ir.IR, locale ir.Locale, resource *Resource) error {
+	entries := ast.Entries(tree)
+	messages := map[string]*ir.Message{}
+	paths := map[string]string{}
+
+	var walk func(s *ir.Section, path string)
+	walk = func(s *ir.Section, path string) {
+		for _, message := range s.Messages {
+			messages[path+message.Name] = message
+			paths[ID(path+message.Name)] = path + message.Name
+		}
+		for _, sub := range s.Sections {
+			walk(sub, path+sub.Name+".")
+		}
+	}
+	for _, s := range out.Sections {
+		walk(s, s.Name+".")
+	}
+
+	failures := []error{}
+	fail := func(path string, err error) {
+		var e *errs.ImportError
+		if errors.As(err, &e) {
+			e.Path = path
+			e.Locale = locale.Name
+		}
+		failures = append(failures, err)
+	}
+
+	for _, m := range resource.Messages {
+		path, ok := paths[m.ID]
+		node, found := entries[path]
+		if !ok || !found || m.Value == nil {
+			fail(m.ID, &errs.ImportError{Err: errs.ErrUnknownMessage, Format: "fluent"})
+			continue
+		}
+		for _, attr := range m.Attributes {
+			fail(path, untranslatable("attribute ."+attr.Name))
+		}
+
+		message := messages[path]
+		t := &translator{resource: resource, seen: map[string]bool{m.ID: true}, plain: !message.IsTemplate}
+		pattern, err := t.pattern(m.Value, "")
+		if err != nil {
+			fail(path, err)
+			continue
+		}
+
+		if !message.IsTemplate {
+			ast.SetField(node, locale.Name, ast.NewText(pattern))
+			continue
+		}
+
+		expr, err := icu.Import(pattern, message.Params, locale.Tag)
+		if err != nil {
+			fail(path, err)
+			continue
+		}
+		ast.SetField(node, locale.Name, expr)
+	}
+
+	return errors.Join(failures...)
+}
+
+// translator returns patterns as ICU MessageFormat patterns, or as plain text
+// for keys which have no params
+type translator struct {
+	resource *Resource
+	// seen holds the messages and terms being inlined
+	seen  map[string]bool
+	plain bool
+}
+
+// pattern returns the ICU pattern of a Fluent one, subject is the variable of
+// the plural the pattern is an option of. Inside of one # is syntax and
+// stands for the variable.
+func (t *translator) pattern(p Pattern, subject string) (string, error) {
+	b := strings.Builder{}
+	for _, element := range p {
+		switch element := element.(type) {
+		case Text:
+			b.WriteString(t.text(string(element), subject))
+		case *Placeable:
+			s, err := t.expression(element.Expr, subject)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		}
+	}
+	return b.String(), nil
+}
+
+func (t *translator) text(s string, subject string) string {
+	switch {
+	case t.plain:
+		return s
+	case len(subject) > 0:
+		return icu.EscapePlural(s)
+	default:
+		return icu.Escape(s)
+	}
+}
+
+func (t *translator) expression(expr any, subject string) (string, error) {
+	switch expr := expr.(type) {
+	case *StringLiteral:
+		return t.text(expr.Value, subject), nil
+	case *NumberLiteral:
+		return t.text(expr.Value, subject), nil
+	case *VariableRef:
+		if t.plain {
+			return "", untranslatable("variable $" + expr.Name + " in a key")
+		}
+		if expr.Name == subject {
+			return "#", nil
+		}
+		return "{" + expr.Name + "}", nil
+	case *FunctionRef:
+		if expr.Name == "NUMBER" && len(expr.Args) == 1 && len(expr.Named) == 0 {
+			return t.expression(expr.Args[0], subject)
+		}
+		return "", untranslatable(expression(expr))
+	case *MessageRef:
+		return t.reference(expr, subject)
+	case *Placeable:
+		return t.expression(expr.Expr, subject)
+	case *Select:
+		if t.plain {
+			return "", untranslatable("select in a key")
+		}
+		return t.choice(expr)
+	default:
+		return "", untranslatable(expression(expr))
+	}
+}
+
+// reference inlines the value of a term or a message
+func (t *translator) reference(ref *MessageRef, subject string) (string, error) {
+	if len(ref.Attribute) > 0 || len(ref.Args) > 0 {
+		return "", untranslatable(expression(ref))
+	}
+
+	id := ref.Name
+	target := t.resource.Message(id)
+	if ref.IsTerm {
+		id = "-" + id
+		target = t.resource.Term(ref.Name)
+	}
+	if target == nil || target.Value == nil {
+		return "", untranslatable("reference to unknown " + id)
+	}
+	if t.seen[id] {
+		return "", untranslatable("recursive reference to " + id)
+	}
+
+	t.seen[id] = true
+	defer delete(t.seen, id)
+	return t.pattern(target.Value, subject)
+}
+
+// choice returns the plural or the select of a select expression. Numeric
+// keys and plural categories make a plural, the default variant becomes the
+// other option.
+func (t *translator) choice(s *Select) (string, error) {
+	ref, ok := s.Selector.(*VariableRef)
+	if fn, isFn := s.Selector.(*FunctionRef); isFn && fn.Name == "NUMBER" && len(fn.Args) == 1 && len(fn.Named) == 0 {
+		ref, ok = fn.Args[0].(*VariableRef)
+	}
+	if !ok {
+		return "", untranslatable("select on " + expression(s.Selector))
+	}
+
+	plural := false
+	for _, variant := range s.Variants {
+		plural = plural || variant.IsNumeric || category(variant.Key)
+	}
+
+	b := strings.Builder{}
+	b.WriteString("{" + ref.Name)
+	if plural {
+		b.WriteString(", plural,")
+	} else {
+		b.WriteString(", select,")
+	}
+
+	for _, variant := range s.Variants {
+		key := variant.Key
+		switch {
+		case variant.IsDefault:
+			key = "other"
+		case key == "other":
+			continue
+		case variant.IsNumeric:
+			key = "=" + key
+		}
+
+		inner := ""
+		if plural {
+			inner = ref.Name
+		}
+		value, err := t.pattern(variant.Value, inner)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(" " + key + " {" + value + "}")
+	}
+
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// category reports whether a key is a CLDR plural category other than other,
+// which selects have as well
+func category(key string) bool {
+	switch key {
+	case "zero", "one", "two", "few", "many":
+		return true
+	}
+	return false
+}
+
+func untranslatable(value string) error {
+	return &errs.ImportError{Err: errs.ErrUnrepresentable, Format: "fluent", Value: value}
+}
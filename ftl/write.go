@@ -0,0 +1,225 @@
+package ftl
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Write writes the terms and the messages of the resource, the comments of
+// an entry above it. Patterns that span lines or hold selects start on a
+// line of their own.
+func (r *Resource) Write(w io.Writer) error {
+	b := &strings.Builder{}
+
+	entries := []*Message{}
+	entries = append(entries, r.Terms...)
+	entries = append(entries, r.Messages...)
+
+	for i, entry := range entries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, comment := range entry.Comments {
+			b.WriteString(strings.TrimRight("# "+comment, " ") + "\n")
+		}
+
+		id := entry.ID
+		if i < len(r.Terms) {
+			id = "-" + id
+		}
+		b.WriteString(id + " =")
+		if entry.Value != nil {
+			b.WriteString(value(entry.Value))
+		}
+		for _, attr := range entry.Attributes {
+			b.WriteString("\n" + indentation(1) + "." + attr.Name + " =" + value(attr.Value))
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// value returns a pattern as it follows the equals sign of an entry
+func value(p Pattern) string {
+	s := serialize(p, 1)
+	if strings.Contains(s, "\n") {
+		return "\n" + indentation(1) + s
+	}
+	return " " + s
+}
+
+// serialize returns the source of a pattern whose lines are indented by depth
+func serialize(p Pattern, depth int) string {
+	if len(p) == 0 {
+		return `{ "" }`
+	}
+
+	b := &strings.Builder{}
+	start := true
+
+	for i, element := range p {
+		switch element := element.(type) {
+		case Text:
+			// Fluent trims the new lines a pattern starts or ends with
+			s := string(element)
+			if i == 0 && strings.HasPrefix(s, "\n") {
+				trimmed := strings.TrimLeft(s, "\n")
+				b.WriteString(literal(s[:len(s)-len(trimmed)]))
+				s, start = trimmed, false
+			}
+			trailing := ""
+			if i == len(p)-1 {
+				trimmed := strings.TrimRight(s, "\n")
+				trailing, s = s[len(trimmed):], trimmed
+			}
+
+			lines := strings.Split(s, "\n")
+			for j, line := range lines {
+				if j > 0 {
+					b.WriteString("\n")
+					if len(line) > 0 {
+						b.WriteString(indentation(depth))
+					}
+					start = true
+				}
+
+				last := i == len(p)-1 && j == len(lines)-1
+				text(b, line, start, last && len(trailing) == 0)
+				start = start && len(line) == 0
+			}
+			if len(trailing) > 0 {
+				b.WriteString(literal(trailing))
+			}
+		case *Placeable:
+			b.WriteString(placeable(element, depth))
+			start = false
+		}
+	}
+
+	return b.String()
+}
+
+// text writes a line of text, the braces and the characters that Fluent
+// would read as syntax at the start of a line are written as string literals
+// and so are the spaces it would trim
+func text(b *strings.Builder, line string, start, last bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if start && len(trimmed) < len(line) {
+		b.WriteString(literal(line[:len(line)-len(trimmed)]))
+		line = trimmed
+	}
+
+	trailing := ""
+	if last {
+		trimmed := strings.TrimRight(line, " ")
+		trailing, line = line[len(trimmed):], trimmed
+	}
+
+	for i, r := range line {
+		switch {
+		case r == '{' || r == '}':
+			b.WriteString(literal(string(r)))
+		case i == 0 && start && strings.ContainsRune("[*.", r):
+			b.WriteString(literal(string(r)))
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	if len(trailing) > 0 {
+		b.WriteString(literal(trailing))
+	}
+}
+
+func placeable(p *Placeable, depth int) string {
+	if s, ok := p.Expr.(*Select); ok {
+		b := &strings.Builder{}
+		b.WriteString("{ " + expression(s.Selector) + " ->\n")
+		for _, variant := range s.Variants {
+			prefix := indentation(depth + 1)
+			if variant.IsDefault {
+				prefix = prefix[:len(prefix)-1] + "*"
+			}
+			b.WriteString(prefix + "[" + variant.Key + "] " + serialize(variant.Value, depth+2) + "\n")
+		}
+		b.WriteString(indentation(depth) + "}")
+		return b.String()
+	}
+
+	return "{ " + expression(p.Expr) + " }"
+}
+
+func expression(expr any) string {
+	switch expr := expr.(type) {
+	case *StringLiteral:
+		return quote(expr.Value)
+	case *NumberLiteral:
+		return expr.Value
+	case *VariableRef:
+		return "$" + expr.Name
+	case *MessageRef:
+		s := expr.Name
+		if expr.IsTerm {
+			s = "-" + s
+		}
+		if len(expr.Attribute) > 0 {
+			s += "." + expr.Attribute
+		}
+		if len(expr.Args) > 0 {
+			s += "(" + named(expr.Args) + ")"
+		}
+		return s
+	case *FunctionRef:
+		args := []string{}
+		for _, arg := range expr.Args {
+			args = append(args, expression(arg))
+		}
+		if len(expr.Named) > 0 {
+			args = append(args, named(expr.Named))
+		}
+		return expr.Name + "(" + strings.Join(args, ", ") + ")"
+	case *Placeable:
+		return placeable(expr, 1)
+	default:
+		return `""`
+	}
+}
+
+func named(args []*NamedArg) string {
+	list := []string{}
+	for _, arg := range args {
+		list = append(list, arg.Name+": "+expression(arg.Value))
+	}
+	return strings.Join(list, ", ")
+}
+
+// literal returns the placeable of a string literal
+func literal(s string) string {
+	return "{ " + quote(s) + " }"
+}
+
+func quote(s string) string {
+	b := strings.Builder{}
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < ' ':
+			code := strconv.FormatInt(int64(r), 16)
+			b.WriteString(`\u` + strings.Repeat("0", 4-len(code)) + code)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func indentation(depth int) string {
+	return strings.Repeat("    ", depth)
+}
@@ -0,0 +1,196 @@
+// Package androidgen exports the messages of a package as Android string
+// resources. Keys and templates become strings named after their paths and
+// templates that select by a count become plurals, params are formatted by
+// their position.
+package androidgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/gen/internal/printf"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+	"golang.org/x/text/language"
+)
+
+const indent = "    "
+
+type Generator struct {
+	ir *ir.IR
+}
+
+// Files returns the resources of every locale, the source locale, the first
+// one the package declares, is written to values/strings.xml and the others
+// to the directories of their qualifiers. The files are returned even if
+// some messages could not be exported, see Generate.
+func (g *Generator) Files() (map[string][]byte, error) {
+	if len(g.ir.Locales) == 0 {
+		return nil, errors.New("package declares no locales")
+	}
+
+	files := map[string][]byte{}
+	failures := []error{}
+
+	for i, locale := range g.ir.Locales {
+		buf := &bytes.Buffer{}
+		if err := g.Generate(buf, locale); err != nil {
+			if !errs.IsExportError(err) {
+				return nil, err
+			}
+			failures = append(failures, err)
+		}
+
+		dir := "values"
+		if i > 0 {
+			dir += "-" + Qualifier(locale.Tag)
+		}
+		files[dir+"/strings.xml"] = buf.Bytes()
+	}
+
+	return files, errors.Join(failures...)
+}
+
+// Generate writes the resources of a locale. A template that selects by a
+// count in any locale is written as plurals in all of them so that they
+// share a resource type. Messages that cannot be represented are left out
+// and reported together once the rest is written.
+func (g *Generator) Generate(w io.Writer, locale ir.Locale) error {
+	ir.Optimize(g.ir)
+	failures := []error{}
+
+	b := &strings.Builder{}
+	b.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<resources>\n")
+
+	var section func(s *ir.Section, path string)
+	section = func(s *ir.Section, path string) {
+		for _, message := range s.Messages {
+			name := strings.ReplaceAll(path+message.Name, ".", "_")
+			if message.Values[locale.Tag] == nil {
+				continue
+			}
+
+			m, err := printf.Convert(message, locale, config)
+			if err != nil {
+				failures = append(failures, errs.Locate(err, path+message.Name, locale.Name))
+				continue
+			}
+
+			if !g.isPlural(message) {
+				attr := ""
+				if !message.IsTemplate && strings.Contains(m.Other(), "%") {
+					attr = ` formatted="false"`
+				}
+				fmt.Fprintf(b, "%s<string name=\"%s\"%s>%s</string>\n", indent, name, attr, escape(m.Other()))
+				continue
+			}
+
+			fmt.Fprintf(b, "%s<plurals name=\"%s\">\n", indent, name)
+			for _, form := range m.Forms {
+				fmt.Fprintf(b, "%s<item quantity=\"%s\">%s</item>\n", indent+indent, form.Category, escape(form.Format))
+			}
+			fmt.Fprintf(b, "%s</plurals>\n", indent)
+		}
+
+		for _, sub := range s.Sections {
+			section(sub, path+sub.Name+".")
+		}
+	}
+
+	for _, s := range g.ir.Sections {
+		section(s, s.Name+".")
+	}
+
+	b.WriteString("</resources>\n")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+// isPlural reports whether a message selects by a count in any locale
+func (g *Generator) isPlural(message *ir.Message) bool {
+	for _, locale := range g.ir.Locales {
+		if message.Values[locale.Tag] == nil {
+			continue
+		}
+		if m, err := printf.Convert(message, locale, config); err == nil && m.IsPlural() {
+			return true
+		}
+	}
+	return false
+}
+
+var config = &printf.Config{Format: "android", Verb: verb}
+
+// verb returns the java format conversion of a param
+func verb(typ types.Type) string {
+	switch printf.Kind(typ) {
+	case "rune":
+		return "c"
+	case "bool":
+		return "b"
+	case "i8", "i16", "i32", "i64", "u8", "u16", "u32", "u64":
+		return "d"
+	case "f32", "f64":
+		return "f"
+	default:
+		return "s"
+	}
+}
+
+// Qualifier returns the resource qualifier of a language, a language and a
+// region are written as en-rUS and other tags in the form of BCP 47
+func Qualifier(tag language.Tag) string {
+	base, script, region := tag.Raw()
+	if script.String() == "Zzzz" && len(tag.Variants()) == 0 && len(tag.Extensions()) == 0 {
+		if region.String() == "ZZ" {
+			return base.String()
+		}
+		return base.String() + "-r" + region.String()
+	}
+	return "b+" + strings.ReplaceAll(tag.String(), "-", "+")
+}
+
+// escape escapes the text of a resource, aapt reads quotes, backslashes and
+// a leading @ or ? as syntax and collapses white space
+func escape(s string) string {
+	b := strings.Builder{}
+	space := true
+
+	for i, r := range s {
+		switch {
+		case r == '\'' || r == '"' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case (r == '@' || r == '?') && i == 0:
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString("\\n")
+		case r == '\t':
+			b.WriteString("\\t")
+		case r == ' ' && (space || i == len(s)-1):
+			b.WriteString("\\u0020")
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		default:
+			b.WriteRune(r)
+		}
+		space = r == ' ' || r == '\n' || r == '\t'
+	}
+
+	return b.String()
+}
+
+func New(out *ir.IR) *Generator {
+	return &Generator{ir: out}
+}
@@ -0,0 +1,76 @@
+package androidgen_test
+
+import (
+	"testing"
+
+	"github.com/CanPacis/lcl/errs"
+	androidgen "github.com/CanPacis/lcl/gen/android"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+const source = `declare shop (en "pt-BR" as pt)
+
+type Money f64
+
+section cart {
+  title {
+    en "Your <cart> isn't empty"
+    pt "@Seu carrinho  100%"
+  }
+
+  summary(name:string n:int) {
+    en ` + "`{name}, {n} {n == 1 ? \"item\" : \"items\"} left`" + `
+    pt ` + "`{name}, {n} itens`" + `
+  }
+
+  total(price:Money ok:bool) {
+    en ` + "`{price} 100% {ok}`" + `
+    pt ` + "`{price}`" + `
+  }
+
+  empty(n:int) {
+    en ` + "`{n == 0 ? \"No items\" : \"Items\"}`" + `
+    pt ` + "`Itens`" + `
+  }
+}
+`
+
+func TestFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	files, err := androidgen.New(test.MustScan(source)).Files()
+	assert.ErrorIs(err, errs.ErrUnrepresentable)
+	assert.EqualError(err, "export error: cart.empty (en), count 0 of n cannot be represented in android")
+
+	assert.Equal(`<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="cart_title">Your &lt;cart&gt; isn\'t empty</string>
+    <plurals name="cart_summary">
+        <item quantity="one">%1$s, %2$d item left</item>
+        <item quantity="other">%1$s, %2$d items left</item>
+    </plurals>
+    <string name="cart_total">%1$f 100%% %2$b</string>
+</resources>
+`, string(files["values/strings.xml"]))
+
+	assert.Equal(`<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="cart_title" formatted="false">\@Seu carrinho \u0020100%</string>
+    <plurals name="cart_summary">
+        <item quantity="other">%1$s, %2$d itens</item>
+    </plurals>
+    <string name="cart_total">%1$f</string>
+    <string name="cart_empty">Itens</string>
+</resources>
+`, string(files["values-pt-rBR/strings.xml"]))
+}
+
+func TestQualifier(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("tr", androidgen.Qualifier(language.MustParse("tr")))
+	assert.Equal("pt-rBR", androidgen.Qualifier(language.MustParse("pt-BR")))
+	assert.Equal("b+sr+Latn", androidgen.Qualifier(language.MustParse("sr-Latn")))
+}
@@ -0,0 +1,173 @@
+// Package arbgen exports the messages of a package as the Application
+// Resource Bundles Flutter localizes apps from. Every locale is written to a
+// bundle of its own, messages are named after their paths in camel case and
+// the bundle of the source locale describes them along with the params of
+// templates.
+package arbgen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/gen/internal/ordered"
+	"github.com/CanPacis/lcl/gen/internal/printf"
+	"github.com/CanPacis/lcl/icu"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+type Config struct {
+	prefix string
+}
+
+// WithPrefix sets the prefix of the names of bundles, app by default
+func WithPrefix(prefix string) func(*Config) {
+	return func(c *Config) {
+		c.prefix = prefix
+	}
+}
+
+type Generator struct {
+	config *Config
+	ir     *ir.IR
+}
+
+// Files returns the bundle of every locale named like app_pt_BR.arb. The files
+// are returned even if some messages could not be exported, see Generate.
+func (g *Generator) Files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	failures := []error{}
+
+	for _, locale := range g.ir.Locales {
+		buf := &bytes.Buffer{}
+		if err := g.Generate(buf, locale); err != nil {
+			if !errs.IsExportError(err) {
+				return nil, err
+			}
+			failures = append(failures, err)
+		}
+		files[g.config.prefix+"_"+Locale(locale)+".arb"] = buf.Bytes()
+	}
+
+	return files, errors.Join(failures...)
+}
+
+// Generate writes the bundle of a locale, messages are written as ICU
+// MessageFormat patterns whose arguments carry no format. The bundle of the
+// source locale, the template gen_l10n reads metadata from, gives every
+// message its description and the placeholders of templates their types.
+// Messages that cannot be represented are left out and reported together
+// once the rest is written.
+func (g *Generator) Generate(w io.Writer, locale ir.Locale) error {
+	ir.Optimize(g.ir)
+	failures := []error{}
+	template := len(g.ir.Locales) > 0 && g.ir.Locales[0].Tag == locale.Tag
+
+	bundle := ordered.Object{{Key: "@@locale", Value: Locale(locale)}}
+
+	var section func(s *ir.Section, path string)
+	section = func(s *ir.Section, path string) {
+		for _, message := range s.Messages {
+			value := message.Values[locale.Tag]
+			if value == nil {
+				continue
+			}
+
+			var pattern string
+			var err error
+			if lit, ok := value.(*ir.Literal); ok && !message.IsTemplate {
+				pattern = icu.Escape(lit.Text())
+			} else {
+				pattern, err = icu.ExportSimple(value)
+			}
+			if err != nil {
+				failures = append(failures, errs.Locate(err, path+message.Name, locale.Name))
+				continue
+			}
+
+			name := Name(path + message.Name)
+			bundle = append(bundle, ordered.Member{Key: name, Value: pattern})
+			if !template {
+				continue
+			}
+
+			metadata := ordered.Object{}
+			if len(message.Comment) > 0 {
+				metadata = append(metadata, ordered.Member{Key: "description", Value: strings.ReplaceAll(message.Comment, "\n", " ")})
+			}
+			if message.IsTemplate {
+				placeholders := ordered.Object{}
+				for _, param := range message.Params {
+					placeholders = append(placeholders, ordered.Member{Key: param.Name, Value: placeholder(param.Type)})
+				}
+				metadata = append(metadata, ordered.Member{Key: "placeholders", Value: placeholders})
+			}
+			if len(metadata) > 0 {
+				bundle = append(bundle, ordered.Member{Key: "@" + name, Value: metadata})
+			}
+		}
+
+		for _, sub := range s.Sections {
+			section(sub, path+sub.Name+".")
+		}
+	}
+
+	for _, s := range g.ir.Sections {
+		section(s, s.Name+".")
+	}
+
+	if err := ordered.Write(w, bundle); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+// Name returns the name of a message by its path, cart.stock.empty is named
+// cartStockEmpty
+func Name(path string) string {
+	b := strings.Builder{}
+	for i, part := range strings.Split(path, ".") {
+		r, size := utf8.DecodeRuneInString(part)
+		if i == 0 {
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+		b.WriteString(part[size:])
+	}
+	return b.String()
+}
+
+// Locale returns the name Flutter gives a locale, its tag with underscores
+func Locale(locale ir.Locale) string {
+	return strings.ReplaceAll(locale.Tag.String(), "-", "_")
+}
+
+// placeholder returns the metadata of a param, numbers are formatted by the
+// locale like the number arguments of ICU
+func placeholder(typ types.Type) ordered.Object {
+	switch printf.Kind(typ) {
+	case "string":
+		return ordered.Object{{Key: "type", Value: "String"}}
+	case "i8", "i16", "i32", "i64", "u8", "u16", "u32", "u64":
+		return ordered.Object{{Key: "type", Value: "int"}, {Key: "format", Value: "decimalPattern"}}
+	case "f32", "f64":
+		return ordered.Object{{Key: "type", Value: "double"}, {Key: "format", Value: "decimalPattern"}}
+	default:
+		return ordered.Object{{Key: "type", Value: "Object"}}
+	}
+}
+
+func New(out *ir.IR, options ...func(*Config)) *Generator {
+	config := &Config{prefix: "app"}
+	for _, option := range options {
+		option(config)
+	}
+
+	return &Generator{config: config, ir: out}
+}
@@ -0,0 +1,103 @@
+package arbgen_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CanPacis/lcl/errs"
+	arbgen "github.com/CanPacis/lcl/gen/arb"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `declare shop (en "pt-BR" as pt)
+
+type Money f64
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+section cart {
+  # The title of the cart page,
+  # keep it short
+  title {
+    en "Your {cart}"
+    pt "Seu carrinho"
+  }
+
+  # Shown below the items
+  summary(name:string n:int price:Money) {
+    en ` + "`{name}, {n == 1 ? \"one item\" : \"items\"} for {price}`" + `
+    pt ` + "`{name}, {n} {plural(n)}`" + `
+  }
+
+  section stock {
+    empty(ok:bool) {
+      en ` + "`Out of stock {ok}`" + `
+      pt ` + "`Esgotado`" + `
+    }
+  }
+}
+`
+
+func TestFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	out := test.MustScan(source)
+	files, err := arbgen.New(out, arbgen.WithPrefix("shop")).Files()
+	assert.ErrorIs(err, errs.ErrUnrepresentable)
+	assert.EqualError(err, "export error: cart.summary (pt), call of plural cannot be represented in icu")
+
+	assert.Equal(`{
+  "@@locale": "en",
+  "cartTitle": "Your '{'cart'}'",
+  "@cartTitle": {
+    "description": "The title of the cart page, keep it short"
+  },
+  "cartSummary": "{name}, {n, plural, =1 {one item} other {items}} for {price}",
+  "@cartSummary": {
+    "description": "Shown below the items",
+    "placeholders": {
+      "name": {
+        "type": "String"
+      },
+      "n": {
+        "type": "int",
+        "format": "decimalPattern"
+      },
+      "price": {
+        "type": "double",
+        "format": "decimalPattern"
+      }
+    }
+  },
+  "cartStockEmpty": "Out of stock {ok}",
+  "@cartStockEmpty": {
+    "placeholders": {
+      "ok": {
+        "type": "Object"
+      }
+    }
+  }
+}
+`, string(files["shop_en.arb"]))
+
+	assert.Equal(`{
+  "@@locale": "pt_BR",
+  "cartTitle": "Seu carrinho",
+  "cartStockEmpty": "Esgotado"
+}
+`, string(files["shop_pt_BR.arb"]))
+
+	// Keys without comments have no metadata
+	buf := &bytes.Buffer{}
+	assert.NoError(arbgen.New(test.MustScan(`declare app (en)
+
+section app {
+  hello {
+    en "Hello"
+  }
+}
+`)).Generate(buf, out.Locales[0]))
+	assert.Equal("{\n  \"@@locale\": \"en\",\n  \"appHello\": \"Hello\"\n}\n", buf.String())
+	assert.Equal("cartStockEmpty", arbgen.Name("Cart.stock.empty"))
+}
@@ -0,0 +1,74 @@
+package gogen_test
+
+import (
+	"bytes"
+	"fmt"
+	goparser "go/parser"
+	"os"
+	"testing"
+
+	"github.com/CanPacis/lcl/analyzer"
+	gogen "github.com/CanPacis/lcl/gen/go"
+	"github.com/CanPacis/lcl/gen/go/internal/catalog"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+// The catalog package is generated from catalog.lcl, this keeps it in sync
+// with the generator and makes sure rendering does not depend on fmt
+func TestCatalog(t *testing.T) {
+	assert := assert.New(t)
+
+	source, err := os.ReadFile("internal/catalog/catalog.lcl")
+	assert.NoError(err)
+	file := parser.NewFile("catalog.lcl", bytes.NewBuffer(source))
+	tree := test.MustParse(test.WithFile(file))
+	out, err := analyzer.New(file, tree, pkg.New("catalog")).Scan()
+	assert.NoError(err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(gogen.New(out, gogen.WithRoot("Catalog")).Generate(buf))
+
+	generated, err := os.ReadFile("internal/catalog/catalog.go")
+	assert.NoError(err)
+	assert.Equal(string(generated), buf.String())
+
+	f, err := goparser.ParseFile(fset, "catalog.go", generated, goparser.ImportsOnly)
+	assert.NoError(err)
+	for _, spec := range f.Imports {
+		assert.NotContains([]string{`"fmt"`, `"reflect"`}, spec.Path.Value)
+	}
+
+	assert.Equal(
+		"Merhaba Ada, 3 okunmamış mesajın var (1.5 MB), sıradaki #4. Arşivlendi: false",
+		catalog.Local(language.Turkish).Inbox.Summary("Ada", 3, 1.5, false),
+	)
+}
+
+var (
+	name     = "Ada"
+	unread   = 3
+	size     = 1.5
+	archived = false
+)
+
+func BenchmarkTemplate(b *testing.B) {
+	inbox := catalog.Local(language.English).Inbox
+	b.ReportAllocs()
+
+	for range b.N {
+		inbox.Summary(name, unread, size, archived)
+	}
+}
+
+// BenchmarkSprintf is the baseline the generated code is measured against
+func BenchmarkSprintf(b *testing.B) {
+	b.ReportAllocs()
+
+	for range b.N {
+		_ = fmt.Sprintf("Hello %v, you have %v unread messages (%v MB), next is #%v. Archived: %v", name, unread, size, unread+1, archived)
+	}
+}
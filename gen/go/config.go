@@ -4,11 +4,17 @@ type Config struct {
 	root  string
 	local string
 	fn    string
+	// imports maps the names of imported lcl packages to the go import paths
+	// of their generated code
+	imports map[string]string
+	hash    string
 }
 
+// WithRoot sets the name of the type that holds the top level sections, it is
+// exported since Local returns it
 func WithRoot(root string) func(*Config) {
 	return func(c *Config) {
-		c.root = lower(root)
+		c.root = capitalize(root)
 	}
 }
 
@@ -23,3 +29,19 @@ func WithFn(fn string) func(*Config) {
 		c.fn = lower(fn)
 	}
 }
+
+// WithImport sets the go import path of the generated code of an imported
+// package
+func WithImport(name, path string) func(*Config) {
+	return func(c *Config) {
+		c.imports[name] = path
+	}
+}
+
+// WithHash records the hash of the sources in the header of the generated
+// code, see Hash
+func WithHash(hash string) func(*Config) {
+	return func(c *Config) {
+		c.hash = hash
+	}
+}
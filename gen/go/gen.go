@@ -1,21 +1,557 @@
 package gogen
 
 import (
-	pkg "github.com/CanPacis/lcl/package"
-	"github.com/CanPacis/lcl/types"
+	"bytes"
+	"errors"
+	goast "go/ast"
+	"go/format"
+	gotoken "go/token"
+	"io"
+	pathpkg "path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
 )
 
+const header = "// Code generated by lcl. DO NOT EDIT.\n"
+
+// hashPrefix starts the header line that records the hash of the sources the
+// code was generated from
+const hashPrefix = "// lcl:hash "
+
+// packages maps the package names generated code may refer to, to their
+// import paths. The standard library is imported under names that start with
+// an underscore, which lcl names cannot, so that params never shadow them.
+var packages = map[string]string{
+	"_math":    "math",
+	"_strconv": "strconv",
+	"_strings": "strings",
+	"language": "golang.org/x/text/language",
+	runtime:    "github.com/CanPacis/lcl",
+}
+
 type Generator struct {
 	config *Config
-	scope  *pkg.Scope
-	env    *types.Environment
+	ir     *ir.IR
+	types  *Resolver
+}
+
+func (g *Generator) Generate(w io.Writer) error {
+	ir.Optimize(g.ir)
+	if err := g.check(); err != nil {
+		return err
+	}
+	decls := []goast.Decl{}
+
+	for _, def := range g.ir.TypeDefs {
+		decls = append(decls, g.types.TypeDefDecl(&def))
+	}
+
+	// Struct declarations are only known after everything else is resolved,
+	// they are placed after the named types
+	n := len(decls)
+
+	for _, fn := range g.ir.FnDefs {
+		decls = append(decls, g.types.FuncDecl(&fn, ""))
+	}
+
+	for _, section := range g.ir.Sections {
+		decls = append(decls, g.sectionDecls(section, "")...)
+	}
+
+	decls = append(decls, g.rootDecl())
+	decls = append(decls, g.localeDecls()...)
+
+	for _, target := range g.ir.Locales {
+		decls = append(decls, g.targetDecl(target))
+	}
+
+	decls = slices.Insert(decls, n, g.types.Decls()...)
+
+	fset := gotoken.NewFileSet()
+	buf := &bytes.Buffer{}
+	buf.WriteString(header)
+	if g.config.hash != "" {
+		buf.WriteString(hashPrefix + g.config.hash + "\n")
+	}
+	buf.WriteString("\n")
+	buf.WriteString("package " + lower(g.ir.Name) + "\n\n")
+
+	g.writeImports(buf, decls)
+
+	// Printing the declarations one by one keeps a blank line between them,
+	// go/printer would otherwise pack the position-less nodes together
+	for _, decl := range decls {
+		if err := format.Node(buf, fset, decl); err != nil {
+			return err
+		}
+		buf.WriteString("\n\n")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(src)
+	return err
+}
+
+// check reports the names of the package that collide once they are
+// declared in go, along with the root type, the fn that selects a locale and
+// the packages that are not imported under an underscored name. The members
+// of the struct of a section are checked against each other, templates
+// declare a field and two methods.
+func (g *Generator) check() error {
+	failures := []error{}
+	declare := func(names map[string]string, name, path string) {
+		if other, ok := names[name]; ok {
+			failures = append(failures, &errs.ExportError{Err: errs.ErrNameCollision, Format: "go", Path: path, Value: other})
+			return
+		}
+		names[name] = path
+	}
+
+	// params reports the params that shadow the runtime
+	params := func(list []ir.Param, path string) {
+		for _, param := range list {
+			declare(map[string]string{runtime: "package " + runtime}, param.Name, path+" param "+param.Name)
+		}
+	}
+
+	names := map[string]string{}
+	for name, path := range packages {
+		if !strings.HasPrefix(name, "_") {
+			declare(names, name, "package "+path)
+		}
+	}
+	declare(names, g.config.root, "root "+g.config.root)
+	declare(names, g.config.local, "fn "+g.config.local)
+	for _, def := range g.ir.TypeDefs {
+		declare(names, typeName(def.Name), "type "+def.Name)
+	}
+	for _, fn := range g.ir.FnDefs {
+		declare(names, fn.Name, "fn "+fn.Name)
+		params(fn.Params, "fn "+fn.Name)
+	}
+
+	var section func(s *ir.Section, name, path string)
+	section = func(s *ir.Section, name, path string) {
+		declare(names, name, "section "+path)
+
+		members := map[string]string{}
+		for _, message := range s.Messages {
+			if message.IsTemplate {
+				declare(members, decapitalize(message.Name), path+"."+message.Name)
+				declare(members, capitalize(message.Name)+"Template", path+"."+message.Name)
+				params(message.Params, path+"."+message.Name)
+			}
+			declare(members, capitalize(message.Name), path+"."+message.Name)
+		}
+		for _, sub := range s.Sections {
+			declare(members, capitalize(sub.Name), path+"."+sub.Name)
+			section(sub, sectionName(name, sub), path+"."+sub.Name)
+		}
+	}
+	for _, s := range g.ir.Sections {
+		section(s, sectionName("", s), s.Name)
+	}
+
+	return errors.Join(failures...)
+}
+
+// sectionDecls generates the struct of a section, the methods of its
+// templates and the declarations of its sub sections
+func (g *Generator) sectionDecls(section *ir.Section, prefix string) []goast.Decl {
+	name := sectionName(prefix, section)
+	fields := []*goast.Field{}
+	decls := []goast.Decl{}
+	subs := []goast.Decl{}
+
+	for _, key := range section.Messages {
+		if key.IsTemplate {
+			continue
+		}
+
+		fields = append(fields, &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(capitalize(key.Name))},
+			Type:  goast.NewIdent("string"),
+		})
+	}
+
+	for _, sub := range section.Sections {
+		fields = append(fields, &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(capitalize(sub.Name))},
+			Type:  goast.NewIdent(sectionName(name, sub)),
+		})
+		subs = append(subs, g.sectionDecls(sub, name)...)
+	}
+
+	for _, template := range section.Messages {
+		if !template.IsTemplate {
+			continue
+		}
+
+		fields = append(fields, &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(decapitalize(template.Name))},
+			Type:  generic(g.templateType(template)),
+		})
+		decls = append(decls, g.templateMethods(template, name)...)
+	}
+
+	decl := &goast.GenDecl{
+		Tok: gotoken.TYPE,
+		Specs: []goast.Spec{
+			&goast.TypeSpec{
+				Name: goast.NewIdent(name),
+				Type: &goast.StructType{Fields: &goast.FieldList{List: fields}},
+			},
+		},
+	}
+
+	return append(append([]goast.Decl{decl}, decls...), subs...)
+}
+
+// sectionName returns the name of the struct of a section, the ones of sub
+// sections are joined to the name of their parent with an underscore which
+// lcl names cannot start with
+func sectionName(parent string, section *ir.Section) string {
+	if len(parent) == 0 {
+		return capitalize(section.Name)
+	}
+	return parent + "_" + capitalize(section.Name)
+}
+
+// rootDecl generates the struct that holds the top level sections of a
+// locale
+func (g *Generator) rootDecl() goast.Decl {
+	fields := []*goast.Field{}
+
+	for _, section := range g.ir.Sections {
+		fields = append(fields, &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(capitalize(section.Name))},
+			Type:  goast.NewIdent(capitalize(section.Name)),
+		})
+	}
+
+	return &goast.GenDecl{
+		Tok: gotoken.TYPE,
+		Specs: []goast.Spec{
+			&goast.TypeSpec{
+				Name: goast.NewIdent(g.config.root),
+				Type: &goast.StructType{Fields: &goast.FieldList{List: fields}},
+			},
+		},
+	}
+}
+
+// localeDecls generates the list of targets, their instances and the fn that
+// selects the instance that matches a tag the best
+func (g *Generator) localeDecls() []goast.Decl {
+	tags := []goast.Expr{}
+	instances := []goast.Expr{}
+
+	for _, target := range g.ir.Locales {
+		tags = append(tags, &goast.CallExpr{
+			Fun:  selector("language", "MustParse"),
+			Args: []goast.Expr{stringLit(target.Tag.String())},
+		})
+		instances = append(instances, &goast.CallExpr{
+			Fun: goast.NewIdent(g.targetName(target)),
+		})
+	}
+
+	vars := &goast.GenDecl{
+		Tok: gotoken.VAR,
+		Specs: []goast.Spec{
+			&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent("_tags")},
+				Values: []goast.Expr{&goast.CompositeLit{
+					Type: &goast.ArrayType{Elt: selector("language", "Tag")},
+					Elts: tags,
+				}},
+			},
+			&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent("_matcher")},
+				Values: []goast.Expr{&goast.CallExpr{
+					Fun:  selector("language", "NewMatcher"),
+					Args: []goast.Expr{goast.NewIdent("_tags")},
+				}},
+			},
+			&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent("_locales")},
+				Values: []goast.Expr{&goast.CompositeLit{
+					Type: &goast.ArrayType{Elt: g.rootType()},
+					Elts: instances,
+				}},
+			},
+		},
+	}
+
+	local := &goast.FuncDecl{
+		Name: goast.NewIdent(g.config.local),
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{List: []*goast.Field{{
+				Names: []*goast.Ident{goast.NewIdent("tag")},
+				Type:  selector("language", "Tag"),
+			}}},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: g.rootType()}}},
+		},
+		Body: &goast.BlockStmt{List: []goast.Stmt{
+			&goast.AssignStmt{
+				Lhs: []goast.Expr{goast.NewIdent("_"), goast.NewIdent("i"), goast.NewIdent("_")},
+				Tok: gotoken.DEFINE,
+				Rhs: []goast.Expr{&goast.CallExpr{
+					Fun:  selector("_matcher", "Match"),
+					Args: []goast.Expr{goast.NewIdent("tag")},
+				}},
+			},
+			&goast.ReturnStmt{Results: []goast.Expr{
+				&goast.IndexExpr{X: goast.NewIdent("_locales"), Index: goast.NewIdent("i")},
+			}},
+		}},
+	}
+
+	return []goast.Decl{vars, local}
+}
+
+// targetDecl generates the fn that builds the instance of a target
+func (g *Generator) targetDecl(target ir.Locale) goast.Decl {
+	name := "_" + recv(g.config.root)
+	stmts := []goast.Stmt{
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{goast.NewIdent(name)},
+			Tok: gotoken.DEFINE,
+			Rhs: []goast.Expr{&goast.UnaryExpr{
+				Op: gotoken.AND,
+				X:  &goast.CompositeLit{Type: goast.NewIdent(g.config.root)},
+			}},
+		},
+	}
+
+	for _, section := range g.ir.Sections {
+		stmts = append(stmts, g.sectionStmts(section, target, selector(name, capitalize(section.Name)), section.Name)...)
+	}
+
+	stmts = append(stmts, &goast.ReturnStmt{Results: []goast.Expr{goast.NewIdent(name)}})
+
+	return &goast.FuncDecl{
+		Name: goast.NewIdent(g.targetName(target)),
+		Type: &goast.FuncType{
+			Params:  &goast.FieldList{},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: g.rootType()}}},
+		},
+		Body: &goast.BlockStmt{List: stmts},
+	}
+}
+
+// targetName returns the name of the fn that builds the instance of a target,
+// names the generated code declares for itself start with an underscore so
+// that they never collide with the ones of the package
+func (g *Generator) targetName(target ir.Locale) string {
+	return "_" + target.Name + capitalize(g.config.root)
+}
+
+func (g *Generator) rootType() goast.Expr {
+	return &goast.StarExpr{X: goast.NewIdent(g.config.root)}
+}
+
+// sectionStmts assigns the values a target has for the keys and templates of
+// a section
+func (g *Generator) sectionStmts(section *ir.Section, target ir.Locale, host goast.Expr, path string) []goast.Stmt {
+	stmts := []goast.Stmt{}
+
+	for _, message := range section.Messages {
+		if message.IsTemplate {
+			stmts = append(stmts, &goast.AssignStmt{
+				Lhs: []goast.Expr{&goast.SelectorExpr{X: host, Sel: goast.NewIdent(decapitalize(message.Name))}},
+				Tok: gotoken.ASSIGN,
+				Rhs: []goast.Expr{call(
+					selector(runtime, "NewTemplate"),
+					stringLit(path+"."+message.Name),
+					&goast.FuncLit{
+						Type: g.templateType(message),
+						Body: &goast.BlockStmt{List: g.body(message.Values[target.Tag])},
+					},
+				)},
+			})
+		} else {
+			stmts = append(stmts, &goast.AssignStmt{
+				Lhs: []goast.Expr{&goast.SelectorExpr{X: host, Sel: goast.NewIdent(capitalize(message.Name))}},
+				Tok: gotoken.ASSIGN,
+				Rhs: []goast.Expr{inline(g.body(message.Values[target.Tag]))},
+			})
+		}
+	}
+
+	for _, sub := range section.Sections {
+		stmts = append(stmts, g.sectionStmts(sub, target, &goast.SelectorExpr{X: host, Sel: goast.NewIdent(capitalize(sub.Name))}, path+"."+sub.Name)...)
+	}
+
+	return stmts
+}
+
+// body generates the statements that compute the value of a field
+func (g *Generator) body(expr ir.Expr) []goast.Stmt {
+	return g.types.Return(expr)
+}
+
+func (g *Generator) templateType(template *ir.Message) *goast.FuncType {
+	params := []*goast.Field{}
+
+	for _, param := range template.Params {
+		params = append(params, &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(param.Name)},
+			Type:  g.types.TypeExpr(param.Type),
+		})
+	}
+
+	return &goast.FuncType{
+		Params:  &goast.FieldList{List: params},
+		Results: &goast.FieldList{List: []*goast.Field{{Type: goast.NewIdent("string")}}},
+	}
+}
+
+// templateMethods generates the method that renders a template with the fn a
+// locale has for it and the method that returns the template itself
+func (g *Generator) templateMethods(template *ir.Message, reciever string) []goast.Decl {
+	typ := g.templateType(template)
+	args := []goast.Expr{}
+	names := []string{}
+
+	for _, param := range typ.Params.List {
+		args = append(args, param.Names[0])
+		names = append(names, param.Names[0].Name)
+	}
+
+	name := recv(reciever)
+	for slices.Contains(names, name) {
+		name += "_"
+	}
+
+	field := selector(name, decapitalize(template.Name))
+	recv := &goast.FieldList{List: []*goast.Field{{
+		Names: []*goast.Ident{goast.NewIdent(name)},
+		Type:  &goast.StarExpr{X: goast.NewIdent(reciever)},
+	}}}
+
+	return []goast.Decl{
+		&goast.FuncDecl{
+			Recv: recv,
+			Name: goast.NewIdent(capitalize(template.Name)),
+			Type: typ,
+			Body: &goast.BlockStmt{List: []goast.Stmt{
+				&goast.ReturnStmt{Results: []goast.Expr{
+					call(call(&goast.SelectorExpr{X: field, Sel: goast.NewIdent("Render")}), args...),
+				}},
+			}},
+		},
+		&goast.FuncDecl{
+			Recv: recv,
+			Name: goast.NewIdent(capitalize(template.Name) + "Template"),
+			Type: &goast.FuncType{
+				Params:  &goast.FieldList{},
+				Results: &goast.FieldList{List: []*goast.Field{{Type: generic(typ)}}},
+			},
+			Body: &goast.BlockStmt{List: []goast.Stmt{
+				&goast.ReturnStmt{Results: []goast.Expr{field}},
+			}},
+		},
+	}
+}
+
+// writeImports writes the import block of the packages the declarations refer
+// to, standard library packages are grouped before the others
+func (g *Generator) writeImports(buf *bytes.Buffer, decls []goast.Decl) {
+	// used maps the import paths to the names they are referred by
+	used := map[string]string{}
+
+	for _, decl := range decls {
+		goast.Inspect(decl, func(n goast.Node) bool {
+			if sel, ok := n.(*goast.SelectorExpr); ok {
+				if ident, ok := sel.X.(*goast.Ident); ok {
+					if path, ok := g.config.imports[ident.Name]; ok {
+						used[path] = ident.Name
+					} else if path, ok := packages[ident.Name]; ok {
+						used[path] = ident.Name
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	if len(used) == 0 {
+		return
+	}
+
+	std, other := []string{}, []string{}
+	for path := range used {
+		if strings.Contains(path, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	slices.Sort(std)
+	slices.Sort(other)
+
+	buf.WriteString("import (\n")
+	// Packages that are referred by a name other than their own are aliased
+	spec := func(path string) string {
+		if name := used[path]; name != pathpkg.Base(path) {
+			return name + " " + strconv.Quote(path) + "\n"
+		}
+		return strconv.Quote(path) + "\n"
+	}
+
+	for _, path := range std {
+		buf.WriteString(spec(path))
+	}
+	if len(std) > 0 && len(other) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, path := range other {
+		buf.WriteString(spec(path))
+	}
+	buf.WriteString(")\n\n")
+}
+
+// Hash returns the source hash recorded in the header of generated code, it
+// is empty if the code was generated without one.
+func Hash(src []byte) string {
+	lines := strings.SplitN(string(src), "\n", 3)
+	if len(lines) < 3 || lines[0]+"\n" != header {
+		return ""
+	}
+
+	hash, ok := strings.CutPrefix(lines[1], hashPrefix)
+	if !ok {
+		return ""
+	}
+	return hash
+}
+
+func selector(x, sel string) *goast.SelectorExpr {
+	return &goast.SelectorExpr{
+		X:   goast.NewIdent(x),
+		Sel: goast.NewIdent(sel),
+	}
+}
+
+func stringLit(s string) *goast.BasicLit {
+	return &goast.BasicLit{Kind: gotoken.STRING, Value: strconv.Quote(s)}
 }
 
-func New(scope *pkg.Scope, env *types.Environment, options ...func(*Config)) *Generator {
+func New(out *ir.IR, options ...func(*Config)) *Generator {
 	config := &Config{
-		root:  "root",
-		local: "Local",
-		fn:    "fn",
+		root:    "Root",
+		local:   "Local",
+		fn:      "fn",
+		imports: map[string]string{},
 	}
 
 	for _, option := range options {
@@ -24,7 +560,7 @@ func New(scope *pkg.Scope, env *types.Environment, options ...func(*Config)) *Ge
 
 	return &Generator{
 		config: config,
-		scope:  scope,
-		env:    env,
+		ir:     out,
+		types:  NewResolver(out.Name),
 	}
 }
@@ -0,0 +1,515 @@
+package gogen_test
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/importer"
+	goparser "go/parser"
+	gotoken "go/token"
+	gotypes "go/types"
+	"testing"
+
+	"github.com/CanPacis/lcl/analyzer"
+	"github.com/CanPacis/lcl/errs"
+	gogen "github.com/CanPacis/lcl/gen/go"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+var fset = gotoken.NewFileSet()
+var imp = importer.ForCompiler(fset, "source", nil)
+
+type GenerateCase struct {
+	In       string
+	Options  []func(*gogen.Config)
+	Contains []string
+	// Uses is go source that is checked together with the output
+	Uses string
+}
+
+func (c *GenerateCase) Run(assert *assert.Assertions) {
+	file := parser.NewFile("mock.lcl", bytes.NewBufferString(c.In))
+	tree := test.MustParse(test.WithFile(file))
+	out, err := analyzer.New(file, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+	if !assert.NoError(err) {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(gogen.New(out, c.Options...).Generate(buf))
+
+	src, err := goparser.ParseFile(fset, "out.go", buf, goparser.ParseComments)
+	if !assert.NoError(err, buf.String()) {
+		return
+	}
+
+	files := []*goast.File{src}
+	if len(c.Uses) > 0 {
+		uses, err := goparser.ParseFile(fset, "uses.go", "package "+tree.Decl.Name.Value+"\n"+c.Uses, 0)
+		if !assert.NoError(err) {
+			return
+		}
+		files = append(files, uses)
+	}
+
+	conf := gotypes.Config{Importer: imp}
+	_, err = conf.Check(tree.Decl.Name.Value, fset, files, nil)
+	assert.NoError(err, buf.String())
+
+	for _, s := range c.Contains {
+		assert.Contains(buf.String(), s)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []test.Runner{
+		&GenerateCase{
+			In: `declare app (en tr)`,
+			Contains: []string{
+				"// Code generated by lcl. DO NOT EDIT.",
+				"package app",
+				`language.MustParse("en"), language.MustParse("tr")`,
+				"func Local(tag language.Tag) *Root {",
+				"_locales = []*Root{_enRoot(), _trRoot()}",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en tr "pt-BR" as pt)
+
+fn(n:int) double n * 2
+fn(a:int b:int) Sum a + b
+
+section auth {
+  login {
+    en "Log in"
+    tr "Giriş yap"
+    pt "Entrar"
+  }
+
+  greet(name:string count:int) {
+    en ` + "`Hello {name}, you have {count} new messages, {double(count)} in total`" + `
+    tr ` + "`Merhaba {name}, {count} yeni mesajın var`" + `
+    pt ` + "`Olá {name}, {Sum(count 1)}%`" + `
+  }
+
+  section errors {
+    expired {
+      en "Your session expired"
+      tr "Oturumun sona erdi"
+      pt "Sua sessão expirou"
+    }
+  }
+}
+
+section home {
+  title {
+    en ` + "`Home`" + `
+    tr ` + "`Ana sayfa`" + `
+    pt ` + "`Início`" + `
+  }
+}`,
+			Contains: []string{
+				`"golang.org/x/text/language"`,
+				`"strconv"`,
+				`"strings"`,
+				"func Sum(a int, b int) int {",
+				"type Auth struct {",
+				"\tLogin  string",
+				"\tErrors Auth_Errors",
+				`"github.com/CanPacis/lcl"`,
+				"\tgreet  lcl.Template[func(name string, count int) string]",
+				"func (a *Auth) Greet(name string, count int) string {\n\treturn a.greet.Render()(name, count)\n}",
+				"func (a *Auth) GreetTemplate() lcl.Template[func(name string, count int) string] {\n\treturn a.greet\n}",
+				`_r.Auth.greet = lcl.NewTemplate("auth.greet", func(name string, count int) string {`,
+				"type Auth_Errors struct {",
+				"type Root struct {",
+				`language.MustParse("pt-BR")`,
+				"_r.Auth.Login = \"Giriş yap\"",
+				"_r.Auth.Errors.Expired = \"Sua sessão expirou\"",
+				"var _b _strings.Builder\n\t\t_b.Grow(81 + len(name))\n\t\t_b.WriteString(\"Hello \")\n\t\t_b.WriteString(name)",
+				"_b.WriteString(_strconv.Itoa(double(count)))\n\t\t_b.WriteString(\" in total\")\n\t\treturn _b.String()",
+				"_b.WriteString(_strconv.Itoa(Sum(count, 1)))\n\t\t_b.WriteString(\"%\")",
+				"_r.Home.Title = \"Ana sayfa\"",
+			},
+			Uses: `
+import "golang.org/x/text/language"
+
+type greeting = func(string, int) string
+
+func render(t interface{ Render() greeting }, name string) string {
+	return t.Render()(name, 0)
+}
+
+func same(a, b language.Tag) bool {
+	greet := Local(a).Auth.GreetTemplate()
+	seen := map[interface{ Key() string }]bool{greet: true}
+	_ = render(greet, "Ada")
+	return greet == Local(b).Auth.GreetTemplate() && seen[greet]
+}`,
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+section a {
+  b(a:int) {
+    en ` + "`{a}`" + `
+  }
+}`,
+			Options: []func(*gogen.Config){gogen.WithRoot("Catalog"), gogen.WithLocal("get")},
+			Contains: []string{
+				"func (a_ *A) B(a int) string {",
+				"type Catalog struct {",
+				"func Get(tag language.Tag) *Catalog {",
+				"func _enCatalog() *Catalog {",
+			},
+		},
+		&GenerateCase{
+			In: `declare shop (en tr)
+
+type Money f64
+type status u8
+type User {name:string balance:Money}
+type Cart {owner:User items:{name:string}[]}
+
+fn(u:User) Balance u.balance
+fn(p:{name:string}) title p.name
+fn(s:status) paid s > 0
+
+section account {
+  summary(user:User cart:Cart first:{name:string}) {
+    en ` + "`{user.name} has {user.balance}, first item is {title(first)}`" + `
+    tr ` + "`{user.name} bakiyesi {user.balance}, ilk ürün {title(first)}`" + `
+  }
+}`,
+			Contains: []string{
+				"type Money float64",
+				"type status uint8",
+				"type User struct {\n\tName    string\n\tBalance Money\n}",
+				"type Cart struct {\n\tOwner User\n\tItems []_struct0\n}",
+				"type _struct0 = struct {\n\tName string\n}",
+				"func Balance(u User) Money {\n\treturn u.Balance\n}",
+				"func title(p _struct0) string {",
+				"func paid(s status) bool {",
+				"func (a *Account) Summary(user User, cart Cart, first _struct0) string {",
+				"_strconv.FormatFloat(float64(user.Balance), 'g', -1, 64)",
+			},
+			Uses: `
+import "golang.org/x/text/language"
+
+func use() string {
+	user := User{Name: "Ada", Balance: 2.5}
+	cart := Cart{Owner: user, Items: []struct{ Name string }{{Name: "Book"}}}
+	return Local(language.Turkish).Account.Summary(user, cart, cart.Items[0])
+}`,
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+fn(n:int) tags n
+fn(n:int) matcher n
+fn(n:int) locales n
+fn(n:int) enRoot n
+fn(n:int) r n
+
+section a {
+  section bc {
+    x {
+      en ` + "`{r(1)}`" + `
+    }
+  }
+}
+
+section aBc {
+  y {
+    en "y"
+  }
+}`,
+			Contains: []string{
+				"type A_Bc struct {",
+				"type ABc struct {",
+				"_r.A.Bc.X = _strconv.Itoa(r(1))",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+fn(math:int fmt:int) power math ^ fmt
+
+section inbox {
+  count(strings:int strconv:string) {
+    en ` + "`{strings} {strconv} {power(strings 2)}`" + `
+  }
+}`,
+			Contains: []string{
+				"return int(_math.Pow(float64(math), float64(fmt)))",
+				"var _b _strings.Builder",
+				"_b.WriteString(_strconv.Itoa(strings))",
+				"_strconv \"strconv\"",
+			},
+		},
+		&GenerateCase{
+			In: `declare mix (en)
+
+fn(n:int small:i32) total n + small
+fn(n:int small:i32) above n > small
+fn(name:string runes:rune[]) same name == runes
+fn(runes:rune[] name:string) equal runes != name
+fn(b:u8) shade b
+fn(c:bool) pick shade(c ? 1 : 2)
+fn(c:bool b:u8) bump b + (c ? 1 : 2)`,
+			Contains: []string{
+				"return n + int(small)",
+				"return n > int(small)",
+				"return name == string(runes)",
+				"return string(runes) != name",
+				"return shade(uint8(func() int {",
+				"return b + uint8(func() int {",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en tr)
+
+fn(n:int) sign n > 0 ? 1 : n < 0 ? -1 : 0
+fn(n:int small:i32) pick n > 10 ? n : small
+fn(anonymous:bool name:string nick:string?) display anonymous ? nick : name
+fn(n:int) twice (n > 0 ? n : 0) * 2
+fn(first:bool a:i32? b:int?) either first ? a : b
+
+section inbox {
+  status {
+    en ` + "`{sign(1) > 0 ? \"Unread\" : \"Read\"}`" + `
+    tr "Okunmadı"
+  }
+
+  count(n:int) {
+    en ` + "`{n} {n == 1 ? \"message\" : \"messages\"}`" + `
+    tr ` + "`{n == 0 ? \"Mesaj yok\" : \"Mesajlar\"}`" + `
+  }
+}`,
+			Contains: []string{
+				"func sign(n int) int {\n\tif n > 0 {\n\t\treturn 1\n\t}\n\tif n < 0 {\n\t\treturn -1\n\t}\n\treturn 0\n}",
+				"func pick(n int, small int32) int {\n\tif n > 10 {\n\t\treturn n\n\t}\n\treturn int(small)\n}",
+				"func display(anonymous bool, name string, nick *string) *string {\n\tif anonymous {\n\t\treturn nick\n\t}\n\treturn lcl.Some(name)\n}",
+				"return func() int {\n\t\tif n > 0 {",
+				"\treturn lcl.Convert(b, func(v int) int32 {\n\t\treturn int32(v)\n\t})",
+				"_r.Inbox.Status = func() string {\n\t\tif sign(1) > 0 {\n\t\t\treturn \"Unread\"\n\t\t}\n\t\treturn \"Read\"\n\t}()",
+				"_0 := func() string {\n\t\t\tif n == 1 {",
+				"if n == 0 {\n\t\t\treturn \"Mesaj yok\"\n\t\t}\n\t\treturn \"Mesajlar\"",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+fn(n:int) same n + 0
+fn(n:int) always true ? n : 0
+fn(n:int) capped 2 > 10 ? n : 10 * 2
+
+section cart {
+  total(n:int) {
+    en ` + "`{n} of {4 * 25}{1 < 2 ? \"%\" : \"\"}`" + `
+  }
+
+  empty {
+    en ` + "`{3 > 2 ? \"No items\" : \"Items\"}`" + `
+  }
+}`,
+			Contains: []string{
+				"func same(n int) int {\n\treturn n\n}",
+				"func always(n int) int {\n\treturn n\n}",
+				"func capped(n int) int {\n\treturn 20\n}",
+				"_b.WriteString(_strconv.Itoa(n))\n\t\t_b.WriteString(\" of 100%\")",
+				"_r.Cart.Empty = \"No items\"",
+			},
+		},
+		&GenerateCase{
+			In: `declare shop (en)
+
+type User {name:string age:int nick:string}
+type Order {buyer:User total:f64}
+
+fn(p:{name:string}) title p.name
+fn(ps:{name:string}[]) first ps[0]
+fn(o:{buyer:{name:string nick:string?}}) buyer o.buyer.name
+fn(n:i32) half n / 2
+
+section orders {
+  summary(u:User users:{name:string age:int}[] order:Order n:int) {
+    en ` + "`{title(u)} {title(first(users))} {buyer(order)} {title(order.buyer)} {half(2)}`" + `
+  }
+}`,
+			Contains: []string{
+				"title(_struct0{Name: u.Name})",
+				"title(first(lcl.ConvertList(users, func(v _struct3) _struct0 {\n\t\t\treturn _struct0{Name: v.Name}\n\t\t}))",
+				"buyer(_struct1{Buyer: func(v User) _struct2 {\n\t\t\treturn _struct2{Name: v.Name, Nick: lcl.Some(v.Nick)}\n\t\t}(order.Buyer)",
+				"title(func(v User) _struct0 {\n\t\t\treturn _struct0{Name: v.Name}\n\t\t}(order.Buyer))",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+type Profile {bio:string nick:string?}
+type User {profile:Profile? nick:string? alt:string? tags:string[]?}
+
+fn(u:User) either u.nick ?? u.alt
+fn(u:User) bio u.profile.bio
+fn(u:User) nick u.profile.nick ?? "anonymous"
+
+section people {
+  card(u:User) {
+    en ` + "`{u.profile.bio ?? \"none\"}, {u.nick ?? u.alt ?? \"-\"}, {u.tags[0] ?? \"none\"}`" + `
+  }
+}`,
+			Contains: []string{
+				"func either(u User) *string {\n\treturn func() *string {\n\t\t_v0 := u.Nick\n\t\tif _v0 == nil {\n\t\t\treturn u.Alt\n\t\t}\n\t\treturn _v0\n\t}()\n}",
+				"func bio(u User) *string {\n\treturn func() *string {\n\t\t_v0 := u.Profile\n\t\tif _v0 == nil {\n\t\t\treturn nil\n\t\t}\n\t\treturn lcl.Some(_v0.Bio)\n\t}()\n}",
+				"_v1 := _v0.Nick\n\t\tif _v1 == nil {\n\t\t\treturn \"anonymous\"\n\t\t}\n\t\treturn *_v1",
+				"_0 := func() string {\n\t\t\t_v0 := u.Profile\n\t\t\tif _v0 == nil {\n\t\t\t\treturn \"none\"\n\t\t\t}\n\t\t\treturn _v0.Bio\n\t\t}()",
+				"return (*_v0)[0]",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+type User {opt:int?}
+
+fn(u:User) isOne (u.opt ?? 0) == 1
+fn(u:User) isMany (u.opt ?? 0) > 1
+fn(x:int? y:int?) eq (x ?? 0) == (y ?? 0)`,
+			Contains: []string{
+				"func isOne(u User) bool {\n\treturn lcl.Coalesce(u.Opt, 0) == 1\n}",
+				"func isMany(u User) bool {\n\treturn lcl.Coalesce(u.Opt, 0) > 1\n}",
+				"func eq(x *int, y *int) bool {\n\treturn lcl.Coalesce(x, 0) == lcl.Coalesce(y, 0)\n}",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+type User {name:string age:u8}
+type Admin {name:string age:u8}
+
+fn(a:User b:Admin) same a == b
+fn(a:User b:{age:u8 name:string}) like a != b
+fn(a:rune[] b:rune[]) eq a == b
+fn(a:rune[] b:string) before a < b`,
+			Contains: []string{
+				"return a == User(b)",
+				"return a != User{Name: b.Name, Age: b.Age}",
+				"return string(a) == string(b)",
+				"return string(a) < b",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+type User {name:string age:u8}
+
+fn(m:map[string]{name:string}) mm m["a"]
+fn(m:map[string]User) useMap mm(m)`,
+			Contains: []string{
+				"mm(lcl.ConvertMap(m, func(v User) _struct0 {\n\t\treturn _struct0{Name: v.Name}\n\t}))",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+fn(x:f64) tiny x * 0.0000001
+fn(x:f64) sqrt x ^ 0.5
+fn() half 7 / 2.0
+fn() two 5.0 / 2.5`,
+			Contains: []string{
+				"return x * 1e-07",
+				"_math.Pow(x, 0.5)",
+				"return 3.5",
+				"return 2.0",
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+section inbox {
+  initials(letters:rune[]) {
+    en ` + "`Signed {letters}`" + `
+  }
+}`,
+			Contains: []string{
+				"_0 := string(letters)",
+				"_b.WriteString(_0)",
+			},
+		},
+		&GenerateCase{
+			In:       "declare app (en)",
+			Options:  []func(*gogen.Config){gogen.WithHash("sha256:abc")},
+			Contains: []string{"// Code generated by lcl. DO NOT EDIT.\n// lcl:hash sha256:abc\n\npackage app"},
+		},
+	}
+
+	test.Run(t, tests)
+}
+
+func TestOptionals(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, body := range []string{"u.opt == 1", "u.opt > 1"} {
+		file := parser.NewFile("mock.lcl", bytes.NewBufferString("declare app (en)\ntype User {opt:int?}\nfn(u:User) f "+body))
+		tree := test.MustParse(test.WithFile(file))
+		_, err := analyzer.New(file, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+		assert.ErrorIs(err, errs.ErrOptionalValue, body)
+	}
+}
+
+func TestHash(t *testing.T) {
+	assert := assert.New(t)
+
+	file := parser.NewFile("mock.lcl", bytes.NewBufferString("declare app (en)"))
+	tree := test.MustParse(test.WithFile(file))
+	out, err := analyzer.New(file, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+	if !assert.NoError(err) {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(gogen.New(out, gogen.WithHash("sha256:abc")).Generate(buf))
+	assert.Equal("sha256:abc", gogen.Hash(buf.Bytes()))
+
+	buf.Reset()
+	assert.NoError(gogen.New(out).Generate(buf))
+	assert.Equal("", gogen.Hash(buf.Bytes()))
+
+	assert.Equal("", gogen.Hash([]byte("// lcl:hash sha256:abc\npackage app\n")))
+}
+
+func TestCollisions(t *testing.T) {
+	assert := assert.New(t)
+
+	file := parser.NewFile("mock.lcl", bytes.NewBufferString(`declare app (en)
+
+fn(n:int) Local n
+fn(lcl:int) language lcl
+
+section inbox {
+  titleTemplate {
+    en "Title"
+  }
+
+  title() {
+    en "Title"
+  }
+}`))
+	tree := test.MustParse(test.WithFile(file))
+	out, err := analyzer.New(file, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+	if !assert.NoError(err) {
+		return
+	}
+
+	err = gogen.New(out).Generate(&bytes.Buffer{})
+	assert.ErrorIs(err, errs.ErrNameCollision)
+	assert.ErrorContains(err, "export error: fn Local, name collision with fn Local in go")
+	assert.ErrorContains(err, "export error: inbox.title, name collision with inbox.titleTemplate in go")
+	assert.ErrorContains(err, "export error: fn language, name collision with package golang.org/x/text/language in go")
+	assert.ErrorContains(err, "export error: fn language param lcl, name collision with package lcl in go")
+
+	err = gogen.New(out, gogen.WithLocal("Get")).Generate(&bytes.Buffer{})
+	assert.NotContains(err.Error(), "fn Local")
+}
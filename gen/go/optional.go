@@ -0,0 +1,152 @@
+package gogen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"strconv"
+
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+// chain is the lowering of a member and index chain whose hosts may be
+// absent, every optional host is bound and checked for nil before it is
+// accessed
+type chain struct {
+	r      *Resolver
+	absent goast.Expr
+	stmts  []goast.Stmt
+	// bound counts the hosts bound to names
+	bound int
+}
+
+// access returns the go expression of a member or index chain, ptr reports
+// whether it is a pointer, that is whether the value it reaches is optional
+// itself
+func (c *chain) access(expr ir.Expr) (x goast.Expr, ptr bool) {
+	switch expr := expr.(type) {
+	case *ir.Member:
+		host, optional := c.access(expr.X)
+		if optional {
+			host = c.guard(host)
+		}
+		x = &goast.SelectorExpr{
+			X:   operand(host, gotoken.UnaryPrec+1),
+			Sel: goast.NewIdent(exported(expr.Name)),
+		}
+		return x, isOptional(element(expr.X, expr.Name))
+	case *ir.Index:
+		host, optional := c.access(expr.X)
+		if optional {
+			host = &goast.ParenExpr{X: &goast.StarExpr{X: c.guard(host)}}
+		}
+		x = &goast.IndexExpr{
+			X:     operand(host, gotoken.UnaryPrec+1),
+			Index: c.r.Expr(expr.Index),
+		}
+		return x, isOptional(element(expr.X, typeOf(expr.Index)))
+	default:
+		return c.r.Expr(expr), isOptional(goType(typeOf(expr)))
+	}
+}
+
+// guard returns the name of a pointer that is known not to be nil, the
+// chain returns the absent value otherwise
+func (c *chain) guard(x goast.Expr) goast.Expr {
+	name, ok := x.(*goast.Ident)
+	if !ok {
+		// Hosts are bound so that they are evaluated once, lcl identifiers
+		// cannot start with an underscore so the names never shadow a param
+		name = goast.NewIdent("_v" + strconv.Itoa(c.bound))
+		c.bound++
+		c.stmts = append(c.stmts, &goast.AssignStmt{
+			Lhs: []goast.Expr{name},
+			Tok: gotoken.DEFINE,
+			Rhs: []goast.Expr{x},
+		})
+	}
+
+	c.stmts = append(c.stmts, &goast.IfStmt{
+		Cond: &goast.BinaryExpr{X: name, Op: gotoken.EQL, Y: goast.NewIdent("nil")},
+		Body: &goast.BlockStmt{List: []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{c.absent}}}},
+	})
+	return name
+}
+
+// element returns the type of the member or the element of a host that a
+// key reaches, hosts that may be absent are unwrapped first
+func element(host ir.Expr, key any) types.Type {
+	if typeOf(host) == nil {
+		return nil
+	}
+	indexer, ok := types.Unwrap(typeOf(host)).(types.Indexer)
+	if !ok {
+		return nil
+	}
+
+	typ, _ := indexer.Index(key)
+	return typ
+}
+
+// isOptional reports whether a type is optional, unknown types are not
+func isOptional(t types.Type) bool {
+	return t != nil && types.IsOptional(t)
+}
+
+// access lowers a member or an index expression, a chain that goes through
+// an optional host becomes a func literal that is called in place and
+// returns nil as soon as a host is absent
+func (r *Resolver) access(expr ir.Expr) goast.Expr {
+	c := &chain{r: r, absent: goast.NewIdent("nil")}
+	x, ptr := c.access(expr)
+	if len(c.stmts) == 0 {
+		return x
+	}
+
+	typ := goType(typeOf(expr))
+	if !ptr {
+		x = call(selector(runtime, "Some"), x)
+	}
+	return called(r.TypeExpr(typ), append(c.stmts, &goast.ReturnStmt{Results: []goast.Expr{x}}))
+}
+
+// coalesce lowers a ?? expression. A plain optional on the left is passed to
+// the runtime along with the default, a chain through optional hosts is
+// checked step by step and returns the default at the first absent host. A
+// default that may be absent itself is returned as it is.
+func (r *Resolver) coalesce(expr *ir.Coalesce) goast.Expr {
+	typ := goType(typeOf(expr))
+	if typ == nil {
+		return call(selector(runtime, "Coalesce"), r.Expr(expr.Left), r.Expr(expr.Right))
+	}
+	optional := types.IsOptional(typ)
+
+	absent := r.value(r.Expr(expr.Right), typeOf(expr.Right), typ)
+	c := &chain{r: r, absent: absent}
+	x, ptr := c.access(expr.Left)
+	if len(c.stmts) == 0 && ptr && !optional {
+		return call(selector(runtime, "Coalesce"), x, absent)
+	}
+
+	switch {
+	case ptr && optional:
+		x = c.guard(x)
+	case ptr:
+		x = &goast.StarExpr{X: c.guard(x)}
+	case optional:
+		x = call(selector(runtime, "Some"), x)
+	}
+	return called(r.TypeExpr(typ), append(c.stmts, &goast.ReturnStmt{Results: []goast.Expr{x}}))
+}
+
+// called returns a func literal of the given result type that runs stmts,
+// called in place
+func called(result goast.Expr, stmts []goast.Stmt) goast.Expr {
+	return call(&goast.FuncLit{
+		Type: &goast.FuncType{
+			Params:  &goast.FieldList{},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: result}}},
+		},
+		Body: &goast.BlockStmt{List: stmts},
+	})
+}
@@ -0,0 +1,321 @@
+package gogen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+// Resolver resolves lcl types and expressions into go from the point of view
+// of the package pkg. Named types of other packages are referenced through
+// their package and struct types are declared once at the top level. A nil
+// resolver qualifies every named type that has a package and inlines structs.
+type Resolver struct {
+	pkg     string
+	structs []*declaration
+}
+
+type declaration struct {
+	name string
+	typ  *types.Struct
+	expr *goast.StructType
+}
+
+// TypeExpr returns the go type expression of the given type
+func (r *Resolver) TypeExpr(typ types.Type) goast.Expr {
+	switch typ := typ.(type) {
+	case *types.Constant:
+		return goast.NewIdent(builtins[typ.String()])
+	case *types.Untyped:
+		return r.TypeExpr(types.Default(typ))
+	case *types.Extended, *types.ExtIndexer:
+		named, _ := types.Named(typ)
+		if name, ok := builtins[named.String()]; ok && len(named.Package()) == 0 {
+			return goast.NewIdent(name)
+		}
+
+		if pkg := named.Package(); len(pkg) > 0 && (r == nil || pkg != r.pkg) {
+			return selector(pkg, typeName(named.String()))
+		}
+		return goast.NewIdent(typeName(named.String()))
+	case *types.List:
+		return &goast.ArrayType{
+			Elt: r.TypeExpr(typ.Type),
+		}
+	case *types.Map:
+		return &goast.MapType{
+			Key:   r.TypeExpr(typ.Key),
+			Value: r.TypeExpr(typ.Value),
+		}
+	case *types.Optional:
+		return &goast.StarExpr{
+			X: r.TypeExpr(typ.Type),
+		}
+	case *types.Template:
+		return generic(r.TypeExpr(&types.Fn{
+			In:  typ.In,
+			Out: types.String,
+		}))
+	case *types.Struct:
+		if r == nil {
+			return r.structType(typ)
+		}
+		return goast.NewIdent(r.declare(typ))
+	case *types.Fn:
+		params := []*goast.Field{}
+
+		for _, param := range typ.In {
+			params = append(params, &goast.Field{Type: r.TypeExpr(param)})
+		}
+
+		return &goast.FuncType{
+			Params: &goast.FieldList{
+				List: params,
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{{
+					Type: r.TypeExpr(typ.Out),
+				}},
+			},
+		}
+	}
+	return nil
+}
+
+// declare returns the name of the declaration of a struct type, identical
+// structs share the same declaration
+func (r *Resolver) declare(typ *types.Struct) string {
+	for _, decl := range r.structs {
+		if types.Identical(decl.typ, typ) {
+			return decl.name
+		}
+	}
+
+	// lcl identifiers cannot start with an underscore so the name never
+	// collides with a user type
+	decl := &declaration{name: "_struct" + strconv.Itoa(len(r.structs)), typ: typ}
+	r.structs = append(r.structs, decl)
+	decl.expr = r.structType(typ)
+	return decl.name
+}
+
+func (r *Resolver) structType(typ *types.Struct) *goast.StructType {
+	fields := []*goast.Field{}
+
+	for _, pair := range *typ {
+		fields = append(fields, &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(exported(pair.Name))},
+			Type:  r.TypeExpr(pair.Type),
+		})
+	}
+
+	return &goast.StructType{
+		Fields: &goast.FieldList{
+			List: fields,
+		},
+	}
+}
+
+// Decls returns the declarations of the struct types that were resolved
+func (r *Resolver) Decls() []goast.Decl {
+	decls := []goast.Decl{}
+
+	for _, decl := range r.structs {
+		decls = append(decls, &goast.GenDecl{
+			Tok: gotoken.TYPE,
+			Specs: []goast.Spec{
+				&goast.TypeSpec{
+					Name: goast.NewIdent(decl.name),
+					// An alias keeps the type identical to the struct literal so
+					// it can be used outside the package
+					Assign: 1,
+					Type:   decl.expr,
+				},
+			},
+		})
+	}
+
+	return decls
+}
+
+func (r *Resolver) FuncDecl(fn *ir.FnDef, reciever string) *goast.FuncDecl {
+	params := []*goast.Field{}
+
+	for _, param := range fn.Params {
+		params = append(params, &goast.Field{
+			Names: []*goast.Ident{goast.NewIdent(param.Name)},
+			Type:  r.TypeExpr(param.Type),
+		})
+	}
+
+	var rv *goast.FieldList
+
+	if len(reciever) > 0 {
+		rv = &goast.FieldList{
+			List: []*goast.Field{
+				{
+					Names: []*goast.Ident{goast.NewIdent(recv(reciever))},
+					Type:  goast.NewIdent(reciever),
+				},
+			},
+		}
+	}
+
+	return &goast.FuncDecl{
+		Name: goast.NewIdent(fn.Name),
+		Body: &goast.BlockStmt{
+			List: r.Return(fn.Body),
+		},
+		Recv: rv,
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{
+				List: params,
+			},
+			Results: &goast.FieldList{
+				List: []*goast.Field{
+					{Type: r.TypeExpr(fn.Type.Out)},
+				},
+			},
+		},
+	}
+}
+
+// TypeDefDecl declares a named type, its underlying struct is declared in
+// place rather than separately
+func (r *Resolver) TypeDefDecl(def *ir.TypeDef) *goast.GenDecl {
+	var typ goast.Expr
+	if named, ok := types.Named(def.Type); ok {
+		if s, ok := named.Base().(*types.Struct); ok {
+			typ = r.structType(s)
+		} else {
+			typ = r.TypeExpr(named.Base())
+		}
+	} else {
+		typ = r.TypeExpr(def.Type)
+	}
+
+	return &goast.GenDecl{
+		Tok: gotoken.TYPE,
+		Specs: []goast.Spec{
+			&goast.TypeSpec{
+				Name: goast.NewIdent(typeName(def.Name)),
+				Type: typ,
+			},
+		},
+	}
+}
+
+// Return lowers an expression in return position, the branches of ternaries
+// become if statements so that only one of them is evaluated
+func (r *Resolver) Return(expr ir.Expr) []goast.Stmt {
+	return r.ret(expr, typeOf(expr))
+}
+
+func (r *Resolver) ret(expr ir.Expr, typ types.Type) []goast.Stmt {
+	switch expr := expr.(type) {
+	case *ir.Ternary:
+		stmt := &goast.IfStmt{
+			Cond: r.Expr(expr.Cond),
+			Body: &goast.BlockStmt{List: r.ret(expr.Then, typ)},
+		}
+		return append([]goast.Stmt{stmt}, r.ret(expr.Else, typ)...)
+	case *ir.Template:
+		if typ == nil || types.Identical(goType(typ), types.String) {
+			return r.Template(expr)
+		}
+	}
+
+	return []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{
+		r.value(r.Expr(expr), typeOf(expr), typ),
+	}}}
+}
+
+// value converts x from the type from to the type to, untyped constants are
+// left as they are and values that are expected to be optional are wrapped
+func (r *Resolver) value(x goast.Expr, from, to types.Type) goast.Expr {
+	from, to = goType(from), goType(to)
+	if from == nil || to == nil {
+		return x
+	}
+
+	// Untyped constants take the type they are used as, other untyped
+	// expressions are computed as their default type
+	u, untyped := from.(*types.Untyped)
+	untyped = untyped && u.IsConstant()
+	if optional, ok := to.(*types.Optional); ok {
+		switch {
+		case types.IsOptional(from):
+			if types.Identical(from, to) {
+				return x
+			}
+
+			inner := types.Unwrap(from)
+			return call(selector(runtime, "Convert"), x, &goast.FuncLit{
+				Type: &goast.FuncType{
+					Params: &goast.FieldList{List: []*goast.Field{{
+						Names: []*goast.Ident{goast.NewIdent("v")},
+						Type:  r.TypeExpr(inner),
+					}}},
+					Results: &goast.FieldList{List: []*goast.Field{{Type: r.TypeExpr(optional.Type)}}},
+				},
+				Body: &goast.BlockStmt{List: []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{
+					r.convert(goast.NewIdent("v"), inner, optional.Type),
+				}}}},
+			})
+		case untyped:
+			return call(&goast.IndexExpr{X: selector(runtime, "Some"), Index: r.TypeExpr(optional.Type)}, x)
+		default:
+			return call(selector(runtime, "Some"), r.convert(x, types.Default(from), optional.Type))
+		}
+	}
+
+	if untyped {
+		return x
+	}
+	return r.convert(x, types.Default(from), to)
+}
+
+// resultType returns the go type of the value of an expression, it is any when
+// the type is not known
+func (r *Resolver) resultType(expr ir.Expr) goast.Expr {
+	typ := goType(typeOf(expr))
+	if typ == nil {
+		return goast.NewIdent("any")
+	}
+	return r.TypeExpr(typ)
+}
+
+func typeOf(expr ir.Expr) types.Type {
+	if expr == nil {
+		return nil
+	}
+	return expr.Type()
+}
+
+// NewResolver returns a resolver for the package pkg
+func NewResolver(pkg string) *Resolver {
+	return &Resolver{pkg: pkg}
+}
+
+// goType returns the type a value of the given type has in go, templates are
+// rendered into strings
+func goType(t types.Type) types.Type {
+	if _, ok := t.(*types.Template); ok {
+		return types.String
+	}
+	return t
+}
+
+// typeName returns the go name of a named type, it keeps the exportedness of
+// the lcl name
+func typeName(name string) string {
+	if first, _ := utf8.DecodeRuneInString(name); unicode.IsUpper(first) {
+		return exported(name)
+	}
+	return lower(name)
+}
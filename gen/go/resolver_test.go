@@ -0,0 +1,78 @@
+package gogen_test
+
+import (
+	"bytes"
+	"go/printer"
+	gotoken "go/token"
+	"testing"
+
+	gogen "github.com/CanPacis/lcl/gen/go"
+	"github.com/CanPacis/lcl/test"
+	"github.com/CanPacis/lcl/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type ResolverCase struct {
+	Pkg string
+	In  []types.Type
+	// Out holds the expressions of the types in order followed by the struct
+	// declarations
+	Out []string
+}
+
+func (c *ResolverCase) Run(assert *assert.Assertions) {
+	r := gogen.NewResolver(c.Pkg)
+	out := []string{}
+	fset := gotoken.NewFileSet()
+
+	for _, typ := range c.In {
+		buf := bytes.NewBuffer([]byte{})
+		printer.Fprint(buf, fset, r.TypeExpr(typ))
+		out = append(out, buf.String())
+	}
+
+	for _, decl := range r.Decls() {
+		buf := bytes.NewBuffer([]byte{})
+		printer.Fprint(buf, fset, decl)
+		out = append(out, buf.String())
+	}
+
+	assert.Equal(c.Out, out)
+}
+
+func TestResolver(t *testing.T) {
+	name := types.NewStruct(types.NewPair(0, "name", types.String))
+
+	tests := []test.Runner{
+		&ResolverCase{
+			Pkg: "shop",
+			In: []types.Type{
+				types.NewNamed("shop", "Money", types.F64),
+				types.NewNamed("shop", "status", types.U8),
+				types.NewNamed("bank", "Money", types.F64),
+				types.NewMap(types.String, types.NewNamed("bank", "Account", name)),
+				types.Int,
+			},
+			Out: []string{"Money", "status", "bank.Money", "map[string]bank.Account", "int"},
+		},
+		&ResolverCase{
+			In: []types.Type{
+				name,
+				types.NewList(types.NewStruct(types.NewPair(0, "name", types.String))),
+				types.NewStruct(
+					types.NewPair(0, "owner", name),
+					types.NewPair(1, "total", types.NewOptional(types.F64)),
+				),
+			},
+			Out: []string{
+				"_struct0",
+				"[]_struct0",
+				"_struct1",
+				"type _struct0 = struct {\n\tName string\n}",
+				"type _struct1 = struct {\n\tOwner\t_struct0\n\tTotal\t*float64\n}",
+			},
+		},
+	}
+
+	test.Run(t, tests)
+}
@@ -0,0 +1,353 @@
+package gogen
+
+import (
+	goast "go/ast"
+	gotoken "go/token"
+	"strconv"
+
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+// builder is the name of the strings.Builder a template writes into, lcl
+// identifiers cannot start with an underscore so it never shadows a param
+const builder = "_b"
+
+// widths holds the maximum number of bytes a value of a type takes when
+// formatted, it is used to pre-size the builder
+var widths = map[types.Type]int{
+	types.Bool: 5,
+	types.I8:   4,
+	types.I16:  6,
+	types.I32:  11,
+	types.I64:  20,
+	types.U8:   3,
+	types.U16:  5,
+	types.U32:  10,
+	types.U64:  20,
+	types.F32:  16,
+	types.F64:  24,
+	types.Int:  20,
+	types.Uint: 20,
+	types.Rune: 4,
+}
+
+// segment is a part of a template, either literal text or a go expression
+// that evaluates to a string or a rune
+type segment struct {
+	text  string
+	value goast.Expr
+	rune  bool
+}
+
+type lowering struct {
+	r        *Resolver
+	segments []segment
+	decls    []goast.Stmt
+	size     int
+	lens     []goast.Expr
+}
+
+func (l *lowering) text(s string) {
+	if len(s) == 0 {
+		return
+	}
+
+	if n := len(l.segments); n > 0 && l.segments[n-1].value == nil {
+		l.segments[n-1].text += s
+	} else {
+		l.segments = append(l.segments, segment{text: s})
+	}
+	l.size += len(s)
+}
+
+func (l *lowering) value(expr goast.Expr, width int) {
+	l.segments = append(l.segments, segment{value: expr})
+	l.size += width
+}
+
+// str adds a string valued expression, anything other than a plain name is
+// stored in a variable first so that its length can be taken without
+// evaluating it twice
+func (l *lowering) str(expr goast.Expr) {
+	switch expr.(type) {
+	case *goast.Ident, *goast.SelectorExpr:
+	default:
+		name := goast.NewIdent("_" + strconv.Itoa(len(l.decls)))
+		l.decls = append(l.decls, &goast.AssignStmt{
+			Lhs: []goast.Expr{name},
+			Tok: gotoken.DEFINE,
+			Rhs: []goast.Expr{expr},
+		})
+		expr = name
+	}
+
+	l.segments = append(l.segments, segment{value: expr})
+	l.lens = append(l.lens, &goast.CallExpr{Fun: goast.NewIdent("len"), Args: []goast.Expr{expr}})
+}
+
+func (l *lowering) lower(expr *ir.Template) {
+	for _, segment := range expr.Segments {
+		if segment.Value == nil {
+			l.text(segment.Text)
+			continue
+		}
+
+		switch value := segment.Value.(type) {
+		case *ir.Literal:
+			switch v := value.Value.(type) {
+			case string:
+				l.text(v)
+				continue
+			case float64:
+				l.text(strconv.FormatFloat(v, 'g', -1, 64))
+				continue
+			}
+		case *ir.Template:
+			l.lower(value)
+			continue
+		}
+
+		var t types.Type
+		if typ := segment.Value.Type(); typ != nil {
+			t = types.Default(typ)
+		}
+		l.format(l.r.Expr(segment.Value), t)
+	}
+}
+
+// format converts a value to a string according to its type, values of
+// unknown type are taken to be strings
+func (l *lowering) format(x goast.Expr, t types.Type) {
+	switch {
+	case t == nil:
+		l.str(x)
+	case types.IsString(t), isRunes(t):
+		l.str(l.r.convert(x, t, types.String))
+	case types.Identical(t, types.Rune):
+		l.segments = append(l.segments, segment{value: x, rune: true})
+		l.size += widths[types.Rune]
+	case types.Identical(t, types.Int):
+		l.value(call(selector("_strconv", "Itoa"), x), widths[types.Int])
+	case types.RootOf(t) == types.Bool:
+		l.value(call(selector("_strconv", "FormatBool"), x), widths[types.Bool])
+	case types.RootOf(t) == types.F32, types.RootOf(t) == types.F64:
+		size := "64"
+		if types.RootOf(t) == types.F32 {
+			size = "32"
+		}
+		l.value(call(
+			selector("_strconv", "FormatFloat"),
+			l.r.convert(x, t, types.F64),
+			&goast.BasicLit{Kind: gotoken.CHAR, Value: "'g'"},
+			&goast.BasicLit{Kind: gotoken.INT, Value: "-1"},
+			&goast.BasicLit{Kind: gotoken.INT, Value: size},
+		), widths[types.RootOf(t)])
+	case types.IsInteger(t):
+		root := types.RootOf(t)
+		fn, conv := "FormatInt", types.Type(types.I64)
+		if root == types.U8 || root == types.U16 || root == types.U32 || root == types.U64 {
+			fn, conv = "FormatUint", types.U64
+		}
+
+		width, ok := widths[t]
+		if !ok {
+			width = widths[root]
+		}
+
+		l.value(call(
+			selector("_strconv", fn),
+			l.r.convert(x, t, conv),
+			&goast.BasicLit{Kind: gotoken.INT, Value: "10"},
+		), width)
+	default:
+		// The checker lets nothing but templates through, which are rendered
+		// into strings
+		l.str(x)
+	}
+}
+
+// ResolveTemplate lowers a template literal without the context of a package,
+// see Resolver.
+func ResolveTemplate(expr *ir.Template) []goast.Stmt {
+	var r *Resolver
+	return r.Template(expr)
+}
+
+// Template lowers a template literal into statements that build the string
+// with a pre-sized strings.Builder and return it. The values are formatted
+// according to their types.
+func (r *Resolver) Template(expr *ir.Template) []goast.Stmt {
+	// A template that only holds a string ternary returns its branches as is
+	if ternary, ok := single(expr); ok && types.IsString(ternary.Typ) {
+		return r.ret(ternary, types.String)
+	}
+
+	l := &lowering{r: r}
+	l.lower(expr)
+
+	switch {
+	case len(l.segments) == 0:
+		return []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{stringLit("")}}}
+	case len(l.segments) == 1 && !l.segments[0].rune:
+		// A single part needs no builder
+		s := l.segments[0]
+		if s.value == nil {
+			return []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{stringLit(s.text)}}}
+		}
+		return append(l.decls, &goast.ReturnStmt{Results: []goast.Expr{s.value}})
+	}
+
+	var size goast.Expr = &goast.BasicLit{Kind: gotoken.INT, Value: strconv.Itoa(l.size)}
+	for _, n := range l.lens {
+		size = &goast.BinaryExpr{X: size, Op: gotoken.ADD, Y: n}
+	}
+
+	stmts := append([]goast.Stmt{}, l.decls...)
+	stmts = append(stmts,
+		&goast.DeclStmt{Decl: &goast.GenDecl{
+			Tok: gotoken.VAR,
+			Specs: []goast.Spec{&goast.ValueSpec{
+				Names: []*goast.Ident{goast.NewIdent(builder)},
+				Type:  selector("_strings", "Builder"),
+			}},
+		}},
+		&goast.ExprStmt{X: call(selector(builder, "Grow"), size)},
+	)
+
+	for _, s := range l.segments {
+		if s.value == nil {
+			stmts = append(stmts, &goast.ExprStmt{X: call(selector(builder, "WriteString"), stringLit(s.text))})
+			continue
+		}
+
+		method := "WriteString"
+		if s.rune {
+			method = "WriteRune"
+		}
+		stmts = append(stmts, &goast.ExprStmt{X: call(selector(builder, method), s.value)})
+	}
+
+	return append(stmts, &goast.ReturnStmt{Results: []goast.Expr{call(selector(builder, "String"))}})
+}
+
+// single returns the only ternary of a template that has no text
+func single(expr *ir.Template) (*ir.Ternary, bool) {
+	if len(expr.Segments) != 1 {
+		return nil, false
+	}
+
+	ternary, ok := expr.Segments[0].Value.(*ir.Ternary)
+	return ternary, ok
+}
+
+// convert converts x from type t to the target type unless they are the same.
+// Go only converts structs with identical fields and lists and maps with
+// identical elements, a struct that has more or wider fields is copied field
+// by field and a list or a map of wider elements is converted element by
+// element.
+func (r *Resolver) convert(x goast.Expr, t, target types.Type) goast.Expr {
+	if types.Identical(t, target) {
+		return x
+	}
+
+	switch to := types.RootOf(target).(type) {
+	case *types.Struct:
+		from, ok := types.RootOf(t).(*types.Struct)
+		if !ok || types.Identical(from, to) {
+			break
+		}
+		return r.copy(x, t, target, func(v goast.Expr) goast.Expr {
+			fields := []goast.Expr{}
+			for _, pair := range *to {
+				typ, _ := from.Index(pair.Name)
+				member := &goast.SelectorExpr{X: v, Sel: goast.NewIdent(exported(pair.Name))}
+				fields = append(fields, &goast.KeyValueExpr{
+					Key:   goast.NewIdent(exported(pair.Name)),
+					Value: r.value(member, typ, pair.Type),
+				})
+			}
+			return &goast.CompositeLit{Type: r.TypeExpr(target), Elts: fields}
+		})
+	case *types.List:
+		from, ok := types.RootOf(t).(*types.List)
+		if !ok || types.Identical(from.Type, to.Type) {
+			break
+		}
+		list := call(selector(runtime, "ConvertList"), x, r.element(from.Type, to.Type))
+		if target.IsRoot() {
+			return list
+		}
+		return call(r.TypeExpr(target), list)
+	case *types.Map:
+		from, ok := types.RootOf(t).(*types.Map)
+		if !ok || types.Identical(from.Value, to.Value) {
+			break
+		}
+		m := call(selector(runtime, "ConvertMap"), x, r.element(from.Value, to.Value))
+		if target.IsRoot() {
+			return m
+		}
+		return call(r.TypeExpr(target), m)
+	}
+
+	return call(r.TypeExpr(target), x)
+}
+
+// element returns the func literal that converts an element of a list or a
+// map from type t to the target type
+func (r *Resolver) element(t, target types.Type) goast.Expr {
+	return &goast.FuncLit{
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{List: []*goast.Field{{
+				Names: []*goast.Ident{goast.NewIdent("v")},
+				Type:  r.TypeExpr(t),
+			}}},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: r.TypeExpr(target)}}},
+		},
+		Body: &goast.BlockStmt{List: []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{
+			r.value(goast.NewIdent("v"), t, target),
+		}}}},
+	}
+}
+
+// copy returns the value build makes of x, x is bound to a param of a func
+// literal first unless it is a plain name so that it is evaluated once
+func (r *Resolver) copy(x goast.Expr, t, target types.Type, build func(v goast.Expr) goast.Expr) goast.Expr {
+	if _, ok := x.(*goast.Ident); ok {
+		return build(x)
+	}
+
+	value := build(goast.NewIdent("v"))
+	return call(&goast.FuncLit{
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{List: []*goast.Field{{
+				Names: []*goast.Ident{goast.NewIdent("v")},
+				Type:  r.TypeExpr(t),
+			}}},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: r.TypeExpr(target)}}},
+		},
+		Body: &goast.BlockStmt{List: []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{value}}}},
+	}, x)
+}
+
+func call(fn goast.Expr, args ...goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{Fun: fn, Args: args}
+}
+
+// inline turns the statements of a template into an expression, a single
+// return is used as is and anything else becomes a func literal that is
+// called in place
+func inline(stmts []goast.Stmt) goast.Expr {
+	if ret, ok := stmts[0].(*goast.ReturnStmt); ok && len(stmts) == 1 {
+		return ret.Results[0]
+	}
+
+	return call(&goast.FuncLit{
+		Type: &goast.FuncType{
+			Params:  &goast.FieldList{},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: goast.NewIdent("string")}}},
+		},
+		Body: &goast.BlockStmt{List: stmts},
+	})
+}
@@ -0,0 +1,97 @@
+package gogen_test
+
+import (
+	"bytes"
+	goast "go/ast"
+	"go/printer"
+	gotoken "go/token"
+	"testing"
+
+	gogen "github.com/CanPacis/lcl/gen/go"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/test"
+	"github.com/CanPacis/lcl/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type TemplateCase struct {
+	In  []ir.Segment
+	Out string
+}
+
+func (c *TemplateCase) Run(assert *assert.Assertions) {
+	out := gogen.ResolveTemplate(&ir.Template{Segments: c.In})
+
+	buf := bytes.NewBuffer([]byte{})
+	printer.Fprint(buf, gotoken.NewFileSet(), &goast.BlockStmt{List: out})
+	assert.Equal(c.Out, buf.String())
+}
+
+func text(s string) ir.Segment {
+	return ir.Segment{Text: s}
+}
+
+func value(expr ir.Expr) ir.Segment {
+	return ir.Segment{Value: expr}
+}
+
+func ref(name string, typ types.Type) *ir.Ref {
+	return &ir.Ref{Name: name, Typ: typ}
+}
+
+func TestTemplate(t *testing.T) {
+	tests := []test.Runner{
+		&TemplateCase{
+			In:  []ir.Segment{},
+			Out: "{\n\treturn \"\"\n}",
+		},
+		&TemplateCase{
+			In: []ir.Segment{
+				text("a "),
+				value(&ir.Literal{Value: 3.0, Typ: types.NewUntyped(3, false)}),
+				text(" "),
+				value(&ir.Literal{Value: 0.5, Typ: types.F64}),
+			},
+			Out: "{\n\treturn \"a 3 0.5\"\n}",
+		},
+		&TemplateCase{
+			In:  []ir.Segment{value(ref("name", types.String))},
+			Out: "{\n\treturn name\n}",
+		},
+		&TemplateCase{
+			In: []ir.Segment{
+				value(&ir.Call{Fn: ref("upper", nil), Args: []ir.Expr{ref("name", types.String)}, Typ: types.String}),
+				text("!"),
+			},
+			Out: "{\n\t_0 := upper(name)\n\tvar _b _strings.Builder\n\t_b.Grow(1 + len(_0))\n\t_b.WriteString(_0)\n\t_b.WriteString(\"!\")\n\treturn _b.String()\n}",
+		},
+		&TemplateCase{
+			In: []ir.Segment{
+				value(ref("a", types.I8)),
+				value(ref("b", types.U16)),
+				value(ref("c", types.F32)),
+				value(ref("d", types.Bool)),
+				value(ref("e", types.Rune)),
+				value(ref("f", types.New("id", types.U64))),
+			},
+			Out: "{\n\tvar _b _strings.Builder\n\t_b.Grow(54)\n" +
+				"\t_b.WriteString(_strconv.FormatInt(int64(a), 10))\n" +
+				"\t_b.WriteString(_strconv.FormatUint(uint64(b), 10))\n" +
+				"\t_b.WriteString(_strconv.FormatFloat(float64(c), 'g', -1, 32))\n" +
+				"\t_b.WriteString(_strconv.FormatBool(d))\n" +
+				"\t_b.WriteRune(e)\n" +
+				"\t_b.WriteString(_strconv.FormatUint(uint64(f), 10))\n" +
+				"\treturn _b.String()\n}",
+		},
+		&TemplateCase{
+			In: []ir.Segment{
+				text("("),
+				value(&ir.Template{Segments: []ir.Segment{text("n="), value(ref("n", types.Int))}}),
+				text(")"),
+			},
+			Out: "{\n\tvar _b _strings.Builder\n\t_b.Grow(24)\n\t_b.WriteString(\"(n=\")\n\t_b.WriteString(_strconv.Itoa(n))\n\t_b.WriteString(\")\")\n\treturn _b.String()\n}",
+		},
+	}
+
+	test.Run(t, tests)
+}
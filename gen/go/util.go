@@ -3,281 +3,253 @@ package gogen
 import (
 	"fmt"
 	goast "go/ast"
-	"go/printer"
 	gotoken "go/token"
+	"strconv"
 	"strings"
 
 	"github.com/CanPacis/lcl/ir"
-	"github.com/CanPacis/lcl/parser/ast"
-	"github.com/CanPacis/lcl/parser/token"
 	"github.com/CanPacis/lcl/types"
 )
 
-func OpToken(t token.Kind) gotoken.Token {
-	switch t {
-	case token.PLUS:
+// runtime is the package name generated code uses to reach the lcl runtime
+const runtime = "lcl"
+
+// builtins maps lcl builtin type names to their go counterparts, the numeric
+// ones double as conversion fns
+var builtins = map[string]string{
+	"bool":   "bool",
+	"i8":     "int8",
+	"i16":    "int16",
+	"i32":    "int32",
+	"i64":    "int64",
+	"u8":     "uint8",
+	"u16":    "uint16",
+	"u32":    "uint32",
+	"u64":    "uint64",
+	"f32":    "float32",
+	"f64":    "float64",
+	"int":    "int",
+	"uint":   "uint",
+	"byte":   "byte",
+	"rune":   "rune",
+	"string": "string",
+}
+
+// OpToken returns the go operator of an ir operator, Pow has no go
+// counterpart and is lowered to a math.Pow call instead
+func OpToken(op ir.Op) gotoken.Token {
+	switch op {
+	case ir.Add:
 		return gotoken.ADD
-	case token.MINUS:
+	case ir.Sub, ir.Neg:
 		return gotoken.SUB
-	case token.STAR:
+	case ir.Mul:
 		return gotoken.MUL
-	case token.FORWARD_SLASH:
+	case ir.Div:
 		return gotoken.QUO
-	case token.AND:
-		return gotoken.AND
-	case token.OR:
-		return gotoken.OR
-	case token.LT:
+	case ir.Mod:
+		return gotoken.REM
+	case ir.Not:
+		return gotoken.NOT
+	case ir.And:
+		return gotoken.LAND
+	case ir.Or:
+		return gotoken.LOR
+	case ir.Lt:
 		return gotoken.LSS
-	case token.LTE:
+	case ir.Lte:
 		return gotoken.LEQ
-	case token.GT:
+	case ir.Gt:
 		return gotoken.GTR
-	case token.GTE:
+	case ir.Gte:
 		return gotoken.GEQ
-	case token.EQUALS:
+	case ir.Eq:
 		return gotoken.EQL
-	case token.NOT_EQUALS:
+	case ir.Neq:
 		return gotoken.NEQ
 	default:
 		return gotoken.ILLEGAL
 	}
 }
 
-func ResolveExpr(expr ast.Expr) goast.Expr {
+// ResolveExpr returns the go expression of the given expression without
+// the context of a package, see Resolver.
+func ResolveExpr(expr ir.Expr) goast.Expr {
+	var r *Resolver
+	return r.Expr(expr)
+}
+
+// Expr returns the go expression of the given expression
+func (r *Resolver) Expr(expr ir.Expr) goast.Expr {
 	switch expr := expr.(type) {
-	case *ast.BinaryExpr:
-		return &goast.BinaryExpr{
-			X:  ResolveExpr(expr.Left),
-			Y:  ResolveExpr(expr.Right),
-			Op: OpToken(expr.Operator.Kind),
+	case *ir.Binary:
+		if expr.Op == ir.Pow {
+			return r.pow(expr)
+		}
+
+		left, right := r.Expr(expr.Left), r.Expr(expr.Right)
+		// Lists of runes are compared as strings and structs of different
+		// types are compared once the right one is copied into the left one
+		switch lt, rt := typeOf(expr.Left), typeOf(expr.Right); {
+		case isRunes(lt) && isRunes(rt):
+			left, right = r.convert(left, lt, types.String), r.convert(right, rt, types.String)
+		case types.IsString(lt) && isRunes(rt):
+			right = r.convert(right, rt, lt)
+		case types.IsString(rt) && isRunes(lt):
+			left = r.convert(left, lt, rt)
+		case isStruct(lt) && isStruct(rt):
+			right = r.convert(right, rt, lt)
 		}
-	case *ast.ArithmeticExpr:
+
+		op := OpToken(expr.Op)
 		return &goast.BinaryExpr{
-			X:  ResolveExpr(expr.Left),
-			Y:  ResolveExpr(expr.Right),
-			Op: OpToken(expr.Operator.Kind),
+			X:  operand(left, op.Precedence()),
+			Y:  operand(right, op.Precedence()+1),
+			Op: op,
 		}
-	case *ast.TernaryExpr:
-		return &goast.CallExpr{
-			Fun: goast.NewIdent("tern"),
-			Args: []goast.Expr{
-				ResolveExpr(expr.Predicate),
-				ResolveExpr(expr.Left),
-				ResolveExpr(expr.Right),
-			},
+	case *ir.Unary:
+		return &goast.UnaryExpr{
+			X:  operand(r.Expr(expr.X), gotoken.UnaryPrec),
+			Op: OpToken(expr.Op),
 		}
-	case *ast.CallExpr:
+	case *ir.Ternary:
+		// Go has no conditional expression, the branches are returned from a
+		// func literal that is called in place so only one of them runs
+		return call(&goast.FuncLit{
+			Type: &goast.FuncType{
+				Params:  &goast.FieldList{},
+				Results: &goast.FieldList{List: []*goast.Field{{Type: r.resultType(expr)}}},
+			},
+			Body: &goast.BlockStmt{List: r.Return(expr)},
+		})
+	case *ir.Coalesce:
+		return r.coalesce(expr)
+	case *ir.Call:
 		args := []goast.Expr{}
 
-		for _, arg := range expr.Args {
-			args = append(args, ResolveExpr(arg))
+		// Arguments are converted to the types of params, which may be
+		// narrower structs or lists of them
+		var fn *types.Fn
+		if typ := typeOf(expr.Fn); typ != nil {
+			fn, _ = types.RootOf(typ).(*types.Fn)
 		}
-
-		return &goast.CallExpr{
-			Fun:  ResolveExpr(expr.Fn),
-			Args: args,
-		}
-	case *ast.MemberExpr:
-		return &goast.SelectorExpr{
-			X:   ResolveExpr(expr.Left),
-			Sel: goast.NewIdent(expr.Right.Value),
+		for i, arg := range expr.Args {
+			if fn != nil && i < len(fn.In) {
+				args = append(args, r.value(r.Expr(arg), typeOf(arg), fn.In[i]))
+				continue
+			}
+			args = append(args, r.Expr(arg))
 		}
-	case *ast.ImportExpr:
-		// TODO
-		panic("not implemented")
-	case *ast.IndexExpr:
-		return &goast.IndexExpr{
-			X:     ResolveExpr(expr.Host),
-			Index: ResolveExpr(expr.Index),
-		}
-	case *ast.GroupExpr:
-		return &goast.ParenExpr{X: ResolveExpr(expr.Expr)}
-	case *ast.IdentExpr:
-		return goast.NewIdent(expr.Value)
-	case *ast.StringLitExpr:
-		return &goast.BasicLit{Value: expr.Value, Kind: gotoken.STRING}
-	case *ast.TemplateLitExpr:
-		// TODO
-		return &goast.BasicLit{Value: "TEMPLATE", Kind: gotoken.STRING}
-	case *ast.NumberLitExpr:
-		isInt := expr.Value == float64(int(expr.Value))
-		if isInt {
-			return &goast.BasicLit{Value: fmt.Sprintf("%d", int(expr.Value)), Kind: gotoken.INT}
+
+		return call(operand(r.Expr(expr.Fn), gotoken.UnaryPrec+1), args...)
+	case *ir.Convert:
+		return call(r.TypeExpr(expr.Typ), r.Expr(expr.X))
+	case *ir.Member, *ir.Index:
+		// Struct fields are exported in go
+		return r.access(expr)
+	case *ir.Import:
+		return selector(expr.Package, expr.Name)
+	case *ir.Ref:
+		return goast.NewIdent(expr.Name)
+	case *ir.Literal:
+		switch value := expr.Value.(type) {
+		case string:
+			return stringLit(value)
+		case bool:
+			return goast.NewIdent(strconv.FormatBool(value))
+		case float64:
+			if u, ok := expr.Typ.(*types.Untyped); value == float64(int(value)) && !(ok && u.IsFloat()) {
+				return &goast.BasicLit{Value: fmt.Sprintf("%d", int(value)), Kind: gotoken.INT}
+			}
+			return &goast.BasicLit{Value: floatLit(value), Kind: gotoken.FLOAT}
 		}
-		return &goast.BasicLit{Value: fmt.Sprintf("%f", expr.Value), Kind: gotoken.FLOAT}
-	case *ast.EmptyExpr:
 		return nil
+	case *ir.Template:
+		return inline(r.Template(expr))
 	default:
 		return nil
 	}
 }
 
-func ResolveTypeExpr(typ types.Type) goast.Expr {
-	switch typ := typ.(type) {
-	case *types.Constant:
-		var name string
-		switch typ.String() {
-		case "bool":
-			name = "bool"
-		case "i8":
-			name = "int8"
-		case "i16":
-			name = "int16"
-		case "i32":
-			name = "int32"
-		case "i64":
-			name = "int64"
-		case "u8":
-			name = "uint8"
-		case "u16":
-			name = "uint16"
-		case "u32":
-			name = "uint32"
-		case "u64":
-			name = "uint64"
-		case "f32":
-			name = "float32"
-		case "f64":
-			name = "float64"
-		}
-
-		return goast.NewIdent(name)
-	case *types.Extended, *types.ExtIndexer:
-		var name string
-		switch typ.String() {
-		case "int":
-			name = "int"
-		case "uint":
-			name = "uint"
-		case "byte":
-			name = "byte"
-		case "rune":
-			name = "rune"
-		case "string":
-			name = "string"
-		default:
-			// TODO: implement this
-			panic("not implemented")
-		}
-		return goast.NewIdent(name)
-	case *types.List:
-		return &goast.ArrayType{
-			Elt: ResolveTypeExpr(typ.Type),
-		}
-	case *types.Template:
-		t := ResolveTypeExpr(&types.Fn{
-			In:  typ.In,
-			Out: types.String,
-		})
-
-		// TODO: figure out how to return a generic type instead of this
-		b := &strings.Builder{}
-		b.WriteString("Template[")
-		printer.Fprint(b, gotoken.NewFileSet(), t)
-		b.WriteString("]")
-		return goast.NewIdent(b.String())
-	case *types.Struct:
-		fields := []*goast.Field{}
-
-		for _, pair := range *typ {
-			fields = append(fields, &goast.Field{
-				Names: []*goast.Ident{goast.NewIdent(exported(pair.Name))},
-				Type:  ResolveTypeExpr(pair.Type),
-			})
-		}
-
-		return &goast.StructType{
-			Fields: &goast.FieldList{
-				List: fields,
-			},
-		}
-	case *types.Fn:
-		params := []*goast.Field{}
-
-		for _, param := range typ.In {
-			params = append(params, &goast.Field{Type: ResolveTypeExpr(param)})
-		}
-
-		return &goast.FuncType{
-			Params: &goast.FieldList{
-				List: params,
-			},
-			Results: &goast.FieldList{
-				List: []*goast.Field{{
-					Type: ResolveTypeExpr(typ.Out),
-				}},
-			},
-		}
+// floatLit formats a float literal with as many digits as it takes to read
+// it back, whole values keep a fraction so go does not treat them as ints
+func floatLit(value float64) string {
+	lit := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(lit, ".e") {
+		lit += ".0"
 	}
-	return nil
+	return lit
 }
 
-func GenerateFuncDecl(fn *ir.FnDef, reciever string) *goast.FuncDecl {
-	params := []*goast.Field{}
-
-	for i, param := range fn.Stmt.Params {
-		typ := fn.Type.In[i]
+// pow lowers an exponentiation to math.Pow, the operands are converted to
+// float64 and the result back to the type of the expression
+func (r *Resolver) pow(expr *ir.Binary) goast.Expr {
+	typ := types.Default(expr.Typ)
+	x := call(selector("_math", "Pow"),
+		r.convert(r.Expr(expr.Left), types.Default(expr.Left.Type()), types.F64),
+		r.convert(r.Expr(expr.Right), types.Default(expr.Right.Type()), types.F64),
+	)
+	return r.convert(x, types.F64, typ)
+}
 
-		params = append(params, &goast.Field{
-			Names: []*goast.Ident{goast.NewIdent(param.Name.Value)},
-			Type:  ResolveTypeExpr(typ),
-		})
+// isRunes reports whether a type is a list of runes that is not a string
+func isRunes(t types.Type) bool {
+	if t == nil || types.IsString(t) {
+		return false
 	}
+	list, ok := types.RootOf(t).(*types.List)
+	return ok && types.Identical(types.RootOf(list.Type), types.RootOf(types.Rune))
+}
 
-	var rv *goast.FieldList
+func isStruct(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := types.RootOf(t).(*types.Struct)
+	return ok
+}
 
-	if len(reciever) > 0 {
-		rv = &goast.FieldList{
-			List: []*goast.Field{
-				{
-					Names: []*goast.Ident{goast.NewIdent(recv(reciever))},
-					Type:  goast.NewIdent(reciever),
-				},
-			},
+// operand wraps x in parens if it binds looser than prec
+func operand(x goast.Expr, prec int) goast.Expr {
+	switch x := x.(type) {
+	case *goast.BinaryExpr:
+		if x.Op.Precedence() < prec {
+			return &goast.ParenExpr{X: x}
+		}
+	case *goast.UnaryExpr:
+		if gotoken.UnaryPrec < prec {
+			return &goast.ParenExpr{X: x}
 		}
 	}
+	return x
+}
 
-	return &goast.FuncDecl{
-		Name: goast.NewIdent(fn.Stmt.Name.Value),
-		Body: &goast.BlockStmt{
-			List: []goast.Stmt{
-				&goast.ReturnStmt{
-					Results: []goast.Expr{
-						ResolveExpr(fn.Stmt.Body),
-					},
-				},
-			},
-		},
-		Recv: rv,
-		Type: &goast.FuncType{
-			Params: &goast.FieldList{
-				List: params,
-			},
-			Results: &goast.FieldList{
-				List: []*goast.Field{
-					{Type: ResolveTypeExpr(fn.Type.Out)},
-				},
-			},
+// ResolveTypeExpr returns the go type expression of the given type without
+// the context of a package, see Resolver.
+func ResolveTypeExpr(typ types.Type) goast.Expr {
+	var r *Resolver
+	return r.TypeExpr(typ)
+}
+
+// generic instantiates the runtime template type with the given func type
+func generic(fn goast.Expr) goast.Expr {
+	return &goast.IndexExpr{
+		X: &goast.SelectorExpr{
+			X:   goast.NewIdent(runtime),
+			Sel: goast.NewIdent("Template"),
 		},
+		Index: fn,
 	}
 }
 
-func GenerateTypeDefDecl(def *ir.TypeDef) *goast.GenDecl {
-	name := lower(def.Name)
-	if def.Exported {
-		name = exported(name)
-	}
+func GenerateFuncDecl(fn *ir.FnDef, reciever string) *goast.FuncDecl {
+	var r *Resolver
+	return r.FuncDecl(fn, reciever)
+}
 
-	return &goast.GenDecl{
-		Tok: gotoken.TYPE,
-		Specs: []goast.Spec{
-			&goast.TypeSpec{
-				Name: goast.NewIdent(name),
-				Type: ResolveTypeExpr(def.Type),
-			},
-		},
-	}
+func GenerateTypeDefDecl(def *ir.TypeDef) *goast.GenDecl {
+	var r *Resolver
+	return r.TypeDefDecl(def)
 }
 
 func capitalize(s string) string {
@@ -287,6 +259,13 @@ func capitalize(s string) string {
 	return strings.ToUpper(string(s[0])) + s[1:]
 }
 
+func decapitalize(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	return strings.ToLower(string(s[0])) + s[1:]
+}
+
 func lower(s string) string {
 	return strings.ToLower(s)
 }
@@ -10,15 +10,13 @@ import (
 
 	gogen "github.com/CanPacis/lcl/gen/go"
 	"github.com/CanPacis/lcl/ir"
-	"github.com/CanPacis/lcl/parser/ast"
-	"github.com/CanPacis/lcl/parser/token"
 	"github.com/CanPacis/lcl/test"
 	"github.com/CanPacis/lcl/types"
 	"github.com/stretchr/testify/assert"
 )
 
 type ExprCase struct {
-	In  ast.Expr
+	In  ir.Expr
 	Out string
 }
 
@@ -77,29 +75,19 @@ func (c *FuncDeclCase) Run(assert *assert.Assertions) {
 func TestUtil(t *testing.T) {
 	fn := &ir.FnDef{
 		Definition: ir.NewDefinition("Test", true),
-		Stmt: &ast.FnDefStmt{
-			Name: &ast.IdentExpr{Value: "Test"},
-			Params: []*ast.TypePair{
-				{
-					Index: 0,
-					Name:  &ast.IdentExpr{Value: "ages"},
-					Type: &ast.ListTypeExpr{
-						Type: &ast.IdentExpr{Value: "int"},
-					},
+		Params:     []ir.Param{{Name: "ages", Type: types.NewList(types.Int)}},
+		Body: &ir.Ternary{
+			Cond: &ir.Binary{
+				Op: ir.Gt,
+				Left: &ir.Index{
+					X:     &ir.Ref{Name: "ages"},
+					Index: &ir.Literal{Value: 0.0},
 				},
+				Right: &ir.Literal{Value: 18.0},
 			},
-			Body: &ast.TernaryExpr{
-				Predicate: &ast.BinaryExpr{
-					Operator: token.Token{Kind: token.GT},
-					Left: &ast.IndexExpr{
-						Host:  &ast.IdentExpr{Value: "ages"},
-						Index: &ast.NumberLitExpr{Value: 0},
-					},
-					Right: &ast.NumberLitExpr{Value: 18},
-				},
-				Left:  &ast.IdentExpr{Value: "true"},
-				Right: &ast.IdentExpr{Value: "false"},
-			},
+			Then: &ir.Literal{Value: true, Typ: types.Bool},
+			Else: &ir.Literal{Value: false, Typ: types.Bool},
+			Typ:  types.Bool,
 		},
 		Type: &types.Fn{
 			In:  []types.Type{types.NewList(types.Int)},
@@ -109,73 +97,122 @@ func TestUtil(t *testing.T) {
 
 	tests := []test.Runner{
 		&ExprCase{
-			In:  &ast.IdentExpr{Value: "test"},
+			In:  &ir.Ref{Name: "test"},
 			Out: "test",
 		},
-		// &GenExprCase{
-		// 	In:  &ast.StringLitExpr{Value: "test"},
-		// 	Out: `"test"`,
-		// },
 		&ExprCase{
-			In:  &ast.NumberLitExpr{Value: 5},
-			Out: "5",
+			In:  &ir.Literal{Value: "test"},
+			Out: `"test"`,
+		},
+		&ExprCase{
+			In:  &ir.Literal{Value: `say "hi"`},
+			Out: `"say \"hi\""`,
+		},
+		&ExprCase{
+			In:  &ir.Literal{Value: true},
+			Out: "true",
+		},
+		&ExprCase{
+			In:  &ir.Unary{Op: ir.Not, X: &ir.Ref{Name: "ok"}},
+			Out: "!ok",
 		},
 		&ExprCase{
-			In:  &ast.NumberLitExpr{Value: 5.2},
-			Out: "5.200000",
+			In:  &ir.Unary{Op: ir.Neg, X: &ir.Ref{Name: "count"}},
+			Out: "-count",
 		},
 		&ExprCase{
-			In:  &ast.GroupExpr{Expr: &ast.NumberLitExpr{Value: 0}},
-			Out: "(0)",
+			In:  &ir.Binary{Op: ir.Mod, Left: &ir.Ref{Name: "count"}, Right: &ir.Literal{Value: 3.0}},
+			Out: "count % 3",
 		},
 		&ExprCase{
-			In: &ast.MemberExpr{
-				Left:  &ast.IdentExpr{Value: "user"},
-				Right: &ast.IdentExpr{Value: "age"},
+			In:  &ir.Binary{Op: ir.Add, Left: &ir.Ref{Name: "name"}, Right: &ir.Literal{Value: "!"}},
+			Out: `name + "!"`,
+		},
+		&ExprCase{
+			In: &ir.Binary{
+				Op:    ir.Mul,
+				Left:  &ir.Binary{Op: ir.Add, Left: &ir.Ref{Name: "a"}, Right: &ir.Ref{Name: "b"}},
+				Right: &ir.Ref{Name: "c"},
 			},
-			Out: "user.age",
+			Out: "(a + b) * c",
 		},
 		&ExprCase{
-			In: &ast.ArithmeticExpr{
-				Operator: token.Token{Kind: token.PLUS},
-				Left:     &ast.NumberLitExpr{Value: 8},
-				Right:    &ast.IdentExpr{Value: "count"},
+			In: &ir.Binary{
+				Op:    ir.Sub,
+				Left:  &ir.Ref{Name: "a"},
+				Right: &ir.Binary{Op: ir.Sub, Left: &ir.Ref{Name: "b"}, Right: &ir.Ref{Name: "c"}},
 			},
-			Out: "8 + count",
+			Out: "a - (b - c)",
 		},
 		&ExprCase{
-			In: &ast.BinaryExpr{
-				Operator: token.Token{Kind: token.GTE},
-				Left:     &ast.NumberLitExpr{Value: 8},
-				Right:    &ast.NumberLitExpr{Value: 9},
+			In: &ir.Binary{
+				Op:    ir.Or,
+				Left:  &ir.Ref{Name: "a"},
+				Right: &ir.Binary{Op: ir.And, Left: &ir.Ref{Name: "b"}, Right: &ir.Ref{Name: "c"}},
 			},
+			Out: "a || b && c",
+		},
+		&ExprCase{
+			In:  &ir.Binary{Op: ir.Pow, Left: &ir.Ref{Name: "n", Typ: types.Int}, Right: &ir.Literal{Value: 2.0, Typ: types.NewUntyped(2, false)}, Typ: types.Int},
+			Out: "int(_math.Pow(float64(n), float64(2)))",
+		},
+		&ExprCase{
+			In:  &ir.Template{Segments: []ir.Segment{{Text: "100% done"}}},
+			Out: `"100% done"`,
+		},
+		&ExprCase{
+			In: &ir.Template{Segments: []ir.Segment{
+				{Text: "Hello "},
+				{Value: &ir.Ref{Name: "name"}},
+				{Text: ", 100% "},
+				{Value: &ir.Ref{Name: "count", Typ: types.Int}},
+			}},
+			Out: "func() string {\n\tvar _b _strings.Builder\n\t_b.Grow(33 + len(name))\n\t_b.WriteString(\"Hello \")\n\t_b.WriteString(name)\n\t_b.WriteString(\", 100% \")\n\t_b.WriteString(_strconv.Itoa(count))\n\treturn _b.String()\n}()",
+		},
+		&ExprCase{
+			In:  &ir.Import{Package: "strings", Name: "ToUpper"},
+			Out: "strings.ToUpper",
+		},
+		&ExprCase{
+			In:  &ir.Literal{Value: 5.0},
+			Out: "5",
+		},
+		&ExprCase{
+			In:  &ir.Literal{Value: 5.2},
+			Out: "5.2",
+		},
+		&ExprCase{
+			In:  &ir.Member{X: &ir.Ref{Name: "user"}, Name: "age"},
+			Out: "user.Age",
+		},
+		&ExprCase{
+			In:  &ir.Binary{Op: ir.Add, Left: &ir.Literal{Value: 8.0}, Right: &ir.Ref{Name: "count"}},
+			Out: "8 + count",
+		},
+		&ExprCase{
+			In:  &ir.Binary{Op: ir.Gte, Left: &ir.Literal{Value: 8.0}, Right: &ir.Literal{Value: 9.0}},
 			Out: "8 >= 9",
 		},
 		&ExprCase{
-			In: &ast.BinaryExpr{
-				Operator: token.Token{Kind: token.LTE},
-				Left:     &ast.NumberLitExpr{Value: 8.1},
-				Right:    &ast.NumberLitExpr{Value: 9.1},
-			},
-			Out: "8.100000 <= 9.100000",
+			In:  &ir.Binary{Op: ir.Lte, Left: &ir.Literal{Value: 8.1}, Right: &ir.Literal{Value: 9.1}},
+			Out: "8.1 <= 9.1",
 		},
 		&ExprCase{
-			In: &ast.CallExpr{
-				Fn:   &ast.IdentExpr{Value: "fn"},
-				Args: []ast.Expr{},
-			},
+			In:  &ir.Call{Fn: &ir.Ref{Name: "fn"}, Args: []ir.Expr{}},
 			Out: "fn()",
 		},
 		&ExprCase{
-			In: &ast.CallExpr{
-				Fn: &ast.IdentExpr{Value: "fn"},
-				Args: []ast.Expr{
-					&ast.IdentExpr{Value: "i"},
-					&ast.IdentExpr{Value: "j"},
-				},
-			},
+			In:  &ir.Call{Fn: &ir.Ref{Name: "fn"}, Args: []ir.Expr{&ir.Ref{Name: "i"}, &ir.Ref{Name: "j"}}},
 			Out: "fn(i, j)",
 		},
+		&ExprCase{
+			In:  &ir.Convert{X: &ir.Ref{Name: "age"}, Typ: types.F64},
+			Out: "float64(age)",
+		},
+		&TypeExprCase{
+			In:  types.NewUntyped(1.5, true),
+			Out: "float64",
+		},
 		&TypeExprCase{
 			In:  types.Bool,
 			Out: "bool",
@@ -200,9 +237,71 @@ func TestUtil(t *testing.T) {
 			In:  types.NewList(types.NewList(types.Rune)),
 			Out: "[][]rune",
 		},
+		&TypeExprCase{
+			In:  types.NewMap(types.String, types.NewList(types.Int)),
+			Out: "map[string][]int",
+		},
+		&ExprCase{
+			In:  &ir.Index{X: &ir.Ref{Name: "counts"}, Index: &ir.Ref{Name: "key"}},
+			Out: "counts[key]",
+		},
+		&TypeExprCase{
+			In:  types.NewOptional(types.String),
+			Out: "*string",
+		},
+		&TypeExprCase{
+			In:  types.NewTemplate([]types.Type{types.String, types.Int}),
+			Out: "lcl.Template[func(string, int) string]",
+		},
+		&TypeExprCase{
+			In:  types.NewList(types.NewTemplate([]types.Type{})),
+			Out: "[]lcl.Template[func() string]",
+		},
+		&TypeExprCase{
+			In:  types.New("Money", types.F64),
+			Out: "Money",
+		},
+		&TypeExprCase{
+			In:  types.NewList(types.New("status", types.U8)),
+			Out: "[]status",
+		},
+		&TypeExprCase{
+			In:  types.NewNamed("shared", "Money", types.F64),
+			Out: "shared.Money",
+		},
+		&TypeExprCase{
+			In: types.New("User", types.NewStruct(
+				types.NewPair(0, "name", types.String),
+			)),
+			Out: "User",
+		},
+		&TypeExprCase{
+			In: types.NewStruct(
+				types.NewPair(0, "name", types.String),
+				types.NewPair(1, "age", types.U8),
+			),
+			Out: "struct {\n\tName\tstring\n\tAge\tuint8\n}",
+		},
+		&ExprCase{
+			In: &ir.Ternary{
+				Cond: &ir.Ref{Name: "ok"},
+				Then: &ir.Literal{Value: "yes"},
+				Else: &ir.Literal{Value: "no"},
+			},
+			Out: "func() any {\n\tif ok {\n\t\treturn \"yes\"\n\t}\n\treturn \"no\"\n}()",
+		},
+		&ExprCase{
+			In:  &ir.Coalesce{Left: &ir.Ref{Name: "nickname"}, Right: &ir.Ref{Name: "name"}},
+			Out: "lcl.Coalesce(nickname, name)",
+		},
 		&FuncDeclCase{
-			In:  fn,
-			Out: `func(ages []int) bool { return tern(ages[0] > 18, true, false) }`,
+			In: fn,
+			Out: `func(ages []int) bool {
+				if ages[0] > 18 {
+					return true
+				}
+				return false
+			}`,
 		},
 	}
 
@@ -0,0 +1,64 @@
+// Package ordered encodes json objects whose members keep the order they are
+// added in, the files generators write follow the order of the source.
+package ordered
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Object is a json object that keeps the order of its members
+type Object []Member
+
+type Member struct {
+	Key   string
+	Value any
+}
+
+func (o Object) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshal encodes v without escaping html, messages are meant to be read by
+// translators
+func marshal(v any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Write writes v indented by two spaces
+func Write(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,304 @@
+// Package printf converts the messages of a package to the positional format
+// strings of mobile platforms. Params become %1$s like placeholders by their
+// position and a ternary over an integer param becomes a plural, every form
+// of which is a whole message.
+package printf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// Categories are the plural categories of CLDR in the order they are written
+var Categories = []string{"zero", "one", "two", "few", "many", "other"}
+
+var forms = map[plural.Form]string{
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+	plural.Other: "other",
+}
+
+type Config struct {
+	// Format names the platform in errors
+	Format string
+	// Verb returns the conversion of a param by its type, like s or d
+	Verb func(typ types.Type) string
+	// Zero is set if the zero form is selected for a count of zero in every
+	// language, not only in the ones whose plural rules have it
+	Zero bool
+}
+
+type Form struct {
+	Category string
+	Format   string
+}
+
+type Message struct {
+	// Plural is the param the forms are selected by, it is empty if the
+	// message has only the other form
+	Plural ir.Param
+	Forms  []Form
+}
+
+// IsPlural reports whether the message is selected by a count
+func (m *Message) IsPlural() bool {
+	return len(m.Plural.Name) > 0
+}
+
+// Other returns the format of the other form
+func (m *Message) Other() string {
+	return m.Forms[len(m.Forms)-1].Format
+}
+
+// Convert returns the value of a message in a locale as format strings. The
+// text of keys is returned as it is, the percent signs of templates are
+// doubled. Ternaries that compare an integer param against counts become
+// plural forms if the plural rules of the locale select those counts and
+// nothing else, expressions that cannot be formatted are reported with an
+// *errs.ExportError.
+func Convert(message *ir.Message, locale ir.Locale, config *Config) (*Message, error) {
+	value := message.Values[locale.Tag]
+	if lit, ok := value.(*ir.Literal); ok && !message.IsTemplate {
+		return &Message{Forms: []Form{{"other", lit.Text()}}}, nil
+	}
+
+	c := &converter{config: config, params: message.Params}
+	counts := []string{}
+	if err := c.counts(value, &counts); err != nil {
+		return nil, err
+	}
+
+	result := &Message{}
+	for _, param := range message.Params {
+		if param.Name == c.subject {
+			result.Plural = param
+		}
+	}
+
+	categories := map[string]string{}
+	for _, count := range counts {
+		category, ok := categorize(locale.Tag, count, config.Zero)
+		if !ok {
+			return nil, c.unrepresentable("count " + count + " of " + c.subject)
+		}
+		categories[category] = count
+	}
+
+	for _, category := range Categories {
+		count, ok := categories[category]
+		if !ok && category != "other" {
+			continue
+		}
+
+		b := &strings.Builder{}
+		if err := c.write(b, value, count); err != nil {
+			return nil, err
+		}
+		result.Forms = append(result.Forms, Form{category, b.String()})
+	}
+
+	return result, nil
+}
+
+type converter struct {
+	config *Config
+	params []ir.Param
+	// subject is the param of the plural, it is empty until a ternary over
+	// one is found
+	subject string
+}
+
+// counts collects the counts the ternaries of an expression compare the
+// subject of the plural against
+func (c *converter) counts(expr ir.Expr, counts *[]string) error {
+	switch expr := expr.(type) {
+	case *ir.Template:
+		for _, segment := range expr.Segments {
+			if segment.Value == nil {
+				continue
+			}
+			if err := c.counts(segment.Value, counts); err != nil {
+				return err
+			}
+		}
+	case *ir.Binary:
+		if expr.Op != ir.Add {
+			return nil
+		}
+		if err := c.counts(expr.Left, counts); err != nil {
+			return err
+		}
+		return c.counts(expr.Right, counts)
+	case *ir.Ternary:
+		name, count, _, ok := c.condition(expr.Cond)
+		if !ok {
+			return c.unrepresentable("ternary on " + ir.Describe(expr.Cond))
+		}
+		if len(c.subject) > 0 && c.subject != name {
+			return c.unrepresentable("plural of both " + c.subject + " and " + name)
+		}
+		c.subject = name
+
+		found := false
+		for _, seen := range *counts {
+			found = found || seen == count
+		}
+		if !found {
+			*counts = append(*counts, count)
+		}
+
+		if err := c.counts(expr.Then, counts); err != nil {
+			return err
+		}
+		return c.counts(expr.Else, counts)
+	}
+
+	return nil
+}
+
+// condition returns the integer param a condition compares against a count,
+// equal is set if the condition holds for the count
+func (c *converter) condition(cond ir.Expr) (name, count string, equal, ok bool) {
+	binary, isBinary := cond.(*ir.Binary)
+	if !isBinary || binary.Op != ir.Eq && binary.Op != ir.Neq {
+		return
+	}
+
+	ref, isRef := binary.Left.(*ir.Ref)
+	lit, isLit := binary.Right.(*ir.Literal)
+	if !isRef || !isLit {
+		ref, isRef = binary.Right.(*ir.Ref)
+		lit, isLit = binary.Left.(*ir.Literal)
+	}
+	if !isRef || !isLit {
+		return
+	}
+
+	param, found := c.param(ref.Name)
+	if _, isNumber := lit.Value.(float64); !found || !isNumber || !types.IsInteger(param.Type) {
+		return
+	}
+	return ref.Name, lit.Text(), binary.Op == ir.Eq, true
+}
+
+// write writes the format of an expression for a count of the plural, the
+// other form is written for an empty count
+func (c *converter) write(b *strings.Builder, expr ir.Expr, count string) error {
+	switch expr := expr.(type) {
+	case *ir.Literal:
+		b.WriteString(escape(expr.Text()))
+	case *ir.Template:
+		for _, segment := range expr.Segments {
+			if segment.Value == nil {
+				b.WriteString(escape(segment.Text))
+				continue
+			}
+			if err := c.write(b, segment.Value, count); err != nil {
+				return err
+			}
+		}
+	case *ir.Ref:
+		position := 0
+		for i, param := range c.params {
+			if param.Name == expr.Name {
+				position = i + 1
+			}
+		}
+		if position == 0 {
+			return c.unrepresentable("reference to " + expr.Name)
+		}
+		b.WriteString(Placeholder(position, c.config.Verb(c.params[position-1].Type)))
+	case *ir.Binary:
+		if expr.Op != ir.Add || !types.IsString(expr.Typ) {
+			return c.unrepresentable(ir.Describe(expr))
+		}
+		if err := c.write(b, expr.Left, count); err != nil {
+			return err
+		}
+		return c.write(b, expr.Right, count)
+	case *ir.Ternary:
+		_, value, equal, _ := c.condition(expr.Cond)
+		if (value == count) == equal {
+			return c.write(b, expr.Then, count)
+		}
+		return c.write(b, expr.Else, count)
+	default:
+		return c.unrepresentable(ir.Describe(expr))
+	}
+
+	return nil
+}
+
+func (c *converter) param(name string) (ir.Param, bool) {
+	for _, param := range c.params {
+		if param.Name == name {
+			return param, true
+		}
+	}
+	return ir.Param{}, false
+}
+
+func (c *converter) unrepresentable(value string) error {
+	return &errs.ExportError{Err: errs.ErrUnrepresentable, Format: c.config.Format, Value: value}
+}
+
+// Placeholder returns the placeholder of the param at a position, positions
+// start at 1
+func Placeholder(position int, verb string) string {
+	return "%" + strconv.Itoa(position) + "$" + verb
+}
+
+// categorize returns the plural category of a locale that holds only the
+// given count. With zero, a count of zero is in the zero category unless the
+// locale gives it other counts as well.
+func categorize(tag language.Tag, count string, zero bool) (string, bool) {
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n < 0 || n >= limit || n != math.Trunc(n) {
+		return "", false
+	}
+
+	category := forms[plural.Cardinal.MatchPlural(tag, int(n), 0, 0, 0, 0)]
+	if zero && n == 0 {
+		category = "zero"
+	}
+
+	for i := 0; i < limit; i++ {
+		if i != int(n) && forms[plural.Cardinal.MatchPlural(tag, i, 0, 0, 0, 0)] == category {
+			return "", false
+		}
+	}
+	return category, category != "other"
+}
+
+// limit bounds the counts plural rules are compared for, the rules of CLDR
+// repeat themselves within it
+const limit = 200
+
+func escape(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// Kind returns the name of the builtin type at the root of the type of a
+// param, strings and runes are named after themselves
+func Kind(typ types.Type) string {
+	typ = types.Default(typ)
+	switch {
+	case typ == nil:
+		return ""
+	case types.Identical(typ, types.Rune):
+		return "rune"
+	case types.IsString(typ):
+		return "string"
+	}
+	return types.RootOf(typ).String()
+}
@@ -0,0 +1,202 @@
+// Package iosgen exports the messages of a package as the string tables of
+// iOS. Messages are keyed by their paths in Localizable.strings and the ones
+// that select by a count are written to Localizable.stringsdict as plural
+// rules, params are formatted by their position.
+package iosgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/gen/internal/printf"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+const (
+	// StringsFile is the name of the table of the messages
+	StringsFile = "Localizable.strings"
+	// DictFile is the name of the table of plural messages
+	DictFile = "Localizable.stringsdict"
+)
+
+const header = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`
+
+type Generator struct {
+	ir *ir.IR
+}
+
+// Files returns the tables of every locale in the .lproj directory named
+// after its tag, the stringsdict is left out if the package has no plural
+// messages. The files are returned even if some messages could not be
+// exported, see Generate.
+func (g *Generator) Files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	failures := []error{}
+
+	plural := false
+	for _, s := range g.ir.Sections {
+		plural = plural || g.hasPlurals(s)
+	}
+
+	for _, locale := range g.ir.Locales {
+		table, dict := &bytes.Buffer{}, &bytes.Buffer{}
+		if err := g.Generate(table, dict, locale); err != nil {
+			if !errs.IsExportError(err) {
+				return nil, err
+			}
+			failures = append(failures, err)
+		}
+
+		dir := locale.Tag.String() + ".lproj/"
+		files[dir+StringsFile] = table.Bytes()
+		if plural {
+			files[dir+DictFile] = dict.Bytes()
+		}
+	}
+
+	return files, errors.Join(failures...)
+}
+
+// Generate writes the tables of a locale. A message that selects by a count
+// in any locale is written to the stringsdict of all of them, its format
+// refers to a variable named after the param of the count. Messages that
+// cannot be represented are left out and reported together once the rest is
+// written.
+func (g *Generator) Generate(table, dict io.Writer, locale ir.Locale) error {
+	ir.Optimize(g.ir)
+	failures := []error{}
+
+	t := &strings.Builder{}
+	d := &strings.Builder{}
+	d.WriteString(header)
+
+	var section func(s *ir.Section, path string)
+	section = func(s *ir.Section, path string) {
+		for _, message := range s.Messages {
+			key := path + message.Name
+			if message.Values[locale.Tag] == nil {
+				continue
+			}
+
+			m, err := printf.Convert(message, locale, config)
+			if err != nil {
+				failures = append(failures, errs.Locate(err, key, locale.Name))
+				continue
+			}
+
+			param, ok := g.plural(message)
+			if !ok {
+				fmt.Fprintf(t, "%s = %s;\n", quote(key), quote(m.Other()))
+				continue
+			}
+
+			position := 0
+			for i, p := range message.Params {
+				if p.Name == param.Name {
+					position = i + 1
+				}
+			}
+
+			fmt.Fprintf(d, "\t<key>%s</key>\n\t<dict>\n", escape(key))
+			entry(d, 2, "NSStringLocalizedFormatKey", printf.Placeholder(position, "#@"+param.Name+"@"))
+			fmt.Fprintf(d, "\t\t<key>%s</key>\n\t\t<dict>\n", escape(param.Name))
+			entry(d, 3, "NSStringFormatSpecTypeKey", "NSStringPluralRuleType")
+			entry(d, 3, "NSStringFormatValueTypeKey", verb(param.Type))
+			for _, form := range m.Forms {
+				entry(d, 3, form.Category, form.Format)
+			}
+			d.WriteString("\t\t</dict>\n\t</dict>\n")
+		}
+
+		for _, sub := range s.Sections {
+			section(sub, path+sub.Name+".")
+		}
+	}
+
+	for _, s := range g.ir.Sections {
+		section(s, s.Name+".")
+	}
+
+	d.WriteString("</dict>\n</plist>\n")
+	if _, err := io.WriteString(table, t.String()); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(dict, d.String()); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+// plural returns the param a message selects by in the first locale it does
+// so in
+func (g *Generator) plural(message *ir.Message) (ir.Param, bool) {
+	for _, locale := range g.ir.Locales {
+		if message.Values[locale.Tag] == nil {
+			continue
+		}
+		if m, err := printf.Convert(message, locale, config); err == nil && m.IsPlural() {
+			return m.Plural, true
+		}
+	}
+	return ir.Param{}, false
+}
+
+func (g *Generator) hasPlurals(s *ir.Section) bool {
+	for _, message := range s.Messages {
+		if _, ok := g.plural(message); ok {
+			return true
+		}
+	}
+	for _, sub := range s.Sections {
+		if g.hasPlurals(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// config selects the zero form for a count of zero in every language like
+// the plural rules of stringsdict do
+var config = &printf.Config{Format: "ios", Verb: verb, Zero: true}
+
+// verb returns the format specifier of a param, integers are read as Int and
+// UInt and other values that are not numbers as objects
+func verb(typ types.Type) string {
+	switch printf.Kind(typ) {
+	case "i8", "i16", "i32", "i64":
+		return "ld"
+	case "u8", "u16", "u32", "u64":
+		return "lu"
+	case "f32", "f64":
+		return "f"
+	default:
+		return "@"
+	}
+}
+
+func entry(b *strings.Builder, depth int, key, value string) {
+	tabs := strings.Repeat("\t", depth)
+	fmt.Fprintf(b, "%s<key>%s</key>\n%s<string>%s</string>\n", tabs, escape(key), tabs, escape(value))
+}
+
+// quote returns a string of a .strings table
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(s) + `"`
+}
+
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func New(out *ir.IR) *Generator {
+	return &Generator{ir: out}
+}
@@ -0,0 +1,84 @@
+package iosgen_test
+
+import (
+	"testing"
+
+	"github.com/CanPacis/lcl/errs"
+	iosgen "github.com/CanPacis/lcl/gen/ios"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `declare shop (en ru)
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+section cart {
+  title {
+    en "Your \"cart\""
+    ru "Корзина"
+  }
+
+  summary(name:string n:u32) {
+    en ` + "`{name}, {n == 0 ? \"no items\" : n == 1 ? \"one item\" : \"items\"} ({n})`" + `
+    ru ` + "`{name}, {n} товаров`" + `
+  }
+
+  count(n:int) {
+    en ` + "`{n} {plural(n)}`" + `
+    ru ` + "`{n == 1 ? \"один\" : \"много\"}`" + `
+  }
+
+  section stock {
+    left(n:i64 price:f64) {
+      en ` + "`{n} left at {price}%`" + `
+      ru ` + "`Осталось {n}`" + `
+    }
+  }
+}
+`
+
+func TestFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	files, err := iosgen.New(test.MustScan(source)).Files()
+	assert.ErrorIs(err, errs.ErrUnrepresentable)
+	assert.ErrorContains(err, "cart.count (en), call of plural cannot be represented in ios")
+	assert.ErrorContains(err, "cart.count (ru), count 1 of n cannot be represented in ios")
+
+	assert.Equal(`"cart.title" = "Your \"cart\"";
+"cart.stock.left" = "%1$ld left at %2$f%%";
+`, string(files["en.lproj/"+iosgen.StringsFile]))
+	assert.Equal(`"cart.title" = "Корзина";
+"cart.stock.left" = "Осталось %1$ld";
+`, string(files["ru.lproj/"+iosgen.StringsFile]))
+
+	assert.Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>cart.summary</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%2$#@n@</string>
+		<key>n</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>lu</string>
+			<key>zero</key>
+			<string>%1$@, no items (%2$lu)</string>
+			<key>one</key>
+			<string>%1$@, one item (%2$lu)</string>
+			<key>other</key>
+			<string>%1$@, items (%2$lu)</string>
+		</dict>
+	</dict>
+</dict>
+</plist>
+`, string(files["en.lproj/"+iosgen.DictFile]))
+	assert.Contains(string(files["ru.lproj/"+iosgen.DictFile]), `			<key>other</key>
+			<string>%1$@, %2$lu товаров</string>
+`)
+}
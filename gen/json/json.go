@@ -0,0 +1,259 @@
+// Package jsongen exports the messages of a package as json. Every locale is
+// written to a file of its own whose objects mirror the sections, and a json
+// schema describes those files along with the params of templates.
+package jsongen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/gen/internal/ordered"
+	"github.com/CanPacis/lcl/icu"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// SchemaFile is the name Files gives the schema
+const SchemaFile = "schema.json"
+
+type Generator struct {
+	ir *ir.IR
+}
+
+// Files returns the file of every locale named after its tag and the schema
+// named SchemaFile. The files are returned even if some messages could not be
+// exported, see Generate.
+func (g *Generator) Files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	failures := []error{}
+
+	for _, locale := range g.ir.Locales {
+		buf := &bytes.Buffer{}
+		if err := g.Generate(buf, locale); err != nil {
+			if !errs.IsExportError(err) {
+				return nil, err
+			}
+			failures = append(failures, err)
+		}
+		files[locale.Tag.String()+".json"] = buf.Bytes()
+	}
+
+	buf := &bytes.Buffer{}
+	if err := g.Schema(buf); err != nil {
+		return nil, err
+	}
+	files[SchemaFile] = buf.Bytes()
+
+	return files, errors.Join(failures...)
+}
+
+// Generate writes the messages of a locale, keys are written as they are and
+// templates as ICU MessageFormat patterns. Messages that cannot be
+// represented are left out and reported together once the rest is written.
+func (g *Generator) Generate(w io.Writer, locale ir.Locale) error {
+	ir.Optimize(g.ir)
+	failures := []error{}
+
+	var section func(s *ir.Section, path string) ordered.Object
+	section = func(s *ir.Section, path string) ordered.Object {
+		obj := ordered.Object{}
+
+		for _, message := range s.Messages {
+			value := message.Values[locale.Tag]
+			if value == nil {
+				continue
+			}
+
+			if !message.IsTemplate {
+				if lit, ok := value.(*ir.Literal); ok {
+					obj = append(obj, ordered.Member{Key: message.Name, Value: lit.Text()})
+					continue
+				}
+			}
+
+			pattern, err := icu.Export(value)
+			if err != nil {
+				failures = append(failures, errs.Locate(err, path+message.Name, locale.Name))
+				continue
+			}
+			obj = append(obj, ordered.Member{Key: message.Name, Value: pattern})
+		}
+
+		for _, sub := range s.Sections {
+			obj = append(obj, ordered.Member{Key: sub.Name, Value: section(sub, path+sub.Name+".")})
+		}
+
+		return obj
+	}
+
+	root := ordered.Object{}
+	for _, s := range g.ir.Sections {
+		root = append(root, ordered.Member{Key: s.Name, Value: section(s, s.Name+".")})
+	}
+
+	if err := ordered.Write(w, root); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+// Schema writes the json schema of the files Generate writes, the schema of
+// the params of a template is given under its "x-params" keyword.
+func (g *Generator) Schema(w io.Writer) error {
+	defs := ordered.Object{}
+	for _, def := range g.ir.TypeDefs {
+		typ := def.Type
+		if named, ok := types.Named(typ); ok {
+			typ = named.Base()
+		}
+		defs = append(defs, ordered.Member{Key: def.Name, Value: g.typeSchema(typ)})
+	}
+
+	var section func(s *ir.Section) ordered.Object
+	section = func(s *ir.Section) ordered.Object {
+		props := ordered.Object{}
+		required := []string{}
+
+		for _, message := range s.Messages {
+			schema := ordered.Object{{Key: "type", Value: "string"}}
+			if message.IsTemplate {
+				schema = append(schema, ordered.Member{Key: "x-params", Value: g.paramsSchema(message.Params)})
+			}
+			props = append(props, ordered.Member{Key: message.Name, Value: schema})
+			required = append(required, message.Name)
+		}
+
+		for _, sub := range s.Sections {
+			props = append(props, ordered.Member{Key: sub.Name, Value: section(sub)})
+			required = append(required, sub.Name)
+		}
+
+		return closed(props, required)
+	}
+
+	props := ordered.Object{}
+	required := []string{}
+	for _, s := range g.ir.Sections {
+		props = append(props, ordered.Member{Key: s.Name, Value: section(s)})
+		required = append(required, s.Name)
+	}
+
+	schema := append(ordered.Object{{Key: "$schema", Value: draft}, {Key: "title", Value: g.ir.Name}}, closed(props, required)...)
+	if len(defs) > 0 {
+		schema = append(schema, ordered.Member{Key: "$defs", Value: defs})
+	}
+
+	return ordered.Write(w, schema)
+}
+
+// paramsSchema describes the params of a template as an object, optional
+// params are not required
+func (g *Generator) paramsSchema(params []ir.Param) ordered.Object {
+	props := ordered.Object{}
+	required := []string{}
+
+	for _, param := range params {
+		props = append(props, ordered.Member{Key: param.Name, Value: g.typeSchema(param.Type)})
+		if !types.IsOptional(param.Type) {
+			required = append(required, param.Name)
+		}
+	}
+
+	return closed(props, required)
+}
+
+// typeSchema describes the json values of a type, the named types of the
+// package refer to their definitions
+func (g *Generator) typeSchema(typ types.Type) ordered.Object {
+	if named, ok := types.Named(typ); ok {
+		switch {
+		case named.Package() == g.ir.Name:
+			return ordered.Object{{Key: "$ref", Value: "#/$defs/" + named.String()}}
+		case types.IsString(named):
+			return ordered.Object{{Key: "type", Value: "string"}}
+		}
+		return g.typeSchema(named.Base())
+	}
+
+	switch typ := typ.(type) {
+	case *types.Constant:
+		return constantSchema(typ)
+	case *types.Untyped:
+		return constantSchema(types.Default(typ).(*types.Constant))
+	case *types.List:
+		return ordered.Object{{Key: "type", Value: "array"}, {Key: "items", Value: g.typeSchema(typ.Type)}}
+	case *types.Map:
+		return ordered.Object{{Key: "type", Value: "object"}, {Key: "additionalProperties", Value: g.typeSchema(typ.Value)}}
+	case *types.Optional:
+		return ordered.Object{{Key: "anyOf", Value: []ordered.Object{g.typeSchema(typ.Type), {{Key: "type", Value: "null"}}}}}
+	case *types.Struct:
+		props := ordered.Object{}
+		required := []string{}
+
+		for _, pair := range *typ {
+			props = append(props, ordered.Member{Key: pair.Name, Value: g.typeSchema(pair.Type)})
+			if !types.IsOptional(pair.Type) {
+				required = append(required, pair.Name)
+			}
+		}
+		return closed(props, required)
+	default:
+		return ordered.Object{}
+	}
+}
+
+// constantSchema describes the json values of a builtin type, integers that
+// fit into a float64 carry their limits
+func constantSchema(typ *types.Constant) ordered.Object {
+	switch {
+	case typ == types.Bool:
+		return ordered.Object{{Key: "type", Value: "boolean"}}
+	case types.IsInteger(typ):
+		schema := ordered.Object{{Key: "type", Value: "integer"}}
+		if min, max, ok := limits(typ); ok {
+			schema = append(schema, ordered.Member{Key: "minimum", Value: min}, ordered.Member{Key: "maximum", Value: max})
+		} else if typ == types.U64 {
+			schema = append(schema, ordered.Member{Key: "minimum", Value: 0})
+		}
+		return schema
+	default:
+		return ordered.Object{{Key: "type", Value: "number"}}
+	}
+}
+
+func limits(typ *types.Constant) (int64, int64, bool) {
+	switch typ {
+	case types.I8:
+		return math.MinInt8, math.MaxInt8, true
+	case types.I16:
+		return math.MinInt16, math.MaxInt16, true
+	case types.I32:
+		return math.MinInt32, math.MaxInt32, true
+	case types.U8:
+		return 0, math.MaxUint8, true
+	case types.U16:
+		return 0, math.MaxUint16, true
+	case types.U32:
+		return 0, math.MaxUint32, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// closed describes an object that has the given properties and no others
+func closed(props ordered.Object, required []string) ordered.Object {
+	schema := ordered.Object{{Key: "type", Value: "object"}, {Key: "properties", Value: props}}
+	if len(required) > 0 {
+		schema = append(schema, ordered.Member{Key: "required", Value: required})
+	}
+	return append(schema, ordered.Member{Key: "additionalProperties", Value: false})
+}
+
+func New(out *ir.IR) *Generator {
+	return &Generator{ir: out}
+}
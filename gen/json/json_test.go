@@ -0,0 +1,143 @@
+package jsongen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/CanPacis/lcl/errs"
+	jsongen "github.com/CanPacis/lcl/gen/json"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `declare shop (en tr)
+
+type Money f64
+type Item {name:string price:Money note:string?}
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+section cart {
+  title {
+    en "Your <cart>"
+    tr "Sepetiniz"
+  }
+
+  summary(n:int) {
+    en ` + "`{n} {plural(n)}`" + `
+    tr ` + "`{n} ürün`" + `
+  }
+
+  item(item:Item count:u8?) {
+    en ` + "`{item.name}`" + `
+    tr ` + "`{item.name}`" + `
+  }
+
+  section stock {
+    empty {
+      en "Out of {stock}"
+      tr "Stokta yok"
+    }
+  }
+}
+`
+
+func TestFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	files, err := jsongen.New(test.MustScan(source)).Files()
+	assert.ErrorIs(err, errs.ErrUnrepresentable)
+	assert.ErrorContains(err, "cart.summary (en), call of plural cannot be represented in icu")
+	assert.ErrorContains(err, "cart.item (en), field name cannot be represented in icu")
+
+	assert.Equal(`{
+  "cart": {
+    "title": "Your <cart>",
+    "stock": {
+      "empty": "Out of {stock}"
+    }
+  }
+}
+`, string(files["en.json"]))
+
+	assert.Equal(`{
+  "cart": {
+    "title": "Sepetiniz",
+    "summary": "{n, number, integer} ürün",
+    "stock": {
+      "empty": "Stokta yok"
+    }
+  }
+}
+`, string(files["tr.json"]))
+
+	assert.Contains(files, jsongen.SchemaFile)
+}
+
+func TestGenerate(t *testing.T) {
+	assert := assert.New(t)
+
+	out := test.MustScan(`declare app (en)
+
+section app {
+  quote {
+    en "Don't {panic}"
+  }
+
+  greet(name:string) {
+    en ` + "`{name}'s {\"{\"}x}`" + `
+  }
+}
+`)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(jsongen.New(out).Generate(buf, out.Locales[0]))
+
+	messages := map[string]map[string]string{}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &messages))
+	assert.Equal("Don't {panic}", messages["app"]["quote"])
+	assert.Equal("{name}'s '{'x'}'", messages["app"]["greet"])
+}
+
+func TestSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(jsongen.New(test.MustScan(source)).Schema(buf))
+
+	schema := map[string]any{}
+	if !assert.NoError(json.Unmarshal(buf.Bytes(), &schema)) {
+		return
+	}
+
+	assert.Equal("https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+	assert.Equal("shop", schema["title"])
+	assert.Equal(false, schema["additionalProperties"])
+
+	defs := schema["$defs"].(map[string]any)
+	assert.Equal(map[string]any{"type": "number"}, defs["Money"])
+	assert.Equal([]any{"name", "price"}, defs["Item"].(map[string]any)["required"])
+
+	cart := schema["properties"].(map[string]any)["cart"].(map[string]any)
+	assert.Equal([]any{"title", "summary", "item", "stock"}, cart["required"])
+
+	props := cart["properties"].(map[string]any)
+	assert.Equal(map[string]any{"type": "string"}, props["title"])
+
+	params := props["summary"].(map[string]any)["x-params"].(map[string]any)
+	assert.Equal(map[string]any{
+		"type":    "integer",
+		"minimum": float64(-2147483648),
+		"maximum": float64(2147483647),
+	}, params["properties"].(map[string]any)["n"])
+
+	params = props["item"].(map[string]any)["x-params"].(map[string]any)
+	assert.Equal([]any{"item"}, params["required"])
+	item := params["properties"].(map[string]any)
+	assert.Equal(map[string]any{"$ref": "#/$defs/Item"}, item["item"])
+	assert.Equal(map[string]any{"anyOf": []any{
+		map[string]any{"type": "integer", "minimum": float64(0), "maximum": float64(255)},
+		map[string]any{"type": "null"},
+	}}, item["count"])
+}
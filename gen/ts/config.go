@@ -0,0 +1,42 @@
+package tsgen
+
+type Config struct {
+	root  string
+	index string
+	// extension is appended to the module paths generated code imports
+	extension string
+	// imports maps the names of imported lcl packages to the module paths of
+	// their generated code
+	imports map[string]string
+}
+
+// WithRoot sets the name of the interface that holds the top level sections
+func WithRoot(root string) func(*Config) {
+	return func(c *Config) {
+		c.root = capitalize(root)
+	}
+}
+
+// WithIndex sets the name of the module that declares the types, fns and the
+// loader, the locale bundles import it
+func WithIndex(index string) func(*Config) {
+	return func(c *Config) {
+		c.index = index
+	}
+}
+
+// WithExtension sets the extension of the module paths generated code imports,
+// like ".js" for node16 module resolution
+func WithExtension(extension string) func(*Config) {
+	return func(c *Config) {
+		c.extension = extension
+	}
+}
+
+// WithImport sets the module path of the generated code of an imported
+// package
+func WithImport(name, path string) func(*Config) {
+	return func(c *Config) {
+		c.imports[name] = path
+	}
+}
@@ -0,0 +1,372 @@
+package tsgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+// module collects the code of a generated module along with the fns and
+// packages it refers to, so that they can be imported
+type module struct {
+	g *Generator
+	// number is the name of the fn templates format numbers with, formats
+	// reports whether it was used
+	number  string
+	formats bool
+	// shadow holds the params in scope, they shadow the fns of the package
+	shadow   map[string]bool
+	fns      map[string]bool
+	packages map[string]bool
+}
+
+// precedences of the typescript operators, higher binds tighter
+const (
+	precTernary = iota + 1
+	precCoalesce
+	precOr
+	precAnd
+	precEquality
+	precRelational
+	precAdditive
+	precMultiplicative
+	precExponent
+	precUnary
+	precPrimary
+)
+
+var operators = map[ir.Op]struct {
+	token string
+	prec  int
+}{
+	ir.Add: {"+", precAdditive},
+	ir.Sub: {"-", precAdditive},
+	ir.Mul: {"*", precMultiplicative},
+	ir.Div: {"/", precMultiplicative},
+	ir.Mod: {"%", precMultiplicative},
+	ir.Pow: {"**", precExponent},
+	ir.Eq:  {"===", precEquality},
+	ir.Neq: {"!==", precEquality},
+	ir.Lt:  {"<", precRelational},
+	ir.Lte: {"<=", precRelational},
+	ir.Gt:  {">", precRelational},
+	ir.Gte: {">=", precRelational},
+	ir.And: {"&&", precAnd},
+	ir.Or:  {"||", precOr},
+}
+
+// wraps holds the expressions that wrap a number around like a conversion to
+// the fixed size integer types of go, bitwise operators bind looser than
+// comparisons
+var wraps = map[types.Type]expr{
+	types.I8:  {"(%s << 24) >> 24", precRelational},
+	types.I16: {"(%s << 16) >> 16", precRelational},
+	types.I32: {"%s | 0", precAnd},
+	types.U8:  {"%s & 0xff", precAnd},
+	types.U16: {"%s & 0xffff", precAnd},
+	types.U32: {"%s >>> 0", precRelational},
+}
+
+// expr is a resolved typescript expression and the precedence of its
+// outermost operator
+type expr struct {
+	code string
+	prec int
+}
+
+// operand returns the code of x, wrapped in parens if it binds looser than
+// prec
+func (x expr) operand(prec int) string {
+	if x.prec < prec {
+		return "(" + x.code + ")"
+	}
+	return x.code
+}
+
+// TypeExpr returns the typescript type of the given type
+func (m *module) TypeExpr(typ types.Type) string {
+	switch typ := typ.(type) {
+	case *types.Constant:
+		if typ == types.Bool {
+			return "boolean"
+		}
+		return "number"
+	case *types.Untyped:
+		return "number"
+	case *types.Extended, *types.ExtIndexer:
+		named, _ := types.Named(typ)
+		switch pkg := named.Package(); {
+		case len(pkg) == 0 && types.IsString(named):
+			return "string"
+		case len(pkg) == 0:
+			return m.TypeExpr(named.Base())
+		case pkg != m.g.ir.Name:
+			m.packages[pkg] = true
+			return pkg + "." + named.String()
+		default:
+			return named.String()
+		}
+	case *types.List:
+		elem := m.TypeExpr(typ.Type)
+		if strings.ContainsAny(elem, "|=") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case *types.Map:
+		key := m.TypeExpr(typ.Key)
+		if key != "string" && key != "number" {
+			key = "string"
+		}
+		return "Record<" + key + ", " + m.TypeExpr(typ.Value) + ">"
+	case *types.Optional:
+		return m.TypeExpr(typ.Type) + " | undefined"
+	case *types.Template:
+		// Templates are rendered into strings where they are used as values
+		return "string"
+	case *types.Fn:
+		return m.fnType(typ.In, typ.Out)
+	case *types.Struct:
+		if len(*typ) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(m.fields(typ), "; ") + " }"
+	default:
+		return "unknown"
+	}
+}
+
+// fnType returns a function type, typescript requires names for its params
+// that are otherwise meaningless
+func (m *module) fnType(in []types.Type, out types.Type) string {
+	params := []string{}
+	for n, typ := range in {
+		params = append(params, "_"+strconv.Itoa(n)+": "+m.TypeExpr(typ))
+	}
+	return "(" + strings.Join(params, ", ") + ") => " + m.TypeExpr(out)
+}
+
+// fields returns the members of a struct type, optional fields may be left
+// out of the objects that are passed in
+func (m *module) fields(typ *types.Struct) []string {
+	fields := []string{}
+	for _, pair := range *typ {
+		if optional, ok := pair.Type.(*types.Optional); ok {
+			fields = append(fields, pair.Name+"?: "+m.TypeExpr(optional.Type))
+			continue
+		}
+		fields = append(fields, pair.Name+": "+m.TypeExpr(pair.Type))
+	}
+	return fields
+}
+
+// Expr returns the typescript code of the given expression
+func (m *module) Expr(x ir.Expr) string {
+	return m.expr(x).code
+}
+
+func (m *module) expr(x ir.Expr) expr {
+	switch x := x.(type) {
+	case *ir.Literal:
+		switch v := x.Value.(type) {
+		case string:
+			return expr{stringLit(v), precPrimary}
+		case bool:
+			return expr{strconv.FormatBool(v), precPrimary}
+		case float64:
+			if v < 0 {
+				return expr{strconv.FormatFloat(v, 'g', -1, 64), precUnary}
+			}
+			return expr{strconv.FormatFloat(v, 'g', -1, 64), precPrimary}
+		}
+		return expr{"undefined", precPrimary}
+	case *ir.Ref:
+		if !m.shadow[x.Name] && m.g.fns[x.Name] {
+			m.fns[x.Name] = true
+		}
+		return expr{ident(x.Name), precPrimary}
+	case *ir.Import:
+		m.packages[x.Package] = true
+		return expr{x.Package + "." + x.Name, precPrimary}
+	case *ir.Binary:
+		return m.binary(x)
+	case *ir.Unary:
+		op := "-"
+		if x.Op == ir.Not {
+			op = "!"
+		}
+		return expr{op + m.expr(x.X).operand(precPrimary), precUnary}
+	case *ir.Ternary:
+		return expr{
+			m.expr(x.Cond).operand(precCoalesce) + " ? " + m.expr(x.Then).operand(precTernary) + " : " + m.expr(x.Else).operand(precTernary),
+			precTernary,
+		}
+	case *ir.Coalesce:
+		// ?? cannot be mixed with && and || without parens
+		return expr{
+			m.expr(x.Left).operand(precAnd+1) + " ?? " + m.expr(x.Right).operand(precAnd+1),
+			precCoalesce,
+		}
+	case *ir.Call:
+		args := []string{}
+		for _, arg := range x.Args {
+			args = append(args, m.expr(arg).operand(precTernary))
+		}
+		return expr{m.expr(x.Fn).operand(precPrimary) + "(" + strings.Join(args, ", ") + ")", precPrimary}
+	case *ir.Convert:
+		return m.convert(m.expr(x.X), types.Default(x.X.Type()), x.Typ)
+	case *ir.Member:
+		return expr{m.expr(x.X).operand(precPrimary) + "." + x.Name, precPrimary}
+	case *ir.Index:
+		index := m.expr(x.X).operand(precPrimary) + "[" + m.Expr(x.Index) + "]"
+		if _, ok := types.RootOf(types.Unwrap(x.X.Type())).(*types.Map); ok {
+			// A missing entry has the zero value of its type like in go
+			if zero := m.zero(x.Typ); zero != "undefined" {
+				return expr{index + " ?? " + zero, precCoalesce}
+			}
+		}
+		return expr{index, precPrimary}
+	case *ir.Template:
+		return expr{m.template(x), precPrimary}
+	default:
+		return expr{"undefined", precPrimary}
+	}
+}
+
+func (m *module) binary(x *ir.Binary) expr {
+	op := operators[x.Op]
+	left, right := m.expr(x.Left), m.expr(x.Right)
+
+	var code string
+	if x.Op == ir.Pow {
+		// ** is right associative and does not take a unary left operand
+		code = left.operand(precPrimary) + " ** " + right.operand(op.prec)
+	} else {
+		code = left.operand(op.prec) + " " + op.token + " " + right.operand(op.prec+1)
+	}
+
+	if x.Op == ir.Div && types.IsInteger(types.Default(x.Typ)) {
+		return expr{"Math.trunc(" + code + ")", precPrimary}
+	}
+	return expr{code, op.prec}
+}
+
+// convert converts a number to the type to, conversions to integer types
+// truncate and wrap around like go conversions
+func (m *module) convert(x expr, from, to types.Type) expr {
+	root := types.RootOf(to)
+	if !types.IsInteger(to) || types.IsInteger(from) && root == types.RootOf(from) {
+		return x
+	}
+
+	// int and uint are 64 bits wide in go whatever their lcl base is
+	if wrap, ok := wraps[root]; ok && !types.Identical(to, types.Int) && !types.Identical(to, types.Uint) {
+		return expr{strings.Replace(wrap.code, "%s", x.operand(precPrimary), 1), wrap.prec}
+	}
+	if types.IsInteger(from) {
+		return x
+	}
+	return expr{"Math.trunc(" + x.code + ")", precPrimary}
+}
+
+// zero returns the zero value of a type
+func (m *module) zero(typ types.Type) string {
+	if typ == nil {
+		return "undefined"
+	}
+
+	switch root := types.RootOf(typ).(type) {
+	case *types.Optional:
+		return "undefined"
+	case *types.List:
+		if types.IsString(typ) {
+			return `""`
+		}
+		return "[]"
+	case *types.Map:
+		return "{}"
+	case *types.Struct:
+		fields := []string{}
+		for _, pair := range *root {
+			if !types.IsOptional(pair.Type) {
+				fields = append(fields, pair.Name+": "+m.zero(pair.Type))
+			}
+		}
+		if len(fields) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	}
+
+	switch {
+	case types.IsString(typ):
+		return `""`
+	case types.RootOf(typ) == types.Bool:
+		return "false"
+	case types.IsNumeric(typ):
+		return "0"
+	default:
+		return "undefined"
+	}
+}
+
+// template returns a template literal, numbers are formatted with the number
+// fn of the module
+func (m *module) template(x *ir.Template) string {
+	b := strings.Builder{}
+	b.WriteString("`")
+
+	for _, segment := range x.Segments {
+		if segment.Value == nil {
+			b.WriteString(escapeTemplate(segment.Text))
+			continue
+		}
+
+		if nested, ok := segment.Value.(*ir.Template); ok {
+			code := m.template(nested)
+			b.WriteString(code[1 : len(code)-1])
+			continue
+		}
+
+		var t types.Type
+		if typ := segment.Value.Type(); typ != nil {
+			t = types.Default(typ)
+		}
+		b.WriteString("${" + m.format(m.expr(segment.Value), t) + "}")
+	}
+
+	b.WriteString("`")
+	return b.String()
+}
+
+// format converts a value to a string according to its type
+func (m *module) format(x expr, t types.Type) string {
+	switch {
+	case t == nil:
+		return x.code
+	case types.IsString(t):
+		return x.code
+	case types.Identical(t, types.Rune):
+		return "String.fromCodePoint(" + x.code + ")"
+	case types.IsNumeric(t):
+		m.formats = true
+		return m.number + "(" + x.code + ")"
+	default:
+		return x.code
+	}
+}
+
+func stringLit(s string) string {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func escapeTemplate(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${", "\r", "\\r").Replace(s)
+}
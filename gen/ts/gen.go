@@ -0,0 +1,388 @@
+// Package tsgen generates typescript from a checked package. The types, fns
+// and section interfaces are declared in an index module and the messages of
+// every locale are kept in a bundle of their own that the index imports
+// lazily.
+package tsgen
+
+import (
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+const header = "// Code generated by lcl. DO NOT EDIT.\n\n"
+
+// number is the fn bundles format numbers with
+const number = "_number"
+
+// reserved holds the words that cannot name a typescript variable, lcl names
+// that are reserved get an underscore appended
+var reserved = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`break case catch class const continue
+		debugger default delete do else enum export extends false finally for
+		function if import in instanceof new null return super switch this
+		throw true try typeof var void while with implements interface let
+		package private protected public static yield await arguments eval
+		undefined`) {
+		reserved[word] = true
+	}
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+type Generator struct {
+	config *Config
+	ir     *ir.IR
+	fns    map[string]bool
+}
+
+// Files returns the generated modules by their file names, the index module
+// and a bundle for every locale named after its tag.
+func (g *Generator) Files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	b := &strings.Builder{}
+	if err := g.Generate(b); err != nil {
+		return nil, err
+	}
+	files[g.config.index+".ts"] = []byte(b.String())
+
+	for _, locale := range g.ir.Locales {
+		b := &strings.Builder{}
+		if err := g.GenerateLocale(b, locale); err != nil {
+			return nil, err
+		}
+		files[bundle(locale)+".ts"] = []byte(b.String())
+	}
+
+	return files, nil
+}
+
+// Generate writes the index module, it declares the types, fns and section
+// interfaces of the package and loads the bundle of a locale on demand.
+func (g *Generator) Generate(w io.Writer) error {
+	ir.Optimize(g.ir)
+	m := g.module("String")
+	b := &strings.Builder{}
+
+	for _, def := range g.ir.TypeDefs {
+		b.WriteString(m.typeDef(def))
+	}
+
+	for _, fn := range g.ir.FnDefs {
+		b.WriteString(m.fnDef(fn))
+	}
+
+	b.WriteString(g.rootDecl())
+	for _, section := range g.ir.Sections {
+		b.WriteString(m.sectionDecl(section, ""))
+	}
+
+	if len(g.ir.Locales) > 0 {
+		b.WriteString(g.loader())
+	}
+
+	_, err := io.WriteString(w, header+m.imports()+strings.TrimSuffix(b.String(), "\n"))
+	return err
+}
+
+// GenerateLocale writes the bundle of a locale, it default exports the
+// messages of the locale.
+func (g *Generator) GenerateLocale(w io.Writer, locale ir.Locale) error {
+	ir.Optimize(g.ir)
+	m := g.module(number)
+	b := &strings.Builder{}
+
+	b.WriteString("const messages: " + g.config.root + " = {\n")
+	for _, section := range g.ir.Sections {
+		b.WriteString(m.sectionValue(section, locale, "  "))
+	}
+	b.WriteString("};\n\nexport default messages;\n")
+
+	names := []string{"type " + g.config.root}
+	for fn := range m.fns {
+		names = append(names, ident(fn))
+	}
+	slices.Sort(names[1:])
+
+	imports := "import { " + strings.Join(names, ", ") + " } from " + stringLit(g.path(g.config.index)) + ";\n"
+	imports += m.imports()
+	if !strings.HasSuffix(imports, "\n\n") {
+		imports += "\n"
+	}
+
+	formatter := ""
+	if m.formats {
+		formatter = "const " + number + " = new Intl.NumberFormat(" + stringLit(locale.Tag.String()) + ").format;\n\n"
+	}
+
+	_, err := io.WriteString(w, header+imports+formatter+b.String())
+	return err
+}
+
+// module returns a module that formats numbers in templates with the fn
+// named format
+func (g *Generator) module(format string) *module {
+	return &module{
+		g:        g,
+		number:   format,
+		shadow:   map[string]bool{},
+		fns:      map[string]bool{},
+		packages: map[string]bool{},
+	}
+}
+
+// imports returns the import declarations of the packages the module refers
+// to
+func (m *module) imports() string {
+	names := []string{}
+	for name := range m.packages {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	slices.Sort(names)
+
+	b := strings.Builder{}
+	for _, name := range names {
+		path, ok := m.g.config.imports[name]
+		if !ok {
+			path = "./" + name
+		}
+		b.WriteString("import * as " + name + " from " + stringLit(m.g.path(path)) + ";\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m *module) typeDef(def ir.TypeDef) string {
+	named, ok := types.Named(def.Type)
+	if !ok {
+		return "export type " + def.Name + " = " + m.TypeExpr(def.Type) + ";\n\n"
+	}
+
+	s, ok := named.Base().(*types.Struct)
+	if !ok {
+		return "export type " + def.Name + " = " + m.TypeExpr(named.Base()) + ";\n\n"
+	}
+
+	b := strings.Builder{}
+	b.WriteString("export interface " + def.Name + " {\n")
+	for _, field := range m.fields(s) {
+		b.WriteString("  " + field + ";\n")
+	}
+	b.WriteString("}\n\n")
+	return b.String()
+}
+
+func (m *module) fnDef(fn ir.FnDef) string {
+	m.scope(fn.Params)
+	defer m.scope(nil)
+
+	return "export function " + ident(fn.Name) + "(" + m.params(fn.Params) + "): " + m.TypeExpr(fn.Type.Out) + " {\n" +
+		"  return " + m.Expr(fn.Body) + ";\n" +
+		"}\n\n"
+}
+
+// scope sets the params that are in scope, they shadow the fns of the package
+func (m *module) scope(params []ir.Param) {
+	m.shadow = map[string]bool{}
+	for _, param := range params {
+		m.shadow[param.Name] = true
+	}
+}
+
+// params returns the typed param list of a fn or template
+func (m *module) params(params []ir.Param) string {
+	list := []string{}
+	for _, param := range params {
+		list = append(list, ident(param.Name)+": "+m.TypeExpr(param.Type))
+	}
+	return strings.Join(list, ", ")
+}
+
+// rootDecl declares the interface that holds the top level sections
+func (g *Generator) rootDecl() string {
+	b := strings.Builder{}
+	b.WriteString("export interface " + g.config.root + " {\n")
+	for _, section := range g.ir.Sections {
+		b.WriteString("  " + section.Name + ": " + capitalize(section.Name) + ";\n")
+	}
+	b.WriteString("}\n\n")
+	return b.String()
+}
+
+// sectionDecl declares the interface of a section and of its sub sections,
+// keys are strings and templates are methods that return strings
+func (m *module) sectionDecl(section *ir.Section, prefix string) string {
+	name := prefix + capitalize(section.Name)
+	b := strings.Builder{}
+	subs := strings.Builder{}
+
+	b.WriteString("export interface " + name + " {\n")
+	for _, message := range section.Messages {
+		if message.IsTemplate {
+			b.WriteString("  " + message.Name + "(" + m.params(message.Params) + "): string;\n")
+		} else {
+			b.WriteString("  " + message.Name + ": string;\n")
+		}
+	}
+
+	for _, sub := range section.Sections {
+		b.WriteString("  " + sub.Name + ": " + name + capitalize(sub.Name) + ";\n")
+		subs.WriteString(m.sectionDecl(sub, name))
+	}
+	b.WriteString("}\n\n")
+
+	return b.String() + subs.String()
+}
+
+// sectionValue returns the property that holds the messages of a section in
+// a bundle, templates are arrow fns whose params are typed by the interface
+// of the section
+func (m *module) sectionValue(section *ir.Section, locale ir.Locale, indent string) string {
+	b := strings.Builder{}
+	b.WriteString(indent + section.Name + ": {\n")
+
+	for _, message := range section.Messages {
+		value := message.Values[locale.Tag]
+		if value == nil {
+			value = &ir.Literal{Value: "", Typ: types.String}
+		}
+
+		if !message.IsTemplate {
+			b.WriteString(indent + "  " + message.Name + ": " + m.Expr(value) + ",\n")
+			continue
+		}
+
+		m.scope(message.Params)
+		names := []string{}
+		for _, param := range message.Params {
+			names = append(names, ident(param.Name))
+		}
+		b.WriteString(indent + "  " + message.Name + ": (" + strings.Join(names, ", ") + ") => " + m.Expr(value) + ",\n")
+		m.scope(nil)
+	}
+
+	for _, sub := range section.Sections {
+		b.WriteString(m.sectionValue(sub, locale, indent+"  "))
+	}
+
+	b.WriteString(indent + "},\n")
+	return b.String()
+}
+
+// loader declares the tags of the locales and the fns that match a tag and
+// load the bundle of its locale
+func (g *Generator) loader() string {
+	tags := []string{}
+	bundles := []string{}
+
+	for _, locale := range g.ir.Locales {
+		tag := stringLit(locale.Tag.String())
+		tags = append(tags, tag)
+
+		key := locale.Tag.String()
+		if !identifier.MatchString(key) {
+			key = tag
+		}
+		bundles = append(bundles, "  "+key+": () => import("+stringLit(g.path("./"+bundle(locale)))+"),\n")
+	}
+
+	root := g.config.root
+	return "export const tags = [" + strings.Join(tags, ", ") + "] as const;\n\n" +
+		"export type Tag = (typeof tags)[number];\n\n" +
+		"const bundles: Record<Tag, () => Promise<{ default: " + root + " }>> = {\n" +
+		strings.Join(bundles, "") +
+		"};\n\n" +
+		"export interface Locale {\n" +
+		"  tag: Tag;\n" +
+		"  messages: " + root + ";\n" +
+		"  number(n: number): string;\n" +
+		"  plural(n: number): Intl.LDMLPluralRule;\n" +
+		"}\n\n" +
+		"// match returns the tag of the locale that matches the given tag the best,\n" +
+		"// a locale of the same language is used if there is no exact match\n" +
+		"export function match(tag: string): Tag {\n" +
+		"  const requested = tag.toLowerCase();\n" +
+		"  const language = requested.split(\"-\")[0];\n" +
+		"  return (\n" +
+		"    tags.find((t) => t.toLowerCase() === requested) ??\n" +
+		"    tags.find((t) => t.toLowerCase().split(\"-\")[0] === language) ??\n" +
+		"    tags[0]\n" +
+		"  );\n" +
+		"}\n\n" +
+		"// load imports the bundle of the locale that matches the given tag the best\n" +
+		"export async function load(tag: string): Promise<Locale> {\n" +
+		"  const matched = match(tag);\n" +
+		"  const { default: messages } = await bundles[matched]();\n" +
+		"  const numbers = new Intl.NumberFormat(matched);\n" +
+		"  const plurals = new Intl.PluralRules(matched);\n" +
+		"  return {\n" +
+		"    tag: matched,\n" +
+		"    messages,\n" +
+		"    number: (n) => numbers.format(n),\n" +
+		"    plural: (n) => plurals.select(n),\n" +
+		"  };\n" +
+		"}\n"
+}
+
+// path returns the module path generated code imports a module by
+func (g *Generator) path(path string) string {
+	if strings.HasPrefix(path, ".") {
+		return path + g.config.extension
+	}
+	if !strings.Contains(path, "/") {
+		return "./" + path + g.config.extension
+	}
+	return path
+}
+
+// bundle returns the module name of the bundle of a locale
+func bundle(locale ir.Locale) string {
+	return locale.Tag.String()
+}
+
+func ident(name string) string {
+	if reserved[name] {
+		return name + "_"
+	}
+	return name
+}
+
+func capitalize(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func New(out *ir.IR, options ...func(*Config)) *Generator {
+	config := &Config{
+		root:    "Messages",
+		index:   "index",
+		imports: map[string]string{},
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	fns := map[string]bool{}
+	for _, fn := range out.FnDefs {
+		fns[fn.Name] = true
+	}
+
+	return &Generator{
+		config: config,
+		ir:     out,
+		fns:    fns,
+	}
+}
@@ -0,0 +1,192 @@
+package tsgen_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CanPacis/lcl/analyzer"
+	tsgen "github.com/CanPacis/lcl/gen/ts"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type GenerateCase struct {
+	In      string
+	Options []func(*tsgen.Config)
+	// Contains maps the file names to the code they should contain
+	Contains map[string][]string
+	Missing  map[string][]string
+}
+
+func (c *GenerateCase) Run(assert *assert.Assertions) {
+	file := parser.NewFile("mock.lcl", bytes.NewBufferString(c.In))
+	tree := test.MustParse(test.WithFile(file))
+	out, err := analyzer.New(file, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+	if !assert.NoError(err) {
+		return
+	}
+
+	files, err := tsgen.New(out, c.Options...).Files()
+	if !assert.NoError(err) {
+		return
+	}
+
+	for name, list := range c.Contains {
+		if !assert.Contains(files, name) {
+			continue
+		}
+		for _, s := range list {
+			assert.Contains(string(files[name]), s)
+		}
+	}
+
+	for name, list := range c.Missing {
+		for _, s := range list {
+			assert.NotContains(string(files[name]), s)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []test.Runner{
+		&GenerateCase{
+			In: `declare shop (en tr "pt-BR" as pt)
+
+type Money f64
+type Item {name:string price:Money note:string?}
+
+fn(n:int) plural n == 1 ? "item" : "items"
+fn(item:Item) label item.name
+
+section cart {
+  title {
+    en "Cart"
+    tr "Sepet"
+    pt "Carrinho"
+  }
+
+  summary(n:int total:Money) {
+    en ` + "`{n} {plural(n)}, {total} in total`" + `
+    tr ` + "`{n} ürün, toplam {total}`" + `
+    pt ` + "`{n} itens`" + `
+  }
+
+  section stock {
+    empty {
+      en "Out of stock"
+      tr "Tükendi"
+      pt "Esgotado"
+    }
+  }
+}`,
+			Contains: map[string][]string{
+				"index.ts": {
+					"// Code generated by lcl. DO NOT EDIT.\n\nexport type Money = number;",
+					"export interface Item {\n  name: string;\n  price: Money;\n  note?: string;\n}",
+					"export function plural(n: number): string {\n  return n === 1 ? \"item\" : \"items\";\n}",
+					"export function label(item: Item): string {\n  return item.name;\n}",
+					"export interface Messages {\n  cart: Cart;\n}",
+					"export interface Cart {\n  title: string;\n  summary(n: number, total: Money): string;\n  stock: CartStock;\n}",
+					"export interface CartStock {\n  empty: string;\n}",
+					`export const tags = ["en", "tr", "pt-BR"] as const;`,
+					"  en: () => import(\"./en\"),\n  tr: () => import(\"./tr\"),\n  \"pt-BR\": () => import(\"./pt-BR\"),",
+					"export function match(tag: string): Tag {",
+					"export async function load(tag: string): Promise<Locale> {",
+					"plural(n: number): Intl.LDMLPluralRule;",
+				},
+				"en.ts": {
+					"import { type Messages, plural } from \"./index\";",
+					"const _number = new Intl.NumberFormat(\"en\").format;",
+					"const messages: Messages = {\n  cart: {\n    title: \"Cart\",",
+					"summary: (n, total) => `${_number(n)} ${plural(n)}, ${_number(total)} in total`,",
+					"    stock: {\n      empty: \"Out of stock\",\n    },\n  },\n};\n\nexport default messages;\n",
+				},
+				"tr.ts": {
+					"import { type Messages } from \"./index\";",
+					"summary: (n, total) => `${_number(n)} ürün, toplam ${_number(total)}`,",
+				},
+				"pt-BR.ts": {
+					"const _number = new Intl.NumberFormat(\"pt-BR\").format;",
+					"empty: \"Esgotado\",",
+				},
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+fn(n:int new:int) wrap i8(n + new) * 2
+fn(a:int b:int) half (a + b) / 2
+fn(a:f64) ratio a / 2
+fn(a:f64 b:f64) power -a ^ (a - b)
+fn(a:bool b:bool c:bool) logic !(a && b) || c
+fn(n:int?) fallback (n ?? 1) > 0 && true
+fn(counts:map[string]u8) count counts["a"]
+fn(items:string[]) first items[0]
+fn(n:int) describe ` + "`#{n} costs ${half(n 1)} \\ {\"`\"}`" + `
+
+section app {
+  title {
+    en ` + "`Hello {\"world\"}`" + `
+  }
+}`,
+			Contains: map[string][]string{
+				"index.ts": {
+					"export function wrap(n: number, new_: number): number {\n  return (((n + new_) << 24) >> 24) * 2;\n}",
+					"return Math.trunc((a + b) / 2);",
+					"return a / 2;",
+					"return -(a ** (a - b));",
+					"return !(a && b) || c;",
+					"return (n ?? 1) > 0;",
+					"return counts[\"a\"] ?? 0;",
+					"return items[0];",
+					"export function describe(n: number): string {\n  return `#${String(n)} costs $${String(half(n, 1))} \\\\ \\``;",
+				},
+				"en.ts": {
+					"title: \"Hello world\",",
+				},
+			},
+			Missing: map[string][]string{
+				"en.ts": {"_number", "import { type Messages, "},
+			},
+		},
+		&GenerateCase{
+			In: `declare app (en)
+
+section app {
+  title {
+    en "Hello"
+  }
+}`,
+			Options: []func(*tsgen.Config){
+				tsgen.WithRoot("strings"),
+				tsgen.WithIndex("app"),
+				tsgen.WithExtension(".js"),
+			},
+			Contains: map[string][]string{
+				"app.ts": {
+					"export interface Strings {\n  app: App;\n}",
+					"en: () => import(\"./en.js\"),",
+				},
+				"en.ts": {
+					"import { type Strings } from \"./app.js\";",
+					"const messages: Strings = {",
+				},
+			},
+		},
+		&GenerateCase{
+			In: `declare app ()
+
+fn(n:int) double n * 2`,
+			Contains: map[string][]string{
+				"index.ts": {"export function double(n: number): number {\n  return n * 2;\n}\n\nexport interface Messages {\n}\n"},
+			},
+			Missing: map[string][]string{
+				"index.ts": {"export const tags", "load"},
+			},
+		},
+	}
+
+	test.Run(t, tests)
+}
@@ -0,0 +1,485 @@
+package goi18n
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/CanPacis/lcl/icu"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/parser/lexer"
+	"github.com/CanPacis/lcl/parser/token"
+	"github.com/CanPacis/lcl/types"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// root is the section of the messages whose ids have no dots
+const root = "messages"
+
+type Config struct {
+	name   string
+	source string
+}
+
+// WithName sets the name of the package, messages by default
+func WithName(name string) func(*Config) {
+	return func(c *Config) {
+		c.name = name
+	}
+}
+
+// WithSource sets the language the package is written in, the language of
+// the first catalog by default
+func WithSource(tag string) func(*Config) {
+	return func(c *Config) {
+		c.source = tag
+	}
+}
+
+// Note is a part of a message that needs review, it is either approximated or
+// left out of the generated source
+type Note struct {
+	Path   string
+	Locale string
+	Text   string
+}
+
+func (n *Note) String() string {
+	if len(n.Locale) == 0 {
+		return fmt.Sprintf("%s: %s", n.Path, n.Text)
+	}
+	return fmt.Sprintf("%s (%s): %s", n.Path, n.Locale, n.Text)
+}
+
+// locale is a language of the catalogs with its messages by their ids
+type locale struct {
+	tag      language.Tag
+	name     string
+	messages map[string]*Message
+}
+
+// Generate returns the source of a package with the messages of catalogs
+// along with the notes of everything that needs review. Every language of the
+// catalogs is a locale and the source one is declared first. Ids are split
+// at their dots into sections and a message, messages a locale has no
+// translation for are copied from the source locale.
+func Generate(catalogs []*Catalog, options ...func(*Config)) (*ast.File, []*Note, error) {
+	config := &Config{name: "messages"}
+	for _, option := range options {
+		option(config)
+	}
+	if len(catalogs) == 0 {
+		return nil, nil, errors.New("no catalogs to generate from")
+	}
+	if len(config.source) == 0 {
+		config.source = catalogs[0].Language
+	}
+
+	source, err := language.Parse(config.source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid source language '%s'", config.source)
+	}
+
+	locales := []*locale{}
+	ids := []string{}
+	for _, catalog := range catalogs {
+		tag, err := language.Parse(catalog.Language)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid language '%s'", catalog.Language)
+		}
+
+		i := slices.IndexFunc(locales, func(l *locale) bool { return l.tag == tag })
+		if i < 0 {
+			locales = append(locales, &locale{tag: tag, name: ident(tag.String()), messages: map[string]*Message{}})
+			i = len(locales) - 1
+		}
+		for _, message := range catalog.Messages {
+			locales[i].messages[message.ID] = message
+			if !slices.Contains(ids, message.ID) {
+				ids = append(ids, message.ID)
+			}
+		}
+	}
+
+	i := slices.IndexFunc(locales, func(l *locale) bool { return l.tag == source })
+	if i < 0 {
+		return nil, nil, fmt.Errorf("no catalog is in the source language %s", source)
+	}
+	first := locales[i]
+	locales = append([]*locale{first}, slices.Delete(locales, i, i+1)...)
+	slices.Sort(ids)
+
+	g := &generator{locales: locales, sections: map[string]*ast.SectionStmt{}, entries: map[string]bool{}}
+	file := &ast.File{
+		Node: ast.NewNode(ast.FileNode, token.Position{}, token.Position{}),
+		Decl: g.decl(ident(config.name)),
+	}
+
+	for _, id := range ids {
+		path := strings.Split(id, ".")
+		if len(path) == 1 {
+			path = []string{root, id}
+		}
+		for i, name := range path {
+			path[i] = ident(name)
+		}
+
+		entry, ok := g.entry(id, path)
+		if !ok {
+			continue
+		}
+
+		section := g.section(file, path[:len(path)-1])
+		section.Body = append(section.Body, entry)
+	}
+
+	return file, g.notes, nil
+}
+
+type generator struct {
+	locales []*locale
+	// sections and entries are the ones generated so far by their paths
+	sections map[string]*ast.SectionStmt
+	entries  map[string]bool
+	notes    []*Note
+}
+
+func (g *generator) note(path, locale, format string, args ...any) {
+	g.notes = append(g.notes, &Note{Path: path, Locale: locale, Text: fmt.Sprintf(format, args...)})
+}
+
+func (g *generator) decl(name string) *ast.DeclStmt {
+	targets := []*ast.DeclTarget{}
+	for _, l := range g.locales {
+		target := &ast.DeclTarget{
+			Node: ast.NewNode(ast.DeclTargetNode, token.Position{}, token.Position{}),
+			Name: identExpr(l.name),
+		}
+		if l.name != l.tag.String() {
+			target.Tag = &ast.StringLitExpr{
+				Node:  ast.NewNode(ast.StringLitExprNode, token.Position{}, token.Position{}),
+				Value: l.tag.String(),
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	return &ast.DeclStmt{
+		Stmt:    ast.NewStmtNode(ast.DeclStmtNode, token.Position{}, token.Position{}),
+		Name:    identExpr(name),
+		Targets: targets,
+	}
+}
+
+// section returns the section of a path, creating the ones that are missing
+func (g *generator) section(file *ast.File, path []string) *ast.SectionStmt {
+	var parent *ast.SectionStmt
+
+	for i, name := range path {
+		key := strings.Join(path[:i+1], ".")
+		section, ok := g.sections[key]
+		if !ok {
+			section = &ast.SectionStmt{
+				Stmt: ast.NewStmtNode(ast.SectionStmtNode, token.Position{}, token.Position{}),
+				Name: identExpr(name),
+			}
+			g.sections[key] = section
+
+			if parent == nil {
+				file.Stmts = append(file.Stmts, section)
+			} else {
+				parent.Body = append(parent.Body, section)
+			}
+		}
+		parent = section
+	}
+
+	return parent
+}
+
+// entry returns the entry of the message of an id, it is left out if its path
+// is taken by another message or section
+func (g *generator) entry(id string, path []string) (ast.Entry, bool) {
+	name := strings.Join(path, ".")
+	if strings.TrimPrefix(name, root+".") != id {
+		g.note(name, "", "named after the id %q", id)
+	}
+	taken := g.entries[name] || g.sections[name] != nil
+	for i := 1; i < len(path); i++ {
+		taken = taken || g.entries[strings.Join(path[:i], ".")]
+	}
+	if taken {
+		g.note(name, "", "the message of the id %q conflicts with another one and is left out", id)
+		return nil, false
+	}
+	g.entries[name] = true
+
+	// Every locale has a message, the ones without a translation use the
+	// message of the source locale or of the first locale that has one
+	messages := make([]*Message, len(g.locales))
+	for i, l := range g.locales {
+		messages[i] = l.messages[id]
+	}
+	fallback := slices.IndexFunc(messages, func(m *Message) bool { return m != nil })
+	for i := range g.locales {
+		if messages[i] == nil {
+			messages[i] = messages[fallback]
+		}
+	}
+
+	// Plural params come first, the rest are in the order they are used in
+	params := []ir.Param{}
+	// typs are the names of the types of params
+	typs := []string{}
+	add := func(m *Message, name string) {
+		if slices.ContainsFunc(params, func(p ir.Param) bool { return p.Name == name }) {
+			return
+		}
+		typ := "string"
+		switch {
+		case len(m.Types[name]) > 0:
+			typ = m.Types[name]
+		case name == m.Plural:
+			typ = "int"
+		}
+		typs = append(typs, typ)
+		params = append(params, ir.Param{Name: name, Type: builtin(typ)})
+	}
+	for _, m := range messages {
+		if len(m.Plural) > 0 {
+			add(m, m.Plural)
+		}
+	}
+	for _, m := range messages {
+		for _, param := range m.Params {
+			add(m, param)
+		}
+	}
+
+	fields := []*ast.Field{}
+	for i, l := range g.locales {
+		m := messages[i]
+		if _, ok := l.messages[id]; ok {
+			for _, text := range m.Notes {
+				g.note(name, l.name, "%s", text)
+			}
+		} else {
+			g.note(name, l.name, "not translated, the message of %s is used", g.locales[fallback].name)
+		}
+
+		// Plurals whose options cannot be joined keep their other form
+		pattern := g.pattern(m, name, l)
+		var value ast.Expr
+		if template, err := icu.Import(pattern, params, l.tag); err == nil {
+			value = template
+		} else if template, other := icu.Import(m.Forms["other"], params, l.tag); len(m.Plural) > 0 && other == nil {
+			g.note(name, l.name, "%s, only the other form is kept", err)
+			value = template
+		} else {
+			g.note(name, l.name, "%s, the pattern is kept as text", err)
+			value = ast.NewText(pattern)
+		}
+
+		fields = append(fields, &ast.Field{
+			Node:  ast.NewNode(ast.FieldNode, token.Position{}, token.Position{}),
+			Tag:   identExpr(l.name),
+			Value: value,
+		})
+	}
+
+	comments := []*ast.CommentStmt{}
+	for _, m := range messages {
+		if len(m.Description) == 0 {
+			continue
+		}
+		for _, line := range strings.Split(m.Description, "\n") {
+			comments = append(comments, &ast.CommentStmt{
+				Stmt:    ast.NewStmtNode(ast.CommentStmtNode, token.Position{}, token.Position{}),
+				Literal: " " + line,
+				Raw:     "# " + line,
+			})
+		}
+		break
+	}
+
+	if len(params) == 0 {
+		for _, field := range fields {
+			field.Value = ast.NewText(text(field.Value))
+		}
+		return &ast.KeyEntry{
+			Node:     ast.NewNode(ast.KeyEntryNode, token.Position{}, token.Position{}),
+			Name:     identExpr(path[len(path)-1]),
+			Fields:   fields,
+			Comments: comments,
+		}, true
+	}
+
+	pairs := []*ast.TypePair{}
+	for i, param := range params {
+		pairs = append(pairs, &ast.TypePair{
+			Node:  ast.NewNode(ast.TypePairNode, token.Position{}, token.Position{}),
+			Index: i,
+			Name:  identExpr(param.Name),
+			Type:  identExpr(typs[i]),
+		})
+	}
+
+	return &ast.TemplateEntry{
+		Node:     ast.NewNode(ast.TemplateEntryNode, token.Position{}, token.Position{}),
+		Name:     identExpr(path[len(path)-1]),
+		Fields:   fields,
+		Params:   pairs,
+		Comments: comments,
+	}, true
+}
+
+// pattern returns the ICU MessageFormat pattern of a message, few and many
+// forms are left out since lcl reads categories as the numbers they are
+// named after
+func (g *generator) pattern(m *Message, path string, l *locale) string {
+	if len(m.Plural) == 0 {
+		return m.Forms["other"]
+	}
+
+	if approximated(l.tag, m.Forms) {
+		g.note(path, l.name, "plural forms are selected by exact counts, not by the plural rules of %s", l.tag)
+	}
+
+	for _, form := range []string{"few", "many"} {
+		if _, ok := m.Forms[form]; ok {
+			g.note(path, l.name, "%s form is left out", form)
+		}
+	}
+
+	selectors := []string{}
+	for selector := range m.Forms {
+		if selector != "few" && selector != "many" {
+			selectors = append(selectors, selector)
+		}
+	}
+	slices.SortFunc(selectors, func(a, b string) int {
+		return rank(a) - rank(b)
+	})
+
+	b := strings.Builder{}
+	b.WriteString("{" + m.Plural + ", plural,")
+	for _, selector := range selectors {
+		// Categories are written as the counts they are read as, ICU patterns
+		// select them by the plural rules of the locale
+		key := selector
+		if n := slices.Index(forms[:3], selector); n >= 0 {
+			key = "=" + strconv.Itoa(n)
+			if _, ok := m.Forms[key]; ok {
+				continue
+			}
+		}
+		b.WriteString(" " + key + " {" + m.Forms[selector] + "}")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// rank orders plural selectors, exact ones come first
+func rank(selector string) int {
+	if n, err := strconv.Atoi(strings.TrimPrefix(selector, "=")); err == nil {
+		return n - 1000
+	}
+	return slices.Index(forms, selector)
+}
+
+// approximated reports whether the forms lcl selects by comparing counts
+// differ from the ones the plural rules of a language select for some count
+func approximated(tag language.Tag, selectors map[string]string) bool {
+	categories := map[plural.Form]string{
+		plural.Zero:  "zero",
+		plural.One:   "one",
+		plural.Two:   "two",
+		plural.Few:   "few",
+		plural.Many:  "many",
+		plural.Other: "other",
+	}
+
+	for n := 0; n < 200; n++ {
+		if _, ok := selectors["="+strconv.Itoa(n)]; ok {
+			continue
+		}
+
+		expected := categories[plural.Cardinal.MatchPlural(tag, n, 0, 0, 0, 0)]
+		if _, ok := selectors[expected]; !ok {
+			expected = "other"
+		}
+		actual := "other"
+		if n < 3 {
+			if _, ok := selectors[forms[n]]; ok {
+				actual = forms[n]
+			}
+		}
+
+		if actual != expected {
+			return true
+		}
+	}
+
+	return false
+}
+
+// text returns the text of a template without interpolations
+func text(value ast.Expr) string {
+	switch value := value.(type) {
+	case *ast.StringLitExpr:
+		return value.Value
+	case *ast.TemplateLitExpr:
+		b := strings.Builder{}
+		for _, part := range value.Value {
+			b.WriteString(text(part))
+		}
+		return b.String()
+	default:
+		return ""
+	}
+}
+
+// builtin returns the type of a param as far as patterns are concerned,
+// every number is an int to them
+func builtin(name string) types.Type {
+	switch name {
+	case "string":
+		return types.String
+	case "bool":
+		return types.Bool
+	default:
+		return types.Int
+	}
+}
+
+// ident returns an identifier for a name, characters identifiers cannot
+// have become underscores
+func ident(name string) string {
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			runes[i] = '_'
+		}
+	}
+	if len(runes) == 0 || !unicode.IsLetter(runes[0]) {
+		runes = append([]rune("m"), runes...)
+	}
+
+	s := string(runes)
+	if lexer.IsKeyword(s) {
+		s += "_"
+	}
+	return s
+}
+
+func identExpr(value string) *ast.IdentExpr {
+	return &ast.IdentExpr{
+		Node:  ast.NewNode(ast.IdentExprNode, token.Position{}, token.Position{}),
+		Value: value,
+	}
+}
@@ -0,0 +1,242 @@
+// Package goi18n generates lcl source from the message files of go-i18n and
+// the catalogs of golang.org/x/text. Messages are read into ICU MessageFormat
+// patterns, placeholders become params of templates and plural forms become
+// plurals over the count of the message. Whatever lcl cannot express the
+// same way is noted for review.
+package goi18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/icu"
+	"golang.org/x/text/language"
+)
+
+// PluralCount is the param the plural forms of go-i18n messages are selected
+// by, named after the field of its localize config
+const PluralCount = "PluralCount"
+
+type Message struct {
+	ID          string
+	Description string
+	// Plural is the param a plural message is selected by, it is empty if the
+	// message is not plural
+	Plural string
+	// Forms are the patterns of a plural message by their selectors, a
+	// message that is not plural has only other
+	Forms map[string]string
+	// Params are the names of the placeholders of the message in order
+	Params []string
+	// Types are the lcl types of params, params without one are strings
+	Types map[string]string
+	// Notes are the parts of the message that need review
+	Notes []string
+}
+
+type Catalog struct {
+	// Language is the tag of the locale of the catalog
+	Language string
+	Messages []*Message
+}
+
+// reserved are the keys of a go-i18n message, a table with only these keys
+// is a message and any other table holds messages
+var reserved = []string{"id", "description", "hash", "leftdelim", "rightdelim", "zero", "one", "two", "few", "many", "other"}
+
+// forms are the plural forms of go-i18n in the order they are written
+var forms = []string{"zero", "one", "two", "few", "many", "other"}
+
+// Parse reads a go-i18n message file in TOML or JSON by the extension of its
+// name. The language of the catalog is the last part of the name that is a
+// language tag, active.en.toml is English. Nested messages are joined into
+// dotted ids.
+func Parse(name string, src []byte) (*Catalog, error) {
+	malformed := func(value string) error {
+		return &errs.ImportError{Err: errs.ErrMalformedFile, Format: "go-i18n", Value: value}
+	}
+
+	var root map[string]any
+	var err error
+	switch ext := filepath.Ext(name); ext {
+	case ".toml":
+		root, err = parseTOML(string(src))
+	case ".json":
+		err = json.Unmarshal(src, &root)
+	default:
+		return nil, malformed(fmt.Sprintf("unknown extension '%s', expected .toml or .json", ext))
+	}
+	if err != nil {
+		return nil, malformed(err.Error())
+	}
+
+	catalog := &Catalog{}
+	parts := strings.Split(strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)), ".")
+	for i := len(parts) - 1; i >= 0 && len(catalog.Language) == 0; i-- {
+		if tag, err := language.Parse(parts[i]); err == nil {
+			catalog.Language = tag.String()
+		}
+	}
+	if len(catalog.Language) == 0 {
+		return nil, malformed(fmt.Sprintf("%s does not name a language", filepath.Base(name)))
+	}
+
+	var walk func(table map[string]any, prefix string) error
+	walk = func(table map[string]any, prefix string) error {
+		for key, value := range table {
+			switch value := value.(type) {
+			case string:
+				catalog.Messages = append(catalog.Messages, message(prefix+key, map[string]any{"other": value}))
+			case map[string]any:
+				if !isMessage(value) {
+					if err := walk(value, prefix+key+"."); err != nil {
+						return err
+					}
+					continue
+				}
+				catalog.Messages = append(catalog.Messages, message(prefix+key, value))
+			default:
+				return malformed(fmt.Sprintf("%s%s is not a message", prefix, key))
+			}
+		}
+		return nil
+	}
+	if err := walk(root, ""); err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(catalog.Messages, func(a, b *Message) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return catalog, nil
+}
+
+func isMessage(table map[string]any) bool {
+	for key := range table {
+		if !slices.Contains(reserved, strings.ToLower(key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// message reads the fields of a go-i18n message
+func message(id string, table map[string]any) *Message {
+	fields := map[string]string{}
+	for key, value := range table {
+		if s, ok := value.(string); ok {
+			fields[strings.ToLower(key)] = s
+		}
+	}
+
+	m := &Message{ID: id, Description: fields["description"], Forms: map[string]string{}}
+	if len(fields["id"]) > 0 {
+		m.ID = fields["id"]
+	}
+	left, right := fields["leftdelim"], fields["rightdelim"]
+	if len(left) == 0 {
+		left = "{{"
+	}
+	if len(right) == 0 {
+		right = "}}"
+	}
+
+	for _, form := range forms {
+		if _, ok := fields[form]; ok && form != "other" {
+			m.Plural = PluralCount
+		}
+	}
+
+	for _, form := range forms {
+		text, ok := fields[form]
+		if !ok {
+			continue
+		}
+		m.Forms[form] = m.template(text, left, right)
+	}
+
+	return m
+}
+
+var field = regexp.MustCompile(`^\.([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// template returns the pattern of a text/template, fields of the data become
+// arguments and other actions are kept as text and noted
+func (m *Message) template(text, left, right string) string {
+	b := strings.Builder{}
+	// pending is the text up to the next argument
+	pending := ""
+	// trim is set if the last action trims the space after it
+	trim := false
+
+	for len(text) > 0 {
+		start := strings.Index(text, left)
+		end := -1
+		if start >= 0 {
+			end = strings.Index(text[start:], right)
+		}
+		if end < 0 {
+			if trim {
+				text = strings.TrimLeft(text, " \t\r\n")
+			}
+			pending += text
+			break
+		}
+		end += start
+
+		before := text[:start]
+		action := text[start+len(left) : end]
+		text = text[end+len(right):]
+
+		if trim {
+			before = strings.TrimLeft(before, " \t\r\n")
+		}
+		if strings.HasPrefix(action, "- ") {
+			before = strings.TrimRight(before, " \t\r\n")
+		}
+		trim = strings.HasSuffix(action, " -")
+		pending += before
+
+		code := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(action, "- "), " -"))
+		if match := field.FindStringSubmatch(code); match != nil {
+			b.WriteString(m.escape(pending, true))
+			b.WriteString(m.argument(match[1]))
+			pending = ""
+			continue
+		}
+
+		m.Notes = append(m.Notes, fmt.Sprintf("template action %s is kept as text", left+action+right))
+		pending += left + action + right
+	}
+
+	b.WriteString(m.escape(pending, false))
+	return b.String()
+}
+
+// argument adds a param to the message and returns its argument
+func (m *Message) argument(name string) string {
+	name = ident(name)
+	if !slices.Contains(m.Params, name) {
+		m.Params = append(m.Params, name)
+	}
+	return "{" + name + "}"
+}
+
+// escape quotes the text of a pattern, an apostrophe before an argument would
+// quote it so the text is escaped as if it went on with a brace
+func (m *Message) escape(text string, argument bool) string {
+	escape := icu.Escape
+	if len(m.Plural) > 0 {
+		escape = icu.EscapePlural
+	}
+
+	if argument && strings.HasSuffix(text, "'") {
+		return strings.TrimSuffix(escape(text+"{"), "'{'")
+	}
+	return escape(text)
+}
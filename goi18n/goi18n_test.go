@@ -0,0 +1,256 @@
+package goi18n_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CanPacis/lcl/analyzer"
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/goi18n"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/parser/printer"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const english = `# go-i18n messages
+HelloWorld = "Hello World!"
+
+[PersonCats]
+description = "The number of cats a person has"
+one = "{{.Name}} has {{.Count}} cat."
+other = "{{.Name}} has {{.Count}} cats."
+
+[cart]
+title = 'Your "cart"'
+"empty-state" = { other = "Nothing {here}" }
+
+[cart.greeting]
+other = """
+Welcome {{ .Name -}} , it's {{.Day}}\
+    {{if .Admin}}!{{end}}"""
+`
+
+const russian = `{
+  "HelloWorld": "Привет мир!",
+  "PersonCats": {
+    "one": "У {{.Name}} {{.Count}} кошка.",
+    "few": "У {{.Name}} {{.Count}} кошки.",
+    "many": "У {{.Name}} {{.Count}} кошек.",
+    "other": "У {{.Name}} {{.Count}} кошки."
+  },
+  "cart": {"title": "Ваша корзина"}
+}`
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	catalog, err := goi18n.Parse("locales/active.en.toml", []byte(english))
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.Equal("en", catalog.Language)
+	if assert.Len(catalog.Messages, 5) {
+		assert.Equal(&goi18n.Message{
+			ID:          "PersonCats",
+			Description: "The number of cats a person has",
+			Plural:      goi18n.PluralCount,
+			Forms: map[string]string{
+				"one":   "{Name} has {Count} cat.",
+				"other": "{Name} has {Count} cats.",
+			},
+			Params: []string{"Name", "Count"},
+		}, catalog.Messages[1])
+
+		greeting := catalog.Messages[3]
+		assert.Equal("cart.greeting", greeting.ID)
+		assert.Equal(map[string]string{"other": "Welcome {Name}, it's {Day}'{{'if .Admin'}}'!'{{'end'}}'"}, greeting.Forms)
+		assert.Equal([]string{
+			"template action {{if .Admin}} is kept as text",
+			"template action {{end}} is kept as text",
+		}, greeting.Notes)
+
+		assert.Equal("cart.empty-state", catalog.Messages[2].ID)
+		assert.Equal(map[string]string{"other": "Nothing '{'here'}'"}, catalog.Messages[2].Forms)
+		assert.Equal(map[string]string{"other": `Your "cart"`}, catalog.Messages[4].Forms)
+	}
+
+	catalog, err = goi18n.Parse("active.ru.json", []byte(russian))
+	if assert.NoError(err) && assert.Len(catalog.Messages, 3) {
+		assert.Equal("ru", catalog.Language)
+		assert.Len(catalog.Messages[1].Forms, 4)
+	}
+
+	for name, src := range map[string]string{
+		"active.toml":    `a = "b"`,
+		"active.en.yaml": `a: b`,
+		"active.en.toml": `a = 1`,
+		"messages.tr.toml": `[a]
+b = "c"
+[a]
+b = "d"`,
+	} {
+		_, err := goi18n.Parse(name, []byte(src))
+		assert.ErrorIs(err, errs.ErrMalformedFile, name)
+	}
+}
+
+func TestParseGotext(t *testing.T) {
+	assert := assert.New(t)
+
+	catalog, err := goi18n.ParseGotext([]byte(`{
+  "language": "de-DE",
+  "messages": [
+    {
+      "id": "Hello {City}!",
+      "message": "Hello {City}!",
+      "translation": "Hallo {City}, {Other}!",
+      "fuzzy": true,
+      "placeholders": [{"id": "City", "string": "%[1]s", "type": "string", "underlyingType": "string", "argNum": 1}]
+    },
+    {
+      "id": ["files.count", "{N} files"],
+      "key": "files.count",
+      "message": "{N} files",
+      "comment": "Number of files",
+      "translation": {
+        "select": {
+          "feature": "plural",
+          "arg": "N",
+          "cases": {"=0": {"msg": "keine Dateien"}, "one": {"msg": "eine Datei"}, "<5": {"msg": "wenige"}, "other": {"msg": "{N} Dateien"}}
+        }
+      },
+      "placeholders": [{"id": "N", "string": "%[1]d", "type": "time.Duration", "underlyingType": "int64", "argNum": 1}]
+    },
+    {"id": "Untranslated", "message": "Untranslated", "translation": ""}
+  ]
+}`))
+	if !assert.NoError(err) || !assert.Len(catalog.Messages, 2) {
+		return
+	}
+
+	assert.Equal("de-DE", catalog.Language)
+	assert.Equal(&goi18n.Message{
+		ID:          "files.count",
+		Description: "Number of files",
+		Plural:      "N",
+		Forms: map[string]string{
+			"=0":    "keine Dateien",
+			"one":   "eine Datei",
+			"other": "{N} Dateien",
+		},
+		Params: []string{"N"},
+		Types:  map[string]string{"N": "i64"},
+		Notes:  []string{"case <5 is not supported"},
+	}, catalog.Messages[0])
+	assert.Equal(&goi18n.Message{
+		ID:     "hello_city",
+		Forms:  map[string]string{"other": "Hallo {City}, '{'Other'}'!"},
+		Params: []string{"City"},
+		Types:  map[string]string{"City": "string"},
+		Notes:  []string{"translation is fuzzy"},
+	}, catalog.Messages[1])
+
+	_, err = goi18n.ParseGotext([]byte(`{"messages": []}`))
+	assert.ErrorIs(err, errs.ErrMalformedFile)
+}
+
+func TestGenerate(t *testing.T) {
+	assert := assert.New(t)
+
+	en, err := goi18n.Parse("active.en.toml", []byte(english))
+	assert.NoError(err)
+	ru, err := goi18n.Parse("active.ru.json", []byte(russian))
+	assert.NoError(err)
+
+	tree, notes, err := goi18n.Generate([]*goi18n.Catalog{ru, en}, goi18n.WithName("shop"), goi18n.WithSource("en"))
+	if !assert.NoError(err) {
+		return
+	}
+
+	src := printer.String(tree)
+	assert.Equal(`declare shop (en ru)
+
+section messages {
+  HelloWorld {
+    en "Hello World!"
+    ru "Привет мир!"
+  }
+
+  # The number of cats a person has
+  PersonCats(PluralCount:int Name:string Count:string) {
+    en `+"`{Name} has {Count}{PluralCount == 1 ? \" cat.\" : \" cats.\"}`"+`
+    ru `+"`У {Name} {Count}{PluralCount == 1 ? \" кошка.\" : \" кошки.\"}`"+`
+  }
+}
+
+section cart {
+  empty_state {
+    en "Nothing {here}"
+    ru "Nothing {here}"
+  }
+
+  greeting(Name:string Day:string) {
+    en `+"`Welcome {Name}, it's {Day}{\"{\"}{\"{\"}if .Admin}}!{\"{\"}{\"{\"}end}}`"+`
+    ru `+"`Welcome {Name}, it's {Day}{\"{\"}{\"{\"}if .Admin}}!{\"{\"}{\"{\"}end}}`"+`
+  }
+
+  title {
+    en "Your \"cart\""
+    ru "Ваша корзина"
+  }
+}
+`, src)
+
+	lines := []string{}
+	for _, note := range notes {
+		lines = append(lines, note.String())
+	}
+	assert.Equal([]string{
+		"messages.PersonCats (ru): plural forms are selected by exact counts, not by the plural rules of ru",
+		"messages.PersonCats (ru): few form is left out",
+		"messages.PersonCats (ru): many form is left out",
+		"cart.empty_state: named after the id \"cart.empty-state\"",
+		"cart.empty_state (ru): not translated, the message of en is used",
+		"cart.greeting (en): template action {{if .Admin}} is kept as text",
+		"cart.greeting (en): template action {{end}} is kept as text",
+		"cart.greeting (ru): not translated, the message of en is used",
+	}, lines)
+
+	file := parser.NewFile("shop.lcl", bytes.NewBufferString(src))
+	parsed := test.MustParse(test.WithFile(file))
+	_, err = analyzer.New(file, parsed, pkg.New("shop")).Scan()
+	assert.NoError(err)
+
+	// Plurals whose options cannot be joined keep their other form and ids
+	// that clash with sections are left out
+	de := &goi18n.Catalog{Language: "de", Messages: []*goi18n.Message{
+		{ID: "files", Forms: map[string]string{"other": "Dateien"}},
+		{ID: "files.count", Plural: "N", Forms: map[string]string{"=0": "keine", "other": "{N} Dateien"}, Params: []string{"N"}},
+		{ID: "files.count.all", Forms: map[string]string{"other": "alle"}},
+	}}
+	tree, notes, err = goi18n.Generate([]*goi18n.Catalog{de})
+	if assert.NoError(err) {
+		assert.Equal(`declare messages (de)
+
+section messages {
+  files {
+    de "Dateien"
+  }
+}
+
+section files {
+  count(N:int) {
+    de `+"`{N} Dateien`"+`
+  }
+}
+`, printer.String(tree))
+		assert.Len(notes, 2)
+		assert.Equal("files.count.all: the message of the id \"files.count.all\" conflicts with another one and is left out", notes[1].String())
+	}
+
+	_, _, err = goi18n.Generate([]*goi18n.Catalog{de}, goi18n.WithSource("fr"))
+	assert.EqualError(err, "no catalog is in the source language fr")
+}
@@ -0,0 +1,217 @@
+package goi18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+)
+
+// builtins are the lcl types of the go types of placeholders
+var builtins = map[string]string{
+	"string":  "string",
+	"bool":    "bool",
+	"int":     "int",
+	"int8":    "i8",
+	"int16":   "i16",
+	"int32":   "i32",
+	"int64":   "i64",
+	"uint":    "uint",
+	"uint8":   "u8",
+	"uint16":  "u16",
+	"uint32":  "u32",
+	"uint64":  "u64",
+	"float32": "f32",
+	"float64": "f64",
+	"byte":    "byte",
+	"rune":    "rune",
+}
+
+type gotextFile struct {
+	Language string `json:"language"`
+	Messages []struct {
+		ID           json.RawMessage `json:"id"`
+		Key          string          `json:"key"`
+		Translation  gotextText      `json:"translation"`
+		Comment      string          `json:"comment"`
+		Fuzzy        bool            `json:"fuzzy"`
+		Placeholders []struct {
+			ID             string `json:"id"`
+			Type           string `json:"type"`
+			UnderlyingType string `json:"underlyingType"`
+		} `json:"placeholders"`
+	} `json:"messages"`
+}
+
+// gotextText is a text of a catalog, a string or a message with a select
+type gotextText struct {
+	Msg    string `json:"msg"`
+	Select *struct {
+		Feature string                `json:"feature"`
+		Arg     string                `json:"arg"`
+		Cases   map[string]gotextText `json:"cases"`
+	} `json:"select"`
+	Var map[string]json.RawMessage `json:"var"`
+}
+
+func (t *gotextText) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.Msg); err == nil {
+		return nil
+	}
+
+	type text gotextText
+	return json.Unmarshal(data, (*text)(t))
+}
+
+var key = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ParseGotext reads a catalog the gotext tool of golang.org/x/text writes,
+// messages.gotext.json or out.gotext.json. Messages are named after their
+// keys, or after the words of their ids if they have none. Untranslated
+// messages are left out.
+func ParseGotext(src []byte) (*Catalog, error) {
+	file := &gotextFile{}
+	if err := json.Unmarshal(src, file); err != nil {
+		return nil, &errs.ImportError{Err: errs.ErrMalformedFile, Format: "gotext", Value: err.Error()}
+	}
+	if len(file.Language) == 0 {
+		return nil, &errs.ImportError{Err: errs.ErrMalformedFile, Format: "gotext", Value: "catalog declares no language"}
+	}
+
+	catalog := &Catalog{Language: file.Language}
+	for _, entry := range file.Messages {
+		ids := []string{}
+		if err := json.Unmarshal(entry.ID, &ids); err != nil {
+			var id string
+			if err := json.Unmarshal(entry.ID, &id); err != nil {
+				return nil, &errs.ImportError{Err: errs.ErrMalformedFile, Format: "gotext", Value: "invalid message id " + string(entry.ID)}
+			}
+			ids = []string{id}
+		}
+
+		m := &Message{Description: entry.Comment, Forms: map[string]string{}, Types: map[string]string{}}
+		switch {
+		case len(entry.Key) > 0:
+			m.ID = entry.Key
+		case len(ids) > 0 && key.MatchString(ids[0]):
+			m.ID = ids[0]
+		case len(ids) > 0:
+			m.ID = slug(ids[0])
+		}
+
+		placeholders := []string{}
+		for _, p := range entry.Placeholders {
+			placeholders = append(placeholders, p.ID)
+			typ, ok := builtins[p.UnderlyingType]
+			if !ok {
+				typ = "string"
+				m.Notes = append(m.Notes, fmt.Sprintf("placeholder %s of type %s is read as a string", p.ID, p.Type))
+			}
+			m.Types[ident(p.ID)] = typ
+		}
+
+		t := entry.Translation
+		if len(t.Var) > 0 {
+			m.Notes = append(m.Notes, "variables of the translation are not supported")
+		}
+		if entry.Fuzzy {
+			m.Notes = append(m.Notes, "translation is fuzzy")
+		}
+
+		if t.Select == nil {
+			if len(t.Msg) == 0 {
+				continue
+			}
+			m.Forms["other"] = m.text(t.Msg, placeholders)
+			catalog.Messages = append(catalog.Messages, m)
+			continue
+		}
+
+		if t.Select.Feature != "plural" {
+			m.Notes = append(m.Notes, fmt.Sprintf("%s select is not supported, the other case is kept", t.Select.Feature))
+		} else {
+			m.Plural = strings.TrimPrefix(t.Select.Arg, "%")
+			if n, err := strconv.Atoi(m.Plural); err == nil && n > 0 && n <= len(placeholders) {
+				m.Plural = placeholders[n-1]
+			}
+			m.Plural = ident(m.Plural)
+		}
+
+		selectors := []string{}
+		for selector := range t.Select.Cases {
+			selectors = append(selectors, selector)
+		}
+		slices.Sort(selectors)
+
+		for _, selector := range selectors {
+			c := t.Select.Cases[selector]
+			switch {
+			case c.Select != nil:
+				m.Notes = append(m.Notes, fmt.Sprintf("nested select of case %s is not supported", selector))
+			case selector == "other", len(m.Plural) > 0 && (slices.Contains(forms, selector) || strings.HasPrefix(selector, "=")):
+				m.Forms[selector] = m.text(c.Msg, placeholders)
+			default:
+				m.Notes = append(m.Notes, fmt.Sprintf("case %s is not supported", selector))
+			}
+		}
+		if _, ok := m.Forms["other"]; !ok {
+			m.Notes = append(m.Notes, "select has no other case")
+			continue
+		}
+		if len(m.Forms) == 1 {
+			m.Plural = ""
+		}
+		catalog.Messages = append(catalog.Messages, m)
+	}
+
+	slices.SortFunc(catalog.Messages, func(a, b *Message) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return catalog, nil
+}
+
+var substitution = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// text returns the pattern of a text of a catalog, substitutions of
+// placeholders become arguments
+func (m *Message) text(s string, placeholders []string) string {
+	b := strings.Builder{}
+	pending := ""
+
+	for len(s) > 0 {
+		match := substitution.FindStringSubmatchIndex(s)
+		if match == nil {
+			pending += s
+			break
+		}
+
+		name := s[match[2]:match[3]]
+		pending += s[:match[0]]
+		if !slices.Contains(placeholders, name) {
+			pending += s[match[0]:match[1]]
+		} else {
+			b.WriteString(m.escape(pending, true))
+			b.WriteString(m.argument(name))
+			pending = ""
+		}
+		s = s[match[1]:]
+	}
+
+	b.WriteString(m.escape(pending, false))
+	return b.String()
+}
+
+// slug returns a name for a message from the first words of its id
+func slug(id string) string {
+	words := strings.FieldsFunc(strings.ToLower(id), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	if len(words) > 5 {
+		words = words[:5]
+	}
+	return strings.Join(words, "_")
+}
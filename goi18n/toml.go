@@ -0,0 +1,312 @@
+package goi18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// parseTOML reads the subset of TOML message files are written in, tables,
+// dotted keys, inline tables and strings. Other values are reported.
+func parseTOML(src string) (map[string]any, error) {
+	t := &toml{src: []rune(src), line: 1}
+	root := map[string]any{}
+	table := root
+
+	for {
+		t.blank()
+		if t.done() {
+			return root, nil
+		}
+
+		if t.peek() == '[' {
+			t.pos++
+			if t.peek() == '[' {
+				return nil, t.errorf("arrays of tables are not supported")
+			}
+			path, err := t.keys()
+			if err != nil {
+				return nil, err
+			}
+			if err := t.expect(']'); err != nil {
+				return nil, err
+			}
+			if table, err = t.table(root, path); err != nil {
+				return nil, err
+			}
+		} else if err := t.pair(table); err != nil {
+			return nil, err
+		}
+
+		t.space()
+		t.comment()
+		if !t.done() && t.peek() != '\n' {
+			return nil, t.errorf("expected a new line")
+		}
+	}
+}
+
+type toml struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+func (t *toml) done() bool {
+	return t.pos >= len(t.src)
+}
+
+func (t *toml) peek() rune {
+	if t.done() {
+		return 0
+	}
+	return t.src[t.pos]
+}
+
+func (t *toml) next() rune {
+	r := t.peek()
+	t.pos++
+	if r == '\n' {
+		t.line++
+	}
+	return r
+}
+
+func (t *toml) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", t.line, fmt.Sprintf(format, args...))
+}
+
+func (t *toml) expect(r rune) error {
+	t.space()
+	if t.peek() != r {
+		return t.errorf("expected '%c'", r)
+	}
+	t.next()
+	return nil
+}
+
+// space skips spaces on the line
+func (t *toml) space() {
+	for t.peek() == ' ' || t.peek() == '\t' || t.peek() == '\r' {
+		t.next()
+	}
+}
+
+func (t *toml) comment() {
+	if t.peek() != '#' {
+		return
+	}
+	for !t.done() && t.peek() != '\n' {
+		t.next()
+	}
+}
+
+// blank skips spaces, comments and new lines
+func (t *toml) blank() {
+	for {
+		t.space()
+		t.comment()
+		if t.peek() != '\n' {
+			return
+		}
+		t.next()
+	}
+}
+
+// table returns the table of a path, creating the ones that are missing
+func (t *toml) table(root map[string]any, path []string) (map[string]any, error) {
+	table := root
+	for _, key := range path {
+		switch value := table[key].(type) {
+		case nil:
+			sub := map[string]any{}
+			table[key] = sub
+			table = sub
+		case map[string]any:
+			table = value
+		default:
+			return nil, t.errorf("key %s is already a value", key)
+		}
+	}
+	return table, nil
+}
+
+func (t *toml) pair(table map[string]any) error {
+	path, err := t.keys()
+	if err != nil {
+		return err
+	}
+	if err := t.expect('='); err != nil {
+		return err
+	}
+	t.space()
+
+	value, err := t.value()
+	if err != nil {
+		return err
+	}
+
+	table, err = t.table(table, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	key := path[len(path)-1]
+	if _, ok := table[key]; ok {
+		return t.errorf("duplicate key %s", key)
+	}
+	table[key] = value
+	return nil
+}
+
+// keys reads a dotted key
+func (t *toml) keys() ([]string, error) {
+	path := []string{}
+
+	for {
+		t.space()
+
+		switch r := t.peek(); {
+		case r == '"' || r == '\'':
+			key, err := t.string()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, key)
+		case r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := t.pos
+			for r := t.peek(); r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r); r = t.peek() {
+				t.next()
+			}
+			path = append(path, string(t.src[start:t.pos]))
+		default:
+			return nil, t.errorf("expected a key")
+		}
+
+		t.space()
+		if t.peek() != '.' {
+			return path, nil
+		}
+		t.next()
+	}
+}
+
+func (t *toml) value() (any, error) {
+	switch t.peek() {
+	case '"', '\'':
+		return t.string()
+	case '{':
+		t.next()
+		table := map[string]any{}
+		t.space()
+		if t.peek() == '}' {
+			t.next()
+			return table, nil
+		}
+		for {
+			if err := t.pair(table); err != nil {
+				return nil, err
+			}
+			t.space()
+			switch t.next() {
+			case ',':
+			case '}':
+				return table, nil
+			default:
+				return nil, t.errorf("expected ',' or '}'")
+			}
+		}
+	default:
+		return nil, t.errorf("only strings and tables are supported as values")
+	}
+}
+
+func (t *toml) string() (string, error) {
+	quote := t.next()
+	multiline := t.peek() == quote && t.pos+1 < len(t.src) && t.src[t.pos+1] == quote
+	if multiline {
+		t.next()
+		t.next()
+		// A new line right after the opening quotes is trimmed
+		if t.peek() == '\r' {
+			t.next()
+		}
+		if t.peek() == '\n' {
+			t.next()
+		}
+	}
+
+	b := strings.Builder{}
+	for {
+		if t.done() {
+			return "", t.errorf("unterminated string")
+		}
+
+		r := t.next()
+		switch {
+		case r == quote && !multiline:
+			return b.String(), nil
+		case r == quote && t.peek() == quote && t.pos+1 < len(t.src) && t.src[t.pos+1] == quote:
+			t.next()
+			t.next()
+			// Up to two quotes may close the string along with the delimiter
+			for t.peek() == quote && !t.done() {
+				b.WriteRune(t.next())
+			}
+			return b.String(), nil
+		case r == '\n' && !multiline:
+			return "", t.errorf("unterminated string")
+		case r == '\\' && quote == '"':
+			if err := t.escape(&b, multiline); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func (t *toml) escape(b *strings.Builder, multiline bool) error {
+	r := t.next()
+
+	switch r {
+	case 'b':
+		b.WriteRune('\b')
+	case 't':
+		b.WriteRune('\t')
+	case 'n':
+		b.WriteRune('\n')
+	case 'f':
+		b.WriteRune('\f')
+	case 'r':
+		b.WriteRune('\r')
+	case '"', '\\':
+		b.WriteRune(r)
+	case 'u', 'U':
+		size := 4
+		if r == 'U' {
+			size = 8
+		}
+		if t.pos+size > len(t.src) {
+			return t.errorf("invalid escape")
+		}
+		code, err := strconv.ParseUint(string(t.src[t.pos:t.pos+size]), 16, 32)
+		if err != nil {
+			return t.errorf("invalid escape")
+		}
+		t.pos += size
+		b.WriteRune(rune(code))
+	default:
+		// A backslash at the end of a line of a multiline string trims the
+		// white space up to the next text
+		if multiline && unicode.IsSpace(r) {
+			for unicode.IsSpace(t.peek()) {
+				t.next()
+			}
+			return nil
+		}
+		return t.errorf("invalid escape \\%c", r)
+	}
+
+	return nil
+}
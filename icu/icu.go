@@ -0,0 +1,279 @@
+// Package icu converts the messages of a package to ICU MessageFormat
+// patterns.
+package icu
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+// Export returns the ICU MessageFormat pattern of the value of a message.
+// Text is quoted where ICU would read it as syntax and params become
+// arguments, numeric ones are formatted as numbers. Ternaries that compare a
+// param against constants become plurals for numbers and selects otherwise,
+// their branches may join strings. Expressions that have no ICU counterpart
+// are reported with an *errs.ExportError.
+func Export(expr ir.Expr) (string, error) {
+	e := &exporter{}
+	if err := e.export(expr); err != nil {
+		return "", err
+	}
+	return e.String(), nil
+}
+
+// ExportSimple is Export for implementations that format arguments on their
+// own, like the gen_l10n tool of Flutter does from the metadata of its
+// bundles. Arguments carry no format and the count of a plural is written as
+// its argument rather than as #.
+func ExportSimple(expr ir.Expr) (string, error) {
+	e := &exporter{simple: true}
+	if err := e.export(expr); err != nil {
+		return "", err
+	}
+	return e.String(), nil
+}
+
+type exporter struct {
+	strings.Builder
+	// plural is the param of the plural being written, it is written as #
+	plural string
+	simple bool
+}
+
+func (e *exporter) export(expr ir.Expr) error {
+	switch expr := expr.(type) {
+	case *ir.Literal:
+		e.WriteString(escape(expr.Text(), e.isPlural()))
+	case *ir.Template:
+		for _, segment := range expr.Segments {
+			if segment.Value == nil {
+				e.WriteString(escape(segment.Text, e.isPlural()))
+				continue
+			}
+			if err := e.export(segment.Value); err != nil {
+				return err
+			}
+		}
+	case *ir.Ref:
+		switch {
+		case e.simple:
+			e.WriteString("{" + expr.Name + "}")
+		case expr.Name == e.plural:
+			e.WriteString("#")
+		default:
+			e.WriteString(placeholder(expr.Name, expr.Typ))
+		}
+	case *ir.Binary:
+		if expr.Op != ir.Add || !types.IsString(expr.Typ) {
+			return unrepresentable(expr)
+		}
+		if err := e.export(expr.Left); err != nil {
+			return err
+		}
+		return e.export(expr.Right)
+	case *ir.Ternary:
+		return e.choice(expr)
+	default:
+		return unrepresentable(expr)
+	}
+
+	return nil
+}
+
+// isPlural reports whether # is syntax in the text being written
+func (e *exporter) isPlural() bool {
+	return len(e.plural) > 0 && !e.simple
+}
+
+// choice writes a chain of ternaries on the same param as a plural or a
+// select, a ternary on something else ends the chain and is written as its
+// other option
+func (e *exporter) choice(expr *ir.Ternary) error {
+	type option struct {
+		key   string
+		value ir.Expr
+	}
+
+	var subject, kind string
+	options := []option{}
+	var other ir.Expr = expr
+
+	for {
+		ternary, ok := other.(*ir.Ternary)
+		if !ok {
+			break
+		}
+
+		name, k, key, negated, ok := selector(ternary.Cond)
+		if !ok {
+			if len(options) == 0 {
+				return &errs.ExportError{Err: errs.ErrUnrepresentable, Format: "icu", Value: "ternary on " + ir.Describe(ternary.Cond)}
+			}
+			break
+		}
+		if len(options) > 0 && (name != subject || k != kind) {
+			break
+		}
+		subject, kind = name, k
+
+		if negated {
+			options = append(options, option{key, ternary.Else})
+			other = ternary.Then
+			break
+		}
+		options = append(options, option{key, ternary.Then})
+		other = ternary.Else
+	}
+
+	plural := e.plural
+	if kind == "plural" {
+		e.plural = subject
+	}
+	defer func() { e.plural = plural }()
+
+	e.WriteString("{" + subject + ", " + kind + ",")
+	for _, option := range append(options, option{"other", other}) {
+		e.WriteString(" " + option.key + " {")
+		if err := e.export(option.value); err != nil {
+			return err
+		}
+		e.WriteString("}")
+	}
+	e.WriteString("}")
+
+	return nil
+}
+
+// selector returns the param a condition tests and the key of the option it
+// holds for, negated is set if the option is the else branch
+func selector(cond ir.Expr) (subject, kind, key string, negated, ok bool) {
+	switch cond := cond.(type) {
+	case *ir.Ref:
+		if types.Identical(types.Default(cond.Typ), types.Bool) {
+			return cond.Name, "select", "true", false, true
+		}
+	case *ir.Unary:
+		if ref, isRef := cond.X.(*ir.Ref); isRef && cond.Op == ir.Not {
+			return ref.Name, "select", "false", false, true
+		}
+	case *ir.Binary:
+		if cond.Op != ir.Eq && cond.Op != ir.Neq {
+			return
+		}
+
+		ref, isRef := cond.Left.(*ir.Ref)
+		lit, isLit := cond.Right.(*ir.Literal)
+		if !isRef || !isLit {
+			ref, isRef = cond.Right.(*ir.Ref)
+			lit, isLit = cond.Left.(*ir.Literal)
+		}
+		if !isRef || !isLit {
+			return
+		}
+
+		switch value := lit.Value.(type) {
+		case float64:
+			if types.IsNumeric(types.Default(ref.Typ)) {
+				return ref.Name, "plural", "=" + lit.Text(), cond.Op == ir.Neq, true
+			}
+		case string:
+			if keyword(value) {
+				return ref.Name, "select", value, cond.Op == ir.Neq, true
+			}
+		}
+	}
+
+	return
+}
+
+// keyword reports whether s can be the key of a select option
+func keyword(s string) bool {
+	if len(s) == 0 || s == "other" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// placeholder returns the argument of a param, numbers are formatted
+// according to the locale
+func placeholder(name string, typ types.Type) string {
+	typ = types.Default(typ)
+	switch {
+	case typ == nil || types.IsString(typ):
+		return "{" + name + "}"
+	case types.IsInteger(typ) && !types.Identical(typ, types.Rune):
+		return "{" + name + ", number, integer}"
+	case types.IsNumeric(typ) && !types.Identical(typ, types.Rune):
+		return "{" + name + ", number}"
+	default:
+		return "{" + name + "}"
+	}
+}
+
+func unrepresentable(expr ir.Expr) error {
+	return &errs.ExportError{Err: errs.ErrUnrepresentable, Format: "icu", Value: ir.Describe(expr)}
+}
+
+// Escape quotes the characters of s that ICU would read as syntax. An
+// apostrophe is doubled only where it would start a quotation, so most
+// apostrophes are left as they are.
+func Escape(s string) string {
+	return escape(s, false)
+}
+
+// EscapePlural quotes the characters of s that ICU would read as syntax in an
+// option of a plural, where # is the number of the plural.
+func EscapePlural(s string) string {
+	return escape(s, true)
+}
+
+// escape quotes the syntax characters of s, # is one inside the messages of
+// a plural. Adjacent syntax characters share a quotation since two
+// apostrophes inside of one are read as an apostrophe.
+func escape(s string, plural bool) string {
+	b := strings.Builder{}
+	runes := []rune(s)
+	// quoted reports whether the last rune written is inside a quotation or
+	// closed one, an apostrophe right after it would be read as a part of it
+	quoted := false
+	open := false
+
+	for i, r := range runes {
+		if r == '{' || r == '}' || plural && r == '#' {
+			if !open {
+				b.WriteRune('\'')
+				open = true
+			}
+			b.WriteRune(r)
+			quoted = true
+			continue
+		}
+
+		if open {
+			b.WriteRune('\'')
+			open = false
+		}
+
+		if r == '\'' && (quoted || i+1 < len(runes) && strings.ContainsRune("{}#|'", runes[i+1])) {
+			b.WriteString("''")
+		} else {
+			b.WriteRune(r)
+		}
+		quoted = false
+	}
+
+	if open {
+		b.WriteRune('\'')
+	}
+
+	return b.String()
+}
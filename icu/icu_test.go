@@ -0,0 +1,250 @@
+package icu_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/icu"
+	"github.com/CanPacis/lcl/interp"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser/printer"
+	"github.com/CanPacis/lcl/test"
+	"github.com/CanPacis/lcl/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+const source = `declare app (en)
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+section app {
+  title {
+    en "Don't {panic}"
+  }
+
+  greet(name:string) {
+    en ` + "`Hello {name}!`" + `
+  }
+
+  count(n:int size:f64 letter:rune) {
+    en ` + "`{n} files, {size} MB, {letter}`" + `
+  }
+
+  items(n:int) {
+    en ` + "`{n} {plural(n)}`" + `
+  }
+
+  double(n:int) {
+    en ` + "`{n * 2}`" + `
+  }
+
+  files(n:int) {
+    en ` + "`{n} {n == 1 ? \"file\" : n == 0 ? \"files, none\" : \"files\"}`" + `
+  }
+
+  invite(gender:string host:string) {
+    en ` + "`{gender == \"female\" ? host + \" invites you to her party\" : host + \" invites you to the party\"}`" + `
+  }
+
+  online(on:bool n:int) {
+    en ` + "`{!on ? \"Offline\" : n != 1 ? \"Online {#}\" : \"Online\"}`" + `
+  }
+
+  bigger(n:int) {
+    en ` + "`{n > 1 ? \"many\" : \"one\"}`" + `
+  }
+}
+`
+
+type ExportCase struct {
+	Path   string
+	Simple bool
+	Out    string
+	Err    error
+
+	ir *ir.IR
+}
+
+func (c *ExportCase) Inject(out *ir.IR) {
+	c.ir = out
+}
+
+func (c *ExportCase) Run(assert *assert.Assertions) {
+	message, ok := interp.New(c.ir, language.English).Message(c.Path)
+	if !assert.True(ok, c.Path) {
+		return
+	}
+
+	export := icu.Export
+	if c.Simple {
+		export = icu.ExportSimple
+	}
+
+	out, err := export(message.Values[language.English])
+	if c.Err != nil {
+		assert.ErrorIs(err, c.Err)
+		return
+	}
+
+	assert.NoError(err)
+	assert.Equal(c.Out, out)
+}
+
+func TestExport(t *testing.T) {
+	out := test.MustScan(source)
+	ir.Optimize(out)
+
+	tests := []test.Injector[*ir.IR]{
+		&ExportCase{Path: "app.title", Out: "Don't '{'panic'}'"},
+		&ExportCase{Path: "app.greet", Out: "Hello {name}!"},
+		&ExportCase{Path: "app.count", Out: "{n, number, integer} files, {size, number} MB, {letter}"},
+		&ExportCase{Path: "app.items", Err: errs.ErrUnrepresentable},
+		&ExportCase{Path: "app.double", Err: errs.ErrUnrepresentable},
+		&ExportCase{Path: "app.files", Out: "{n, number, integer} {n, plural, =1 {file} =0 {files, none} other {files}}"},
+		&ExportCase{Path: "app.invite", Out: "{gender, select, female {{host} invites you to her party} other {{host} invites you to the party}}"},
+		&ExportCase{Path: "app.online", Out: "{on, select, false {Offline} other {{n, plural, =1 {Online} other {Online '{#}'}}}}"},
+		&ExportCase{Path: "app.bigger", Err: errs.ErrUnrepresentable},
+		&ExportCase{Path: "app.count", Simple: true, Out: "{n} files, {size} MB, {letter}"},
+		&ExportCase{Path: "app.online", Simple: true, Out: "{on, select, false {Offline} other {{n, plural, =1 {Online} other {Online '{'#'}'}}}}"},
+	}
+
+	test.RunWith(t, tests, out)
+}
+
+func TestEscape(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("plain text", icu.Escape("plain text"))
+	assert.Equal("it's", icu.Escape("it's"))
+	assert.Equal("'{'x'}'", icu.Escape("{x}"))
+	assert.Equal("'''{'", icu.Escape("'{"))
+	assert.Equal("'{'''", icu.Escape("{'"))
+	assert.Equal("'''", icu.Escape("''"))
+	assert.Equal("# and |", icu.Escape("# and |"))
+	assert.Equal("''#", icu.Escape("'#"))
+}
+
+type ImportCase struct {
+	In     string
+	Params []ir.Param
+	// Tag is the locale of the pattern, English if it is not set
+	Tag language.Tag
+	Out string
+	Err error
+}
+
+func (c *ImportCase) Run(assert *assert.Assertions) {
+	tag := c.Tag
+	if tag == language.Und {
+		tag = language.English
+	}
+
+	expr, err := icu.Import(c.In, c.Params, tag)
+	if c.Err != nil {
+		assert.ErrorIs(err, c.Err, c.In)
+		return
+	}
+
+	if assert.NoError(err, c.In) {
+		assert.Equal(c.Out, printer.String(expr), c.In)
+	}
+}
+
+func TestImport(t *testing.T) {
+	n := []ir.Param{{Name: "n", Type: types.Int}}
+	invite := []ir.Param{{Name: "gender", Type: types.String}, {Name: "host", Type: types.String}, {Name: "n", Type: types.Int}}
+	online := []ir.Param{{Name: "on", Type: types.Bool}, {Name: "n", Type: types.Int}}
+
+	tests := []test.Runner{
+		&ImportCase{In: "Don't '{'panic'}'", Out: "`Don't {\"{\"}panic}`"},
+		&ImportCase{In: "it''s '{#}' #", Out: "`it's {\"{\"}#} #`"},
+		&ImportCase{In: "{host} has {n, number, integer} guests", Params: invite, Out: "`{host} has {n} guests`"},
+		&ImportCase{
+			In:     "{n, number, integer} {n, plural, =1 {file} =0 {files, none} other {files}}",
+			Params: n,
+			Out:    "`{n} {n == 1 ? \"file\" : n == 0 ? \"files, none\" : \"files\"}`",
+		},
+		&ImportCase{
+			In:     "{n, plural, one {You have # new message} other {You have # new messages}}",
+			Params: n,
+			Out:    "`You have {n}{n == 1 ? \" new message\" : \" new messages\"}`",
+		},
+		&ImportCase{
+			In:     "{gender, select, female {{host} invites you to her party} other {{host} invites you to the party}}",
+			Params: invite,
+			Out:    "`{host}{gender == \"female\" ? \" invites you to her party\" : \" invites you to the party\"}`",
+		},
+		&ImportCase{
+			In:     "{gender, select, female {She and {host}} other {They and {host} or {host}}}",
+			Params: invite,
+			Out:    "`{gender == \"female\" ? \"She and \" : \"They and \" + host + \" or \"}{host}`",
+		},
+		&ImportCase{
+			In:     "{on, select, false {Offline} other {{n, plural, =1 {Online} other {Online '{#}'}}}}",
+			Params: online,
+			Out:    "`{!on ? \"Offline\" : n == 1 ? \"Online\" : \"Online {#}\"}`",
+		},
+		&ImportCase{In: "{n, plural, one {# file} other {# files in {n, number}}}", Params: n, Err: errs.ErrUnrepresentable},
+		&ImportCase{In: "{n, plural, few {a} other {b}}", Params: n, Err: errs.ErrUnrepresentable},
+		&ImportCase{In: "{n, plural, zero {a} other {b}}", Params: n, Err: errs.ErrUnrepresentable},
+		&ImportCase{In: "{n, plural, one {a} other {b}}", Params: n, Tag: language.Russian, Err: errs.ErrUnrepresentable},
+		&ImportCase{In: "{n, plural, one {a} other {b}}", Params: n, Tag: language.French, Err: errs.ErrUnrepresentable},
+		&ImportCase{
+			In:     "{n, plural, zero {a} one {b} two {c} few {d} other {e}}",
+			Params: n,
+			Tag:    language.MustParse("cy"),
+			Out:    "`{n == 0 ? \"a\" : n == 1 ? \"b\" : n == 2 ? \"c\" : n == 3 ? \"d\" : \"e\"}`",
+		},
+		&ImportCase{In: "{n, plural, offset:1 =1 {a} other {b}}", Params: n, Err: errs.ErrUnrepresentable},
+		&ImportCase{In: "{n, number, percent}", Params: n, Err: errs.ErrUnrepresentable},
+		&ImportCase{In: "{n, date, short}", Params: n, Err: errs.ErrUnrepresentable},
+		&ImportCase{In: "{count}", Params: n, Err: errs.ErrUnknownArgument},
+		&ImportCase{In: "{n", Params: n, Err: errs.ErrMalformedPattern},
+		&ImportCase{In: "a } b", Params: n, Err: errs.ErrMalformedPattern},
+		&ImportCase{In: "{n, plural, =1 {a}}", Params: n, Err: errs.ErrMalformedPattern},
+		&ImportCase{In: "{n, plural, =1 {a} other {b}", Params: n, Err: errs.ErrMalformedPattern},
+	}
+
+	test.Run(t, tests)
+
+	_, err := icu.Import("{n, plural, few {a} other {b}}", n, language.English)
+	assert.EqualError(t, err, "import error: plural category few of en in icu cannot be represented in lcl")
+	_, err = icu.Import("{n", n, language.English)
+	assert.EqualError(t, err, "import error: malformed pattern, expected '}' at column 3")
+	_, err = icu.Export(&ir.Binary{Op: ir.Mul, Left: &ir.Ref{Name: "n", Typ: types.Int}, Right: &ir.Ref{Name: "n", Typ: types.Int}, Typ: types.Int})
+	assert.EqualError(t, err, "export error: arithmetic * cannot be represented in icu")
+}
+
+func TestRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	out := test.MustScan(source)
+	ir.Optimize(out)
+	in := interp.New(out, language.English)
+
+	for _, path := range []string{"app.title", "app.greet", "app.count", "app.files", "app.online"} {
+		message, _ := in.Message(path)
+		pattern, err := icu.Export(message.Values[language.English])
+		if !assert.NoError(err) {
+			continue
+		}
+
+		expr, err := icu.Import(pattern, message.Params, language.English)
+		if !assert.NoError(err, pattern) {
+			continue
+		}
+		params := []string{}
+		for _, param := range message.Params {
+			params = append(params, param.Name+":"+param.Type.String())
+		}
+
+		imported := test.MustScan(fmt.Sprintf("declare app (en)\n\nsection app {\n  m(%s) {\n    en %s\n  }\n}\n", strings.Join(params, " "), printer.String(expr)))
+		ir.Optimize(imported)
+		again, err := icu.Export(imported.Sections[0].Messages[0].Values[language.English])
+		assert.NoError(err)
+		assert.Equal(pattern, again)
+	}
+}
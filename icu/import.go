@@ -0,0 +1,388 @@
+package icu
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/parser/token"
+	"github.com/CanPacis/lcl/types"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// categories names the plural forms of CLDR as ICU does
+var categories = map[plural.Form]string{
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+	plural.Other: "other",
+}
+
+// limit bounds the counts plural rules are compared for, the rules of CLDR
+// repeat themselves within it
+const limit = 200
+
+// Import returns the template literal of an ICU MessageFormat pattern with
+// the given params in the given locale. Arguments become references to params
+// and plurals and selects become ternaries, the parts all options share are
+// moved out of the ternary since lcl can only join strings. Print the result
+// with the printer package to get its source.
+func Import(pattern string, params []ir.Param, tag language.Tag) (*ast.TemplateLitExpr, error) {
+	msg, err := parse(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &importer{params: map[string]types.Type{}, tag: tag}
+	for _, param := range params {
+		i.params[param.Name] = param.Type
+	}
+
+	parts, err := i.template(msg, "")
+	if err != nil {
+		return nil, err
+	}
+	return &ast.TemplateLitExpr{
+		Node:  ast.NewNode(ast.TemplateLitExprNode, token.Position{}, token.Position{}),
+		Value: parts,
+	}, nil
+}
+
+type importer struct {
+	params map[string]types.Type
+	tag    language.Tag
+}
+
+// template returns the parts of a template literal, subject is the argument
+// of the plural the message is an option of
+func (i *importer) template(msg message, subject string) ([]ast.Expr, error) {
+	parts := []ast.Expr{}
+
+	for _, part := range msg {
+		switch part := part.(type) {
+		case text:
+			if n := len(parts); n > 0 {
+				if prev, ok := parts[n-1].(*ast.StringLitExpr); ok {
+					prev.Value += string(part)
+					continue
+				}
+			}
+			parts = append(parts, str(string(part)))
+		case pound:
+			parts = append(parts, ident(subject))
+		case *argument:
+			if part.Options != nil {
+				choice, err := i.choice(part, subject)
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, choice...)
+				continue
+			}
+
+			ref, err := i.argument(part)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, ref)
+		}
+	}
+
+	return parts, nil
+}
+
+// argument returns the reference of a simple argument, numbers are formatted
+// by lcl so only their default styles are accepted
+func (i *importer) argument(arg *argument) (ast.Expr, error) {
+	if _, ok := i.params[arg.Name]; !ok {
+		return nil, &errs.ImportError{Err: errs.ErrUnknownArgument, Format: "icu", Value: arg.Name}
+	}
+
+	switch {
+	case arg.Kind == "":
+	case arg.Kind == "number" && (arg.Style == "" || arg.Style == "integer"):
+	case arg.Style == "":
+		return nil, untranslatable(arg.Kind + " argument")
+	default:
+		return nil, untranslatable(arg.Kind + " argument with style " + arg.Style)
+	}
+
+	return ident(arg.Name), nil
+}
+
+// choice returns the parts of a plural or a select. The atoms every option
+// starts or ends with are moved out up to the last argument among them, the
+// rest of the options becomes a ternary.
+func (i *importer) choice(arg *argument, subject string) ([]ast.Expr, error) {
+	if _, ok := i.params[arg.Name]; !ok {
+		return nil, &errs.ImportError{Err: errs.ErrUnknownArgument, Format: "icu", Value: arg.Name}
+	}
+	switch {
+	case arg.Kind == "selectordinal":
+		return nil, untranslatable("selectordinal argument")
+	case arg.Offset != 0:
+		return nil, untranslatable("plural offset")
+	}
+	if arg.Kind == "plural" {
+		subject = arg.Name
+	}
+
+	options := [][]part{}
+	for _, option := range arg.Options {
+		options = append(options, atoms(option.Message))
+	}
+
+	shortest := len(options[0])
+	for _, option := range options {
+		shortest = min(shortest, len(option))
+	}
+
+	prefix := 0
+	for prefix < shortest && shared(options, func(atoms []part) part { return atoms[prefix] }) {
+		prefix++
+	}
+	for prefix > 0 && isText(options[0][prefix-1]) {
+		prefix--
+	}
+
+	suffix := 0
+	for suffix < shortest-prefix && shared(options, func(atoms []part) part { return atoms[len(atoms)-1-suffix] }) {
+		suffix++
+	}
+	for suffix > 0 && isText(options[0][len(options[0])-suffix]) {
+		suffix--
+	}
+
+	head, err := i.template(message(options[0][:prefix]), subject)
+	if err != nil {
+		return nil, err
+	}
+	tail, err := i.template(message(options[0][len(options[0])-suffix:]), subject)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []ast.Expr{}
+	for _, option := range options {
+		branch, err := i.concat(option[prefix:len(option)-suffix], subject)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, branch)
+	}
+
+	var expr ast.Expr
+	for j, option := range arg.Options {
+		if option.Key == "other" {
+			expr = branches[j]
+		}
+	}
+
+	for j := len(arg.Options) - 1; j >= 0; j-- {
+		option := arg.Options[j]
+		if option.Key == "other" {
+			continue
+		}
+
+		cond, err := i.condition(arg, option.Key)
+		if err != nil {
+			return nil, err
+		}
+		expr = &ast.TernaryExpr{
+			Node:      ast.NewNode(ast.TernaryExprNode, token.Position{}, token.Position{}),
+			Predicate: cond,
+			Left:      branches[j],
+			Right:     expr,
+		}
+	}
+
+	return append(append(head, expr), tail...), nil
+}
+
+// concat returns the parts of an option joined as strings
+func (i *importer) concat(atoms []part, subject string) (ast.Expr, error) {
+	parts := []ast.Expr{}
+
+	for _, part := range message(atoms).merge() {
+		switch part := part.(type) {
+		case text:
+			parts = append(parts, str(string(part)))
+		case pound:
+			return nil, untranslatable("# inside the text of an option")
+		case *argument:
+			if part.Options != nil {
+				choice, err := i.choice(part, subject)
+				if err != nil {
+					return nil, err
+				}
+				if len(choice) != 1 {
+					return nil, untranslatable("argument inside the text of an option")
+				}
+				parts = append(parts, choice[0])
+				continue
+			}
+
+			ref, err := i.argument(part)
+			if err != nil {
+				return nil, err
+			}
+			if !types.IsString(i.params[part.Name]) {
+				return nil, untranslatable(i.params[part.Name].String() + " argument inside the text of an option")
+			}
+			parts = append(parts, ref)
+		}
+	}
+
+	if len(parts) == 0 {
+		return str(""), nil
+	}
+
+	expr := parts[0]
+	for _, part := range parts[1:] {
+		expr = &ast.ArithmeticExpr{
+			Node:     ast.NewNode(ast.ArithmeticExprNode, token.Position{}, token.Position{}),
+			Operator: token.Token{Kind: token.PLUS, Literal: "+"},
+			Left:     expr,
+			Right:    part,
+		}
+	}
+	return expr, nil
+}
+
+// condition returns the predicate an option of a plural or a select is
+// chosen by
+func (i *importer) condition(arg *argument, key string) (ast.Expr, error) {
+	subject := ident(arg.Name)
+
+	if arg.Kind == "plural" {
+		var n float64
+		if strings.HasPrefix(key, "=") {
+			value, err := strconv.ParseFloat(key[1:], 64)
+			if err != nil {
+				return nil, untranslatable("plural selector " + key)
+			}
+			n = value
+		} else if value, ok := count(i.tag, key); ok {
+			n = value
+		} else {
+			return nil, untranslatable("plural category " + key + " of " + i.tag.String())
+		}
+
+		return compare(subject, &ast.NumberLitExpr{
+			Node:  ast.NewNode(ast.NumberLitExprNode, token.Position{}, token.Position{}),
+			Value: n,
+		}), nil
+	}
+
+	if types.Identical(types.Default(i.params[arg.Name]), types.Bool) {
+		switch key {
+		case "true":
+			return subject, nil
+		case "false":
+			return &ast.UnaryExpr{
+				Node:     ast.NewNode(ast.UnaryExprNode, token.Position{}, token.Position{}),
+				Operator: token.Token{Kind: token.EXCLAMATION_MARK, Literal: "!"},
+				Expr:     subject,
+			}, nil
+		default:
+			return nil, untranslatable("bool selector " + key)
+		}
+	}
+
+	return compare(subject, str(key)), nil
+}
+
+// count returns the only count a plural category holds in a locale, lcl has
+// no plural rules so a category that holds more counts, like one in Russian
+// that holds 1, 21, 31 and so on, cannot be compared against
+func count(tag language.Tag, category string) (float64, bool) {
+	n := -1
+	for i := 0; i < limit; i++ {
+		if categories[plural.Cardinal.MatchPlural(tag, i, 0, 0, 0, 0)] != category {
+			continue
+		}
+		if n >= 0 {
+			return 0, false
+		}
+		n = i
+	}
+	return float64(n), n >= 0
+}
+
+// atoms splits the text of a message into runes so that messages can be
+// compared part by part
+func atoms(msg message) []part {
+	list := []part{}
+	for _, p := range msg {
+		if t, ok := p.(text); ok {
+			for _, r := range t {
+				list = append(list, text(r))
+			}
+			continue
+		}
+		list = append(list, p)
+	}
+	return list
+}
+
+// merge joins adjacent text
+func (m message) merge() message {
+	list := message{}
+	for _, p := range m {
+		if t, ok := p.(text); ok && len(list) > 0 {
+			if prev, ok := list[len(list)-1].(text); ok {
+				list[len(list)-1] = prev + t
+				continue
+			}
+		}
+		list = append(list, p)
+	}
+	return list
+}
+
+func shared(options [][]part, at func([]part) part) bool {
+	first := at(options[0])
+	for _, option := range options[1:] {
+		if !equal(first, at(option)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isText(p part) bool {
+	_, ok := p.(text)
+	return ok
+}
+
+func untranslatable(value string) error {
+	return &errs.ImportError{Err: errs.ErrUnrepresentable, Format: "icu", Value: value}
+}
+
+func ident(name string) *ast.IdentExpr {
+	return &ast.IdentExpr{
+		Node:  ast.NewNode(ast.IdentExprNode, token.Position{}, token.Position{}),
+		Value: name,
+	}
+}
+
+func str(value string) *ast.StringLitExpr {
+	return &ast.StringLitExpr{
+		Node:  ast.NewNode(ast.StringLitExprNode, token.Position{}, token.Position{}),
+		Value: value,
+	}
+}
+
+func compare(left, right ast.Expr) ast.Expr {
+	return &ast.BinaryExpr{
+		Node:     ast.NewNode(ast.BinaryExprNode, token.Position{}, token.Position{}),
+		Operator: token.Token{Kind: token.EQUALS, Literal: "=="},
+		Left:     left,
+		Right:    right,
+	}
+}
@@ -0,0 +1,314 @@
+package icu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/CanPacis/lcl/errs"
+)
+
+// message is a parsed pattern, its parts are text, arguments and the # of
+// plurals
+type message []part
+
+type part interface {
+	part()
+}
+
+type text string
+
+type pound struct{}
+
+// argument is a placeholder of a pattern. Kind is empty for a simple argument
+// and options are only set for plural, selectordinal and select.
+type argument struct {
+	Name    string
+	Kind    string
+	Style   string
+	Offset  int
+	Options []option
+}
+
+type option struct {
+	Key     string
+	Message message
+}
+
+func (text) part()      {}
+func (pound) part()     {}
+func (*argument) part() {}
+
+// equal reports whether two parts are the same, arguments with options are
+// never equal to anything
+func equal(a, b part) bool {
+	switch a := a.(type) {
+	case text:
+		b, ok := b.(text)
+		return ok && a == b
+	case pound:
+		_, ok := b.(pound)
+		return ok
+	case *argument:
+		b, ok := b.(*argument)
+		return ok && a.Options == nil && b.Options == nil && a.Name == b.Name && a.Kind == b.Kind && a.Style == b.Style
+	default:
+		return false
+	}
+}
+
+type parser struct {
+	src []rune
+	pos int
+}
+
+// parse reads a pattern the way ICU does with its default apostrophe mode,
+// an apostrophe only quotes when it is followed by a syntax character.
+func parse(pattern string) (message, error) {
+	p := &parser{src: []rune(pattern)}
+
+	msg, err := p.message(false, false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.error("unexpected '}'")
+	}
+	return msg, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek(offset int) rune {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *parser) error(format string, args ...any) error {
+	return &errs.ImportError{
+		Err:    errs.ErrMalformedPattern,
+		Format: "icu",
+		Value:  fmt.Sprintf(format, args...) + fmt.Sprintf(" at column %d", p.pos+1),
+	}
+}
+
+func (p *parser) space() {
+	for !p.done() && unicode.IsSpace(p.peek(0)) {
+		p.pos++
+	}
+}
+
+func (p *parser) expect(r rune) error {
+	if p.peek(0) != r {
+		return p.error("expected '%c'", r)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) word() string {
+	start := p.pos
+	for !p.done() {
+		r := p.peek(0)
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+// message reads until the end of the pattern or the brace that closes a
+// nested message, the brace is left to the caller
+func (p *parser) message(plural, nested bool) (message, error) {
+	msg := message{}
+	b := strings.Builder{}
+
+	flush := func() {
+		if b.Len() > 0 {
+			msg = append(msg, text(b.String()))
+			b.Reset()
+		}
+	}
+
+	for !p.done() {
+		r := p.peek(0)
+
+		switch {
+		case r == '\'':
+			next := p.peek(1)
+			switch {
+			case next == '\'':
+				b.WriteRune('\'')
+				p.pos += 2
+			case next == '{' || next == '}' || next == '|' || plural && next == '#':
+				p.pos++
+				for !p.done() {
+					if p.peek(0) == '\'' {
+						if p.peek(1) == '\'' {
+							b.WriteRune('\'')
+							p.pos += 2
+							continue
+						}
+						p.pos++
+						break
+					}
+					b.WriteRune(p.peek(0))
+					p.pos++
+				}
+			default:
+				b.WriteRune(r)
+				p.pos++
+			}
+		case r == '{':
+			flush()
+			arg, err := p.argument(plural)
+			if err != nil {
+				return nil, err
+			}
+			msg = append(msg, arg)
+		case r == '}':
+			if !nested {
+				return nil, p.error("unexpected '}'")
+			}
+			flush()
+			return msg, nil
+		case r == '#' && plural:
+			flush()
+			msg = append(msg, pound{})
+			p.pos++
+		default:
+			b.WriteRune(r)
+			p.pos++
+		}
+	}
+
+	if nested {
+		return nil, p.error("expected '}'")
+	}
+	flush()
+	return msg, nil
+}
+
+func (p *parser) argument(plural bool) (*argument, error) {
+	p.pos++
+	p.space()
+
+	arg := &argument{Name: p.word()}
+	if len(arg.Name) == 0 {
+		return nil, p.error("expected an argument name")
+	}
+	p.space()
+
+	if p.done() {
+		return nil, p.error("expected '}'")
+	}
+	if p.peek(0) == '}' {
+		p.pos++
+		return arg, nil
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	p.space()
+
+	arg.Kind = p.word()
+	if len(arg.Kind) == 0 {
+		return nil, p.error("expected an argument type")
+	}
+	p.space()
+
+	if p.peek(0) == '}' {
+		p.pos++
+		return arg, nil
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+	p.space()
+
+	switch arg.Kind {
+	case "plural", "selectordinal":
+		if err := p.options(arg, true); err != nil {
+			return nil, err
+		}
+	case "select":
+		if err := p.options(arg, plural); err != nil {
+			return nil, err
+		}
+	default:
+		start := p.pos
+		depth := 0
+		for !p.done() && (p.peek(0) != '}' || depth > 0) {
+			switch p.peek(0) {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			p.pos++
+		}
+		arg.Style = strings.TrimSpace(string(p.src[start:p.pos]))
+	}
+
+	if err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	return arg, nil
+}
+
+func (p *parser) options(arg *argument, plural bool) error {
+	if arg.Kind != "select" && strings.HasPrefix(string(p.src[p.pos:]), "offset:") {
+		p.pos += len("offset:")
+		p.space()
+		offset, err := strconv.Atoi(p.word())
+		if err != nil {
+			return p.error("expected an offset")
+		}
+		arg.Offset = offset
+	}
+
+	for {
+		p.space()
+		if p.done() || p.peek(0) == '}' {
+			break
+		}
+
+		key := ""
+		if p.peek(0) == '=' {
+			p.pos++
+			key = "=" + p.word()
+		} else {
+			key = p.word()
+		}
+		if len(key) == 0 || key == "=" {
+			return p.error("expected a selector")
+		}
+		p.space()
+
+		if err := p.expect('{'); err != nil {
+			return err
+		}
+		msg, err := p.message(plural, true)
+		if err != nil {
+			return err
+		}
+		if err := p.expect('}'); err != nil {
+			return err
+		}
+
+		arg.Options = append(arg.Options, option{Key: key, Message: msg})
+	}
+
+	for _, option := range arg.Options {
+		if option.Key == "other" {
+			return nil
+		}
+	}
+	return p.error("expected an other selector")
+}
@@ -0,0 +1,424 @@
+// Package interp evaluates the messages of a package without generating code.
+package interp
+
+import (
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+	"golang.org/x/text/language"
+)
+
+// Interpreter renders the messages of a package for one of its locales.
+//
+// Values are represented by go values: numbers are float64 regardless of
+// their type, lists are []any, maps are map[any]any, structs are
+// map[string]any keyed by their lcl field names and absent optionals are nil.
+// Sections can be referred to by name in the expressions given to Eval, their
+// keys evaluate to strings and their templates to values that can be called.
+type Interpreter struct {
+	locale   ir.Locale
+	fns      map[string]*ir.FnDef
+	sections map[string]*ir.Section
+	messages map[string]*ir.Message
+	imports  map[string]*Interpreter
+}
+
+// closure is the value of a fn, it is evaluated by the interpreter of the
+// package it is defined in
+type closure struct {
+	i  *Interpreter
+	fn *ir.FnDef
+}
+
+// Locale returns the locale the interpreter renders messages for, it is the
+// best match of the requested tag among the locales of the package.
+func (i *Interpreter) Locale() ir.Locale {
+	return i.locale
+}
+
+// Import makes the fns of another package available under name for the
+// import expressions of this one.
+func (i *Interpreter) Import(name string, out *ir.IR) {
+	i.imports[name] = New(out, i.locale.Tag)
+}
+
+// Message returns the key or template at path, like "auth.greet".
+func (i *Interpreter) Message(path string) (*ir.Message, bool) {
+	message, ok := i.messages[path]
+	return message, ok
+}
+
+// Render renders the key or template at path. The arguments of a template are
+// given as a map[string]any or a struct whose fields match the params by
+// name, they are checked against the param types before evaluation.
+func (i *Interpreter) Render(path string, args any) (string, error) {
+	message, ok := i.messages[path]
+	if !ok {
+		return "", &errs.RuntimeError{Err: errs.ErrUnknownMessage, Value: path}
+	}
+
+	env, err := bind(message.Params, args)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := i.eval(message.Values[i.locale.Tag], env)
+	if err != nil {
+		return "", err
+	}
+
+	s, _ := v.(string)
+	return s, nil
+}
+
+// Eval evaluates an expression in the scope of the package, it may refer to
+// fns but not to params.
+func (i *Interpreter) Eval(expr ir.Expr) (any, error) {
+	return i.eval(expr, map[string]any{})
+}
+
+func (i *Interpreter) eval(expr ir.Expr, env map[string]any) (any, error) {
+	switch expr := expr.(type) {
+	case *ir.Literal:
+		return expr.Value, nil
+	case *ir.Ref:
+		if v, ok := env[expr.Name]; ok {
+			return v, nil
+		}
+		if fn, ok := i.fns[expr.Name]; ok {
+			return &closure{i: i, fn: fn}, nil
+		}
+		if section, ok := i.sections[expr.Name]; ok {
+			return section, nil
+		}
+		return nil, &errs.RuntimeError{Err: errs.ErrUnresolvedConstReference, Value: expr.Name}
+	case *ir.Import:
+		pkg, ok := i.imports[expr.Package]
+		if !ok {
+			return nil, &errs.RuntimeError{Err: errs.ErrUnresolvedImport, Value: expr.Package}
+		}
+		fn, ok := pkg.fns[expr.Name]
+		if !ok {
+			return nil, &errs.RuntimeError{Err: errs.ErrUnresolvedConstReference, Value: expr.Package + "::" + expr.Name}
+		}
+		return &closure{i: pkg, fn: fn}, nil
+	case *ir.Binary:
+		return i.binary(expr, env)
+	case *ir.Unary:
+		x, err := i.eval(expr.X, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if expr.Op == ir.Not {
+			return !x.(bool), nil
+		}
+		return cast(-x.(float64), expr.Typ), nil
+	case *ir.Ternary:
+		cond, err := i.eval(expr.Cond, env)
+		if err != nil {
+			return nil, err
+		}
+
+		if cond.(bool) {
+			return i.eval(expr.Then, env)
+		}
+		return i.eval(expr.Else, env)
+	case *ir.Coalesce:
+		left, err := i.eval(expr.Left, env)
+		if err != nil || left != nil {
+			return left, err
+		}
+		return i.eval(expr.Right, env)
+	case *ir.Call:
+		return i.call(expr, env)
+	case *ir.Convert:
+		x, err := i.eval(expr.X, env)
+		if err != nil {
+			return nil, err
+		}
+		return cast(x.(float64), expr.Typ), nil
+	case *ir.Member:
+		x, err := i.eval(expr.X, env)
+		if err != nil || x == nil {
+			return nil, err
+		}
+
+		if section, ok := x.(*ir.Section); ok {
+			return i.member(section, expr.Name)
+		}
+		return x.(map[string]any)[expr.Name], nil
+	case *ir.Index:
+		return i.index(expr, env)
+	case *ir.Template:
+		return i.template(expr, env)
+	default:
+		return nil, nil
+	}
+}
+
+func (i *Interpreter) binary(expr *ir.Binary, env map[string]any) (any, error) {
+	left, err := i.eval(expr.Left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators do not evaluate the right side if the left one
+	// determines the result
+	switch expr.Op {
+	case ir.And:
+		if !left.(bool) {
+			return false, nil
+		}
+		return i.eval(expr.Right, env)
+	case ir.Or:
+		if left.(bool) {
+			return true, nil
+		}
+		return i.eval(expr.Right, env)
+	}
+
+	right, err := i.eval(expr.Right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.Op {
+	case ir.Eq:
+		return reflect.DeepEqual(left, right), nil
+	case ir.Neq:
+		return !reflect.DeepEqual(left, right), nil
+	case ir.Lt, ir.Lte, ir.Gt, ir.Gte:
+		return compare(expr.Op, left, right), nil
+	}
+
+	if l, ok := left.(string); ok {
+		return l + right.(string), nil
+	}
+
+	l, r := left.(float64), right.(float64)
+	typ := types.Default(expr.Typ)
+	integer := types.IsInteger(typ)
+
+	var v float64
+	switch expr.Op {
+	case ir.Add:
+		v = l + r
+	case ir.Sub:
+		v = l - r
+	case ir.Mul:
+		v = l * r
+	case ir.Div:
+		if integer && r == 0 {
+			return nil, &errs.RuntimeError{Err: errs.ErrDivisionByZero}
+		}
+
+		v = l / r
+		if integer {
+			v = math.Trunc(v)
+		}
+	case ir.Mod:
+		if r == 0 {
+			return nil, &errs.RuntimeError{Err: errs.ErrDivisionByZero}
+		}
+		v = math.Mod(l, r)
+	case ir.Pow:
+		v = math.Pow(l, r)
+	}
+
+	return cast(v, typ), nil
+}
+
+// compare orders two numbers or strings, an absent optional is not ordered
+func compare(op ir.Op, left, right any) bool {
+	var c int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false
+		}
+
+		switch {
+		case l < r:
+			c = -1
+		case l > r:
+			c = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		c = strings.Compare(l, r)
+	default:
+		return false
+	}
+
+	switch op {
+	case ir.Lt:
+		return c < 0
+	case ir.Lte:
+		return c <= 0
+	case ir.Gt:
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func (i *Interpreter) call(expr *ir.Call, env map[string]any) (any, error) {
+	fn, err := i.eval(expr.Fn, env)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []any{}
+	for _, arg := range expr.Args {
+		v, err := i.eval(arg, env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+
+	switch fn := fn.(type) {
+	case *closure:
+		return fn.i.eval(fn.fn.Body, scope(fn.fn.Params, args))
+	case *ir.Message:
+		return i.eval(fn.Values[i.locale.Tag], scope(fn.Params, args))
+	default:
+		return nil, nil
+	}
+}
+
+// member returns a member of a section, keys are rendered right away while
+// templates are returned to be called
+func (i *Interpreter) member(section *ir.Section, name string) (any, error) {
+	for _, message := range section.Messages {
+		if message.Name != name {
+			continue
+		}
+
+		if message.IsTemplate {
+			return message, nil
+		}
+		return i.eval(message.Values[i.locale.Tag], map[string]any{})
+	}
+
+	for _, sub := range section.Sections {
+		if sub.Name == name {
+			return sub, nil
+		}
+	}
+
+	return nil, &errs.RuntimeError{Err: errs.ErrUnknownMessage, Value: section.Name + "." + name}
+}
+
+func scope(params []ir.Param, args []any) map[string]any {
+	env := map[string]any{}
+	for n, arg := range args {
+		env[params[n].Name] = arg
+	}
+	return env
+}
+
+func (i *Interpreter) index(expr *ir.Index, env map[string]any) (any, error) {
+	x, err := i.eval(expr.X, env)
+	if err != nil || x == nil {
+		return nil, err
+	}
+
+	index, err := i.eval(expr.Index, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch x := x.(type) {
+	case []any:
+		n := int(index.(float64))
+		if n < 0 || n >= len(x) {
+			return nil, &errs.RuntimeError{Err: errs.ErrIndexOutOfRange, N: n, M: len(x)}
+		}
+		return x[n], nil
+	case map[any]any:
+		if v, ok := x[index]; ok {
+			return v, nil
+		}
+		return zero(expr.Typ), nil
+	case map[string]any:
+		return x[index.(string)], nil
+	default:
+		return nil, nil
+	}
+}
+
+func (i *Interpreter) template(expr *ir.Template, env map[string]any) (any, error) {
+	b := strings.Builder{}
+
+	for _, segment := range expr.Segments {
+		if segment.Value == nil {
+			b.WriteString(segment.Text)
+			continue
+		}
+
+		v, err := i.eval(segment.Value, env)
+		if err != nil {
+			return nil, err
+		}
+
+		var t types.Type
+		if typ := segment.Value.Type(); typ != nil {
+			t = types.Default(typ)
+		}
+		b.WriteString(Format(v, t))
+	}
+
+	return b.String(), nil
+}
+
+// New returns an interpreter for the locale of out that best matches tag.
+func New(out *ir.IR, tag language.Tag) *Interpreter {
+	i := &Interpreter{
+		fns:      map[string]*ir.FnDef{},
+		sections: map[string]*ir.Section{},
+		messages: map[string]*ir.Message{},
+		imports:  map[string]*Interpreter{},
+	}
+
+	if len(out.Locales) > 0 {
+		tags := []language.Tag{}
+		for _, locale := range out.Locales {
+			tags = append(tags, locale.Tag)
+		}
+
+		_, n, _ := language.NewMatcher(tags).Match(tag)
+		i.locale = out.Locales[n]
+	}
+
+	for n := range out.FnDefs {
+		i.fns[out.FnDefs[n].Name] = &out.FnDefs[n]
+	}
+
+	var walk func(sections []*ir.Section, prefix string)
+	walk = func(sections []*ir.Section, prefix string) {
+		for _, section := range sections {
+			path := prefix + section.Name
+			for _, message := range section.Messages {
+				i.messages[path+"."+message.Name] = message
+			}
+			walk(section.Sections, path+".")
+		}
+	}
+	walk(out.Sections, "")
+
+	for _, section := range out.Sections {
+		i.sections[section.Name] = section
+	}
+
+	return i
+}
@@ -0,0 +1,170 @@
+package interp_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CanPacis/lcl/analyzer"
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/interp"
+	"github.com/CanPacis/lcl/ir"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+const source = `declare shop (en tr)
+
+type Money f64
+type Item {name:string price:Money}
+
+fn(n:int) plural n == 1 ? "item" : "items"
+fn(item:Item) label item.name
+fn(items:Item[]) first label(items[0])
+fn(n:u8) wrap n + 250
+
+section cart {
+  title {
+    en "Cart"
+    tr "Sepet"
+  }
+
+  summary(n:int total:Money) {
+    en ` + "`{n} {plural(n)}, {total / 2} each`" + `
+    tr ` + "`{n} ürün, tanesi {total / 2}`" + `
+  }
+
+  top(items:Item[]) {
+    en ` + "`First is {first(items)}`" + `
+    tr ` + "`İlk ürün {first(items)}`" + `
+  }
+
+  section stock {
+    left(counts:map[string]int name:string nick:string? n:int) {
+      en ` + "`{nick ?? name}: {counts[name]} left, {wrap(10)} {7 / 2} {i8(n) * 2}`" + `
+      tr ` + "`{nick ?? name}: {counts[name]} kaldı`" + `
+    }
+  }
+}
+`
+
+type RenderCase struct {
+	Tag  language.Tag
+	Path string
+	Args any
+	Out  string
+	Err  error
+
+	ir *ir.IR
+}
+
+func (c *RenderCase) Inject(out *ir.IR) {
+	c.ir = out
+}
+
+func (c *RenderCase) Run(assert *assert.Assertions) {
+	out, err := interp.New(c.ir, c.Tag).Render(c.Path, c.Args)
+	if c.Err != nil {
+		assert.ErrorIs(err, c.Err)
+		return
+	}
+
+	assert.NoError(err)
+	assert.Equal(c.Out, out)
+}
+
+type item struct {
+	Name  string
+	Price float64
+}
+
+func TestRender(t *testing.T) {
+	file := parser.NewFile("mock.lcl", bytes.NewBufferString(source))
+	tree := test.MustParse(test.WithFile(file))
+	out, err := analyzer.New(file, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	nick := "Bookworm"
+	tests := []test.Injector[*ir.IR]{
+		&RenderCase{Tag: language.English, Path: "cart.title", Out: "Cart"},
+		&RenderCase{Tag: language.Turkish, Path: "cart.title", Out: "Sepet"},
+		&RenderCase{Tag: language.MustParse("tr-TR"), Path: "cart.title", Out: "Sepet"},
+		&RenderCase{Tag: language.Japanese, Path: "cart.title", Out: "Cart"},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.summary",
+			Args: map[string]any{"n": 1, "total": 5},
+			Out:  "1 item, 2.5 each",
+		},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.summary",
+			Args: struct {
+				N     int
+				Total float32
+			}{N: 3, Total: 7},
+			Out: "3 items, 3.5 each",
+		},
+		&RenderCase{
+			Tag:  language.Turkish,
+			Path: "cart.top",
+			Args: map[string]any{"items": []item{{Name: "Kitap", Price: 10}}},
+			Out:  "İlk ürün Kitap",
+		},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.top",
+			Args: map[string]any{"items": []map[string]any{{"name": "Book", "price": 10}}},
+			Out:  "First is Book",
+		},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.stock.left",
+			Args: map[string]any{"counts": map[string]int{"Book": 2}, "name": "Book", "n": 100},
+			Out:  "Book: 2 left, 4 3 -56",
+		},
+		&RenderCase{
+			Tag:  language.Turkish,
+			Path: "cart.stock.left",
+			Args: map[string]any{"counts": map[string]int{}, "name": "Book", "nick": &nick, "n": 1},
+			Out:  "Bookworm: 0 kaldı",
+		},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.top",
+			Args: map[string]any{"items": []item{}},
+			Err:  errs.ErrIndexOutOfRange,
+		},
+		&RenderCase{Tag: language.English, Path: "cart.missing", Err: errs.ErrUnknownMessage},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.summary",
+			Args: map[string]any{"n": 1},
+			Err:  errs.ErrMissingArgument,
+		},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.summary",
+			Args: map[string]any{"n": 1.5, "total": 5},
+			Err:  errs.ErrInvalidArgument,
+		},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.summary",
+			Args: map[string]any{"n": "1", "total": 5},
+			Err:  errs.ErrInvalidArgument,
+		},
+		&RenderCase{
+			Tag:  language.English,
+			Path: "cart.top",
+			Args: map[string]any{"items": []map[string]any{{"name": "Book"}}},
+			Err:  errs.ErrInvalidArgument,
+		},
+	}
+
+	test.RunWith(t, tests, out)
+}
@@ -0,0 +1,289 @@
+package interp
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/types"
+)
+
+// bind converts the arguments of a message into values of its params
+func bind(params []ir.Param, args any) (map[string]any, error) {
+	env := map[string]any{}
+	rv := deref(reflect.ValueOf(args))
+
+	for _, param := range params {
+		arg := field(rv, param.Name)
+		if !arg.IsValid() || isNil(arg) {
+			if types.IsOptional(param.Type) {
+				env[param.Name] = nil
+				continue
+			}
+			return nil, &errs.RuntimeError{Err: errs.ErrMissingArgument, Value: param.Name, Type: param.Type}
+		}
+
+		v, ok := value(param.Type, arg)
+		if !ok {
+			return nil, &errs.RuntimeError{Err: errs.ErrInvalidArgument, Value: param.Name, Type: param.Type}
+		}
+		env[param.Name] = v
+	}
+
+	return env, nil
+}
+
+// field returns the value of name in a map with string keys or a struct, the
+// fields of a struct are matched ignoring case since lcl names are usually
+// not exported
+func field(rv reflect.Value, name string) reflect.Value {
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}
+		}
+		return deref(rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key())))
+	case reflect.Struct:
+		return deref(rv.FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(n, name)
+		}))
+	default:
+		return reflect.Value{}
+	}
+}
+
+// deref follows the interfaces a value is stored in, nil pointers are kept
+// so that they can be told apart from missing values
+func deref(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Interface && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func isNil(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// value converts a go value to a value of the type t. Numbers are checked
+// like untyped constants so that an argument is accepted only if a literal of
+// the same value would be.
+func value(t types.Type, rv reflect.Value) (any, bool) {
+	rv = deref(rv)
+
+	if types.IsOptional(t) {
+		if !rv.IsValid() || isNil(rv) {
+			return nil, true
+		}
+		t = types.Unwrap(t)
+	}
+
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = deref(rv.Elem())
+	}
+
+	switch {
+	case types.IsString(t):
+		if rv.Kind() != reflect.String {
+			return nil, false
+		}
+		return rv.String(), true
+	case types.RootOf(t) == types.Bool:
+		if rv.Kind() != reflect.Bool {
+			return nil, false
+		}
+		return rv.Bool(), true
+	case types.IsNumeric(t):
+		var v float64
+		float := false
+
+		switch {
+		case rv.CanInt():
+			v = float64(rv.Int())
+		case rv.CanUint():
+			v = float64(rv.Uint())
+		case rv.CanFloat():
+			v, float = rv.Float(), true
+		default:
+			return nil, false
+		}
+
+		if !t.Assignable(types.NewUntyped(v, float)) {
+			return nil, false
+		}
+		return v, true
+	}
+
+	switch root := types.RootOf(t).(type) {
+	case *types.List:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, false
+		}
+
+		list := []any{}
+		for n := range rv.Len() {
+			v, ok := value(root.Type, rv.Index(n))
+			if !ok {
+				return nil, false
+			}
+			list = append(list, v)
+		}
+		return list, true
+	case *types.Map:
+		if rv.Kind() != reflect.Map {
+			return nil, false
+		}
+
+		m := map[any]any{}
+		iter := rv.MapRange()
+		for iter.Next() {
+			k, ok := value(root.Key, iter.Key())
+			if !ok {
+				return nil, false
+			}
+			v, ok := value(root.Value, iter.Value())
+			if !ok {
+				return nil, false
+			}
+			m[k] = v
+		}
+		return m, true
+	case *types.Struct:
+		s := map[string]any{}
+		for _, pair := range *root {
+			f := field(rv, pair.Name)
+			if !f.IsValid() || isNil(f) {
+				if !types.IsOptional(pair.Type) {
+					return nil, false
+				}
+				s[pair.Name] = nil
+				continue
+			}
+
+			v, ok := value(pair.Type, f)
+			if !ok {
+				return nil, false
+			}
+			s[pair.Name] = v
+		}
+		return s, true
+	default:
+		return nil, false
+	}
+}
+
+// cast converts a number to the numeric type t, the result wraps around or is
+// truncated like a go conversion to the type the go backend uses for t
+func cast(v float64, t types.Type) float64 {
+	t = types.Default(t)
+	for t != nil {
+		switch {
+		case types.Identical(t, types.Int):
+			return float64(int(v))
+		case types.Identical(t, types.Uint):
+			return float64(uint(int64(v)))
+		case types.Identical(t, types.Byte):
+			return float64(uint8(int64(v)))
+		case types.Identical(t, types.Rune):
+			return float64(int32(int64(v)))
+		}
+
+		if named, ok := types.Named(t); ok {
+			t = named.Base()
+			continue
+		}
+		break
+	}
+
+	switch types.RootOf(t) {
+	case types.I8:
+		return float64(int8(int64(v)))
+	case types.I16:
+		return float64(int16(int64(v)))
+	case types.I32:
+		return float64(int32(int64(v)))
+	case types.I64:
+		return float64(int64(v))
+	case types.U8:
+		return float64(uint8(int64(v)))
+	case types.U16:
+		return float64(uint16(int64(v)))
+	case types.U32:
+		return float64(uint32(int64(v)))
+	case types.U64:
+		return float64(uint64(v))
+	case types.F32:
+		return float64(float32(v))
+	default:
+		return v
+	}
+}
+
+// zero returns the value a missing map entry of type t has
+func zero(t types.Type) any {
+	switch root := types.RootOf(t).(type) {
+	case *types.Optional:
+		return nil
+	case *types.List:
+		return []any{}
+	case *types.Map:
+		return map[any]any{}
+	case *types.Struct:
+		s := map[string]any{}
+		for _, pair := range *root {
+			s[pair.Name] = zero(pair.Type)
+		}
+		return s
+	}
+
+	switch {
+	case types.IsString(t):
+		return ""
+	case types.RootOf(t) == types.Bool:
+		return false
+	case types.IsNumeric(t):
+		return 0.0
+	default:
+		return nil
+	}
+}
+
+// Format returns the text of a value of type t in a template, it matches the
+// formatting of generated code.
+func Format(v any, t types.Type) string {
+	if v == nil {
+		return "<nil>"
+	}
+
+	switch {
+	case t == nil:
+		return fmt.Sprint(v)
+	case types.IsString(t):
+		return v.(string)
+	case types.Identical(t, types.Rune):
+		return string(rune(v.(float64)))
+	case types.RootOf(t) == types.Bool:
+		return strconv.FormatBool(v.(bool))
+	case types.RootOf(t) == types.F32:
+		return strconv.FormatFloat(v.(float64), 'g', -1, 32)
+	case types.RootOf(t) == types.F64:
+		return strconv.FormatFloat(v.(float64), 'g', -1, 64)
+	case types.IsInteger(t):
+		switch types.RootOf(t) {
+		case types.U8, types.U16, types.U32, types.U64:
+			return strconv.FormatUint(uint64(v.(float64)), 10)
+		default:
+			return strconv.FormatInt(int64(v.(float64)), 10)
+		}
+	default:
+		return fmt.Sprint(v)
+	}
+}
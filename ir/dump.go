@@ -0,0 +1,313 @@
+package ir
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/types"
+)
+
+// Dump writes a textual form of the ir for debugging. Expressions are written
+// as s-expressions and every one of them is followed by its type, like
+// (+:int n:int 1:untyped int).
+func Dump(w io.Writer, out *IR) error {
+	d := &dumper{}
+	d.line(0, "package %s", out.Name)
+
+	if len(out.Locales) > 0 {
+		d.line(0, "")
+	}
+	for _, locale := range out.Locales {
+		d.line(0, "locale %s %s", locale.Name, locale.Tag)
+	}
+
+	if len(out.TypeDefs) > 0 {
+		d.line(0, "")
+	}
+	for _, def := range out.TypeDefs {
+		typ := def.Type
+		if !typ.IsRoot() {
+			typ = typ.Base()
+		}
+		d.line(0, "type %s %s", def.Name, typ)
+	}
+
+	for _, fn := range out.FnDefs {
+		d.line(0, "")
+		d.line(0, "fn %s(%s) %s", fn.Name, params(fn.Params), typeString(fn.Type.Out))
+		d.line(1, "%s", Format(fn.Body))
+	}
+
+	for _, section := range out.Sections {
+		d.line(0, "")
+		d.section(section, out.Locales, 0)
+	}
+
+	_, err := io.WriteString(w, d.String())
+	return err
+}
+
+type dumper struct {
+	strings.Builder
+}
+
+func (d *dumper) line(depth int, format string, args ...any) {
+	d.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(d, format, args...)
+	d.WriteString("\n")
+}
+
+func (d *dumper) section(section *Section, locales []Locale, depth int) {
+	d.line(depth, "section %s", section.Name)
+
+	for _, message := range section.Messages {
+		if message.IsTemplate {
+			d.line(depth+1, "template %s(%s)", message.Name, params(message.Params))
+		} else {
+			d.line(depth+1, "key %s", message.Name)
+		}
+
+		for _, locale := range locales {
+			if value, ok := message.Values[locale.Tag]; ok {
+				d.line(depth+2, "%s %s", locale.Name, Format(value))
+			}
+		}
+	}
+
+	for _, sub := range section.Sections {
+		d.section(sub, locales, depth+1)
+	}
+}
+
+func params(params []Param) string {
+	list := []string{}
+	for _, param := range params {
+		list = append(list, param.Name+" "+typeString(param.Type))
+	}
+	return strings.Join(list, ", ")
+}
+
+func typeString(t types.Type) string {
+	if t == nil {
+		return "?"
+	}
+	return t.String()
+}
+
+// Format returns the textual form of an expression
+func Format(expr Expr) string {
+	if expr == nil {
+		return "<nil>"
+	}
+
+	typ := ":" + typeString(expr.Type())
+
+	switch expr := expr.(type) {
+	case *Literal:
+		switch value := expr.Value.(type) {
+		case string:
+			return strconv.Quote(value) + typ
+		case float64:
+			return strconv.FormatFloat(value, 'g', -1, 64) + typ
+		default:
+			return fmt.Sprint(value) + typ
+		}
+	case *Ref:
+		return expr.Name + typ
+	case *Import:
+		return expr.Package + "::" + expr.Name + typ
+	case *Binary:
+		return list(expr.Op.String()+typ, expr.Left, expr.Right)
+	case *Unary:
+		return list(expr.Op.String()+typ, expr.X)
+	case *Ternary:
+		return list("?"+typ, expr.Cond, expr.Then, expr.Else)
+	case *Coalesce:
+		return list("??"+typ, expr.Left, expr.Right)
+	case *Call:
+		return list("call"+typ, append([]Expr{expr.Fn}, expr.Args...)...)
+	case *Convert:
+		return list("convert"+typ, expr.X)
+	case *Member:
+		return list("."+expr.Name+typ, expr.X)
+	case *Index:
+		return list("index"+typ, expr.X, expr.Index)
+	case *Template:
+		parts := []string{"template" + typ}
+		for _, segment := range expr.Segments {
+			if segment.Value == nil {
+				parts = append(parts, strconv.Quote(segment.Text))
+			} else {
+				parts = append(parts, Format(segment.Value))
+			}
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	default:
+		return fmt.Sprintf("<%T>", expr)
+	}
+}
+
+func list(head string, exprs ...Expr) string {
+	parts := []string{head}
+	for _, expr := range exprs {
+		parts = append(parts, Format(expr))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// Precedences of the source of expressions, they follow the ones of the
+// printer of the parser
+const (
+	precTernary = iota + 1
+	precCoalesce
+	precBinary
+	precAdditive
+	precMultiplicative
+	precUnary
+	precExponent
+	precPrimary
+)
+
+// Source returns the lcl source of an expression, conversions the checker
+// inserted are left out
+func Source(expr Expr) string {
+	src, _ := source(expr)
+	return src
+}
+
+// source returns the source of an expression along with its precedence
+func source(expr Expr) (string, int) {
+	switch expr := expr.(type) {
+	case *Ternary:
+		return group(expr.Cond, precTernary+1) + " ? " + Source(expr.Then) + " : " + Source(expr.Else), precTernary
+	case *Coalesce:
+		return group(expr.Left, precCoalesce) + " ?? " + group(expr.Right, precCoalesce+1), precCoalesce
+	case *Binary:
+		switch expr.Op {
+		case Pow:
+			return group(expr.Left, precExponent+1) + " ^ " + group(expr.Right, precExponent), precExponent
+		case Add, Sub:
+			return group(expr.Left, precAdditive) + " " + expr.Op.String() + " " + group(expr.Right, precAdditive+1), precAdditive
+		case Mul, Div, Mod:
+			return group(expr.Left, precMultiplicative) + " " + expr.Op.String() + " " + group(expr.Right, precMultiplicative+1), precMultiplicative
+		default:
+			return group(expr.Left, precBinary) + " " + expr.Op.String() + " " + group(expr.Right, precBinary+1), precBinary
+		}
+	case *Unary:
+		return expr.Op.String() + group(expr.X, precUnary), precUnary
+	case *Call:
+		args := []string{}
+		for _, arg := range expr.Args {
+			args = append(args, Source(arg))
+		}
+		return group(expr.Fn, precPrimary) + "(" + strings.Join(args, " ") + ")", precPrimary
+	case *Convert:
+		if expr.Implicit {
+			return source(expr.X)
+		}
+		return expr.Typ.String() + "(" + Source(expr.X) + ")", precPrimary
+	case *Member:
+		return group(expr.X, precPrimary) + "." + expr.Name, precPrimary
+	case *Import:
+		return expr.Package + "::" + expr.Name, precPrimary
+	case *Index:
+		return group(expr.X, precPrimary) + "[" + Source(expr.Index) + "]", precPrimary
+	case *Ref:
+		return expr.Name, precPrimary
+	case *Literal:
+		switch value := expr.Value.(type) {
+		case string:
+			return quote(value), precPrimary
+		case float64:
+			number := strconv.FormatFloat(value, 'f', -1, 64)
+			// Whole floats keep their fraction to stay floats
+			if u, ok := expr.Typ.(*types.Untyped); ok && u.IsFloat() && !strings.Contains(number, ".") {
+				number += ".0"
+			}
+			return number, precPrimary
+		default:
+			return expr.Text(), precPrimary
+		}
+	case *Template:
+		return "`" + templateSource(expr) + "`", precPrimary
+	default:
+		return "", precPrimary
+	}
+}
+
+// group wraps an expression that binds looser than prec in parentheses
+func group(expr Expr, prec int) string {
+	src, p := source(expr)
+	if p < prec {
+		return "(" + src + ")"
+	}
+	return src
+}
+
+func quote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// templateSource returns the contents of a template, the characters a
+// template cannot hold are interpolated as strings
+func templateSource(expr *Template) string {
+	b := strings.Builder{}
+
+	for _, segment := range expr.Segments {
+		if segment.Value != nil {
+			b.WriteString("{" + Source(segment.Value) + "}")
+			continue
+		}
+		for _, r := range segment.Text {
+			switch r {
+			case '{', '`':
+				b.WriteString("{" + quote(string(r)) + "}")
+			default:
+				b.WriteRune(r)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// Describe names the construct an expression is for diagnostics
+func Describe(expr Expr) string {
+	switch expr := expr.(type) {
+	case *Call:
+		switch fn := expr.Fn.(type) {
+		case *Ref:
+			return "call of " + fn.Name
+		case *Import:
+			return "call of " + fn.Package + "::" + fn.Name
+		}
+		return "call"
+	case *Binary:
+		switch expr.Op {
+		case Eq, Neq, Lt, Lte, Gt, Gte:
+			return "comparison " + expr.Op.String()
+		case And, Or:
+			return "logical operator " + expr.Op.String()
+		default:
+			return "arithmetic " + expr.Op.String()
+		}
+	case *Unary:
+		return "operator " + expr.Op.String()
+	case *Ternary:
+		return "ternary"
+	case *Coalesce:
+		return "operator ??"
+	case *Convert:
+		return "conversion to " + expr.Typ.String()
+	case *Member:
+		return "field " + expr.Name
+	case *Index:
+		return "index"
+	case *Import:
+		return "reference to " + expr.Package + "::" + expr.Name
+	default:
+		return "expression"
+	}
+}
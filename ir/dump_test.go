@@ -0,0 +1,104 @@
+package ir_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CanPacis/lcl/analyzer"
+	"github.com/CanPacis/lcl/ir"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/test"
+	"github.com/CanPacis/lcl/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `declare app (en tr)
+
+type Count int
+
+fn(n:int) sign n > 0 ? 1 : 0
+
+section inbox {
+  status {
+    en "Unread"
+    tr "Okunmadı"
+  }
+
+  count(n:int) {
+    en ` + "`{n} {n == 1 ? \"message\" : \"messages\"}`" + `
+    tr ` + "`{n} mesaj`" + `
+  }
+}
+`
+
+func TestDump(t *testing.T) {
+	assert := assert.New(t)
+
+	file := parser.NewFile("mock.lcl", bytes.NewBufferString(source))
+	tree := test.MustParse(test.WithFile(file))
+	out, err := analyzer.New(file, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+	if !assert.NoError(err) {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(ir.Dump(buf, out))
+	assert.Equal(`package app
+
+locale en en
+locale tr tr
+
+type Count int
+
+fn sign(n int) int
+  (?:untyped int (>:bool n:int 0:untyped int) 1:untyped int 0:untyped int)
+
+section inbox
+  key status
+    en "Unread":string
+    tr "Okunmadı":string
+  template count(n int)
+    en (template:template (string int string string string) n:int " " (?:string (==:bool n:int 1:untyped int) "message":string "messages":string))
+    tr (template:template (string int string) n:int " mesaj")
+`, buf.String())
+}
+
+func TestFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("<nil>", ir.Format(nil))
+	assert.Equal(`(call:? f:? "a":? 2:?)`, ir.Format(&ir.Call{
+		Fn:   &ir.Ref{Name: "f"},
+		Args: []ir.Expr{&ir.Literal{Value: "a"}, &ir.Literal{Value: 2.0}},
+	}))
+	assert.Equal("(!:? (.ok:? A::b:?))", ir.Format(&ir.Unary{
+		Op: ir.Not,
+		X:  &ir.Member{X: &ir.Import{Package: "A", Name: "b"}, Name: "ok"},
+	}))
+}
+
+func TestSource(t *testing.T) {
+	assert := assert.New(t)
+
+	n := &ir.Ref{Name: "n", Typ: types.Int}
+	assert.Equal("(n + 1) * 2", ir.Source(&ir.Binary{
+		Op:    ir.Mul,
+		Left:  &ir.Binary{Op: ir.Add, Left: n, Right: &ir.Literal{Value: 1.0, Typ: types.NewUntyped(1, false)}},
+		Right: &ir.Literal{Value: 2.0, Typ: types.NewUntyped(2, false)},
+	}))
+	assert.Equal("n / 2.0", ir.Source(&ir.Binary{
+		Op:    ir.Div,
+		Left:  &ir.Convert{X: n, Typ: types.F64, Implicit: true},
+		Right: &ir.Literal{Value: 2.0, Typ: types.NewUntyped(2, true)},
+	}))
+	assert.Equal(`f64(n) > 1 ? "many" : name ?? "you"`, ir.Source(&ir.Ternary{
+		Cond: &ir.Binary{Op: ir.Gt, Left: &ir.Convert{X: n, Typ: types.F64}, Right: &ir.Literal{Value: 1.0}},
+		Then: &ir.Literal{Value: "many"},
+		Else: &ir.Coalesce{Left: &ir.Ref{Name: "name"}, Right: &ir.Literal{Value: "you"}},
+	}))
+	assert.Equal("`a {\"{\"} {pkg::f(n -n)}`", ir.Source(&ir.Template{Segments: []ir.Segment{
+		{Text: "a { "},
+		{Value: &ir.Call{Fn: &ir.Import{Package: "pkg", Name: "f"}, Args: []ir.Expr{n, &ir.Unary{Op: ir.Neg, X: n}}}},
+	}}))
+}
@@ -0,0 +1,180 @@
+package ir
+
+import "github.com/CanPacis/lcl/types"
+
+// Expr is a checked expression, every expression knows its resolved type.
+type Expr interface {
+	Type() types.Type
+}
+
+type Op int
+
+const (
+	Illegal Op = iota
+	// Arithmetic
+	Add
+	Sub
+	Mul
+	Div
+	Mod
+	Pow
+	// Comparison
+	Eq
+	Neq
+	Lt
+	Lte
+	Gt
+	Gte
+	// Logical
+	And
+	Or
+	Not
+	Neg
+)
+
+var ops = map[Op]string{
+	Illegal: "illegal",
+	Add:     "+",
+	Sub:     "-",
+	Mul:     "*",
+	Div:     "/",
+	Mod:     "%",
+	Pow:     "^",
+	Eq:      "==",
+	Neq:     "!=",
+	Lt:      "<",
+	Lte:     "<=",
+	Gt:      ">",
+	Gte:     ">=",
+	And:     "&&",
+	Or:      "||",
+	Not:     "!",
+	Neg:     "-",
+}
+
+func (o Op) String() string {
+	return ops[o]
+}
+
+// Literal is a string, number or bool constant. Numbers are held as float64
+// and are untyped unless they were converted.
+type Literal struct {
+	Value any
+	Typ   types.Type
+}
+
+func (e *Literal) Type() types.Type { return e.Typ }
+
+// Text returns the text the literal has in a template.
+func (e *Literal) Text() string { return format(e.Value) }
+
+// Ref refers to a param, a fn or a builtin by name
+type Ref struct {
+	Name string
+	Typ  types.Type
+}
+
+func (e *Ref) Type() types.Type { return e.Typ }
+
+// Import refers to a member of an imported package
+type Import struct {
+	Package string
+	Name    string
+	Typ     types.Type
+}
+
+func (e *Import) Type() types.Type { return e.Typ }
+
+type Binary struct {
+	Op    Op
+	Left  Expr
+	Right Expr
+	Typ   types.Type
+}
+
+func (e *Binary) Type() types.Type { return e.Typ }
+
+type Unary struct {
+	Op  Op
+	X   Expr
+	Typ types.Type
+}
+
+func (e *Unary) Type() types.Type { return e.Typ }
+
+// Ternary evaluates to Then if Cond holds and to Else otherwise, only one of
+// the branches is evaluated.
+type Ternary struct {
+	Cond Expr
+	Then Expr
+	Else Expr
+	Typ  types.Type
+}
+
+func (e *Ternary) Type() types.Type { return e.Typ }
+
+// Coalesce evaluates to the value of the optional Left if it is present and to
+// Right otherwise.
+type Coalesce struct {
+	Left  Expr
+	Right Expr
+	Typ   types.Type
+}
+
+func (e *Coalesce) Type() types.Type { return e.Typ }
+
+type Call struct {
+	Fn   Expr
+	Args []Expr
+	Typ  types.Type
+}
+
+func (e *Call) Type() types.Type { return e.Typ }
+
+// Convert converts X to its own type. Implicit conversions are the ones the
+// checker inserts between numeric operands of different types.
+type Convert struct {
+	X        Expr
+	Typ      types.Type
+	Implicit bool
+}
+
+func (e *Convert) Type() types.Type { return e.Typ }
+
+// Member selects the field of a struct.
+type Member struct {
+	X    Expr
+	Name string
+	Typ  types.Type
+}
+
+func (e *Member) Type() types.Type { return e.Typ }
+
+// Index indexes a list or a map.
+type Index struct {
+	X     Expr
+	Index Expr
+	Typ   types.Type
+}
+
+func (e *Index) Type() types.Type { return e.Typ }
+
+// Segment is a part of a template, either literal text or a value.
+type Segment struct {
+	Text  string
+	Value Expr
+}
+
+// Template is a template literal. Its segments are flat, adjacent text is
+// merged and nested templates are inlined.
+type Template struct {
+	Segments []Segment
+	Typ      *types.Template
+}
+
+func (e *Template) Type() types.Type {
+	if e.Typ == nil {
+		return nil
+	}
+	return e.Typ
+}
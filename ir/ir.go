@@ -1,6 +1,11 @@
 package ir
 
+// IR is the typed representation of a package that backends generate code
+// from, it holds no syntax nodes.
 type IR struct {
+	Name     string
+	Locales  []Locale
 	FnDefs   []FnDef
 	TypeDefs []TypeDef
+	Sections []*Section
 }
@@ -0,0 +1,303 @@
+package ir
+
+import (
+	"strconv"
+
+	"github.com/CanPacis/lcl/types"
+)
+
+// Optimize folds the constant expressions of the fns and messages of out in
+// place, see Fold.
+func Optimize(out *IR) {
+	for i := range out.FnDefs {
+		out.FnDefs[i].Body = Fold(out.FnDefs[i].Body)
+	}
+
+	for _, section := range out.Sections {
+		optimizeSection(section)
+	}
+}
+
+func optimizeSection(section *Section) {
+	for _, message := range section.Messages {
+		for tag, value := range message.Values {
+			message.Values[tag] = Fold(value)
+		}
+	}
+
+	for _, sub := range section.Sections {
+		optimizeSection(sub)
+	}
+}
+
+// Fold returns an equivalent of expr where operations on constants are
+// computed, ternaries with a constant condition are replaced by the branch
+// they take and templates without interpolations become plain strings. The
+// given expression is not modified.
+func Fold(expr Expr) Expr {
+	switch expr := expr.(type) {
+	case *Binary:
+		// Arithmetic on untyped constants is already computed by the checker
+		if u, ok := expr.Typ.(*types.Untyped); ok && u.IsConstant() {
+			return &Literal{Value: u.Value, Typ: u}
+		}
+		return foldBinary(&Binary{Op: expr.Op, Left: Fold(expr.Left), Right: Fold(expr.Right), Typ: expr.Typ})
+	case *Unary:
+		if u, ok := expr.Typ.(*types.Untyped); ok && u.IsConstant() {
+			return &Literal{Value: u.Value, Typ: u}
+		}
+
+		x := Fold(expr.X)
+		if v, ok := literal[bool](x); ok && expr.Op == Not {
+			return &Literal{Value: !v, Typ: expr.Typ}
+		}
+		return &Unary{Op: expr.Op, X: x, Typ: expr.Typ}
+	case *Ternary:
+		cond := Fold(expr.Cond)
+		then, els := Fold(expr.Then), Fold(expr.Else)
+
+		if v, ok := literal[bool](cond); ok {
+			branch := els
+			if v {
+				branch = then
+			}
+
+			// The branch stands in for the ternary only if it has the same
+			// type, an optional ternary may take a branch that is not
+			if branch, ok := retype(branch, expr.Typ); ok {
+				return branch
+			}
+		}
+		return &Ternary{Cond: cond, Then: then, Else: els, Typ: expr.Typ}
+	case *Coalesce:
+		return &Coalesce{Left: Fold(expr.Left), Right: Fold(expr.Right), Typ: expr.Typ}
+	case *Call:
+		args := []Expr{}
+		for _, arg := range expr.Args {
+			args = append(args, Fold(arg))
+		}
+		return &Call{Fn: Fold(expr.Fn), Args: args, Typ: expr.Typ}
+	case *Convert:
+		return &Convert{X: Fold(expr.X), Typ: expr.Typ}
+	case *Member:
+		return &Member{X: Fold(expr.X), Name: expr.Name, Typ: expr.Typ}
+	case *Index:
+		return &Index{X: Fold(expr.X), Index: Fold(expr.Index), Typ: expr.Typ}
+	case *Template:
+		return foldTemplate(expr)
+	default:
+		return expr
+	}
+}
+
+func foldBinary(expr *Binary) Expr {
+	left, lok := expr.Left.(*Literal)
+	right, rok := expr.Right.(*Literal)
+
+	if lok && rok {
+		if value, ok := compute(expr.Op, left.Value, right.Value); ok {
+			return &Literal{Value: value, Typ: expr.Typ}
+		}
+	}
+
+	// Operations that leave the other operand as it is, the operand that is
+	// kept must be evaluated since it may call a fn
+	switch {
+	case lok && identity(expr.Op, left.Value, true):
+		if x, ok := retype(expr.Right, expr.Typ); ok {
+			return x
+		}
+	case rok && identity(expr.Op, right.Value, false):
+		if x, ok := retype(expr.Left, expr.Typ); ok {
+			return x
+		}
+	case lok && expr.Op == And && left.Value == false,
+		lok && expr.Op == Or && left.Value == true:
+		return &Literal{Value: left.Value, Typ: expr.Typ}
+	}
+
+	return expr
+}
+
+// compute evaluates an operation on two constants
+func compute(op Op, left, right any) (any, bool) {
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, false
+		}
+
+		switch op {
+		case Eq:
+			return l == r, true
+		case Neq:
+			return l != r, true
+		case Lt:
+			return l < r, true
+		case Lte:
+			return l <= r, true
+		case Gt:
+			return l > r, true
+		case Gte:
+			return l >= r, true
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, false
+		}
+
+		switch op {
+		case Add:
+			return l + r, true
+		case Eq:
+			return l == r, true
+		case Neq:
+			return l != r, true
+		case Lt:
+			return l < r, true
+		case Lte:
+			return l <= r, true
+		case Gt:
+			return l > r, true
+		case Gte:
+			return l >= r, true
+		}
+	case bool:
+		r, ok := right.(bool)
+		if !ok {
+			return nil, false
+		}
+
+		switch op {
+		case And:
+			return l && r, true
+		case Or:
+			return l || r, true
+		case Eq:
+			return l == r, true
+		case Neq:
+			return l != r, true
+		}
+	}
+
+	return nil, false
+}
+
+// identity reports whether the constant v leaves the other operand of op as
+// it is, left tells on which side of the operation v is
+func identity(op Op, v any, left bool) bool {
+	switch op {
+	case Add:
+		return v == 0.0 || v == ""
+	case Sub, Div, Pow:
+		return !left && (v == 0.0 && op == Sub || v == 1.0 && op != Sub)
+	case Mul:
+		return v == 1.0
+	case And:
+		return v == true
+	case Or:
+		return v == false
+	}
+	return false
+}
+
+// retype returns expr as an expression of the type typ if it already has it,
+// constants adopt the type unless it is optional
+func retype(expr Expr, typ types.Type) (Expr, bool) {
+	switch {
+	case typ == nil || types.Identical(typeOf(expr), typ):
+		return expr, true
+	case types.IsOptional(typ):
+		return nil, false
+	}
+
+	lit, ok := expr.(*Literal)
+	if !ok {
+		return nil, false
+	}
+
+	if _, ok := typ.(*types.Untyped); ok {
+		return lit, true
+	}
+	return &Literal{Value: lit.Value, Typ: typ}, true
+}
+
+func foldTemplate(expr *Template) Expr {
+	template := &Template{Typ: expr.Typ}
+	text := func(s string) {
+		if len(s) == 0 {
+			return
+		}
+
+		n := len(template.Segments)
+		if n > 0 && template.Segments[n-1].Value == nil {
+			template.Segments[n-1].Text += s
+		} else {
+			template.Segments = append(template.Segments, Segment{Text: s})
+		}
+	}
+
+	var add func(segments []Segment)
+	add = func(segments []Segment) {
+		for _, segment := range segments {
+			if segment.Value == nil {
+				text(segment.Text)
+				continue
+			}
+
+			switch value := Fold(segment.Value).(type) {
+			case *Literal:
+				text(format(value.Value))
+			case *Template:
+				add(value.Segments)
+			default:
+				template.Segments = append(template.Segments, Segment{Value: value})
+			}
+		}
+	}
+	add(expr.Segments)
+
+	switch len(template.Segments) {
+	case 0:
+		return &Literal{Value: "", Typ: types.String}
+	case 1:
+		if template.Segments[0].Value == nil {
+			return &Literal{Value: template.Segments[0].Text, Typ: types.String}
+		}
+	}
+	return template
+}
+
+// format returns the text a constant has in a template
+func format(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+func literal[T any](expr Expr) (T, bool) {
+	lit, ok := expr.(*Literal)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	v, ok := lit.Value.(T)
+	return v, ok
+}
+
+func typeOf(expr Expr) types.Type {
+	if expr == nil {
+		return nil
+	}
+	return expr.Type()
+}
@@ -0,0 +1,171 @@
+package ir_test
+
+import (
+	"testing"
+
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/test"
+	"github.com/CanPacis/lcl/types"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+type FoldCase struct {
+	In  ir.Expr
+	Out string
+}
+
+func (c *FoldCase) Run(assert *assert.Assertions) {
+	before := ir.Format(c.In)
+	assert.Equal(c.Out, ir.Format(ir.Fold(c.In)))
+	assert.Equal(before, ir.Format(c.In))
+}
+
+func num(v float64) *ir.Literal {
+	return &ir.Literal{Value: v, Typ: types.NewUntyped(v, false)}
+}
+
+func str(s string) *ir.Literal {
+	return &ir.Literal{Value: s, Typ: types.String}
+}
+
+func boolean(b bool) *ir.Literal {
+	return &ir.Literal{Value: b, Typ: types.Bool}
+}
+
+func TestFold(t *testing.T) {
+	n := &ir.Ref{Name: "n", Typ: types.Int}
+	name := &ir.Ref{Name: "name", Typ: types.String}
+	ok := &ir.Ref{Name: "ok", Typ: types.Bool}
+	call := &ir.Call{Fn: &ir.Ref{Name: "f"}, Args: []ir.Expr{}, Typ: types.Bool}
+
+	tests := []test.Runner{
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.Mul, Left: num(2), Right: num(3), Typ: types.NewUntyped(6, false)},
+			Out: "6:untyped int",
+		},
+		&FoldCase{
+			In:  &ir.Unary{Op: ir.Neg, X: num(2), Typ: types.NewUntyped(-2, false)},
+			Out: "-2:untyped int",
+		},
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.Lt, Left: num(2), Right: num(3), Typ: types.Bool},
+			Out: "true:bool",
+		},
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.Add, Left: str("a"), Right: str("b"), Typ: types.String},
+			Out: `"ab":string`,
+		},
+		&FoldCase{
+			In:  &ir.Unary{Op: ir.Not, X: &ir.Binary{Op: ir.Eq, Left: str("a"), Right: str("b"), Typ: types.Bool}, Typ: types.Bool},
+			Out: "true:bool",
+		},
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.Add, Left: n, Right: num(0), Typ: types.Int},
+			Out: "n:int",
+		},
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.Mul, Left: num(1), Right: n, Typ: types.Int},
+			Out: "n:int",
+		},
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.Sub, Left: num(0), Right: n, Typ: types.Int},
+			Out: "(-:int 0:untyped int n:int)",
+		},
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.Add, Left: str(""), Right: name, Typ: types.String},
+			Out: "name:string",
+		},
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.And, Left: boolean(true), Right: ok, Typ: types.Bool},
+			Out: "ok:bool",
+		},
+		&FoldCase{
+			In:  &ir.Binary{Op: ir.Or, Left: boolean(true), Right: call, Typ: types.Bool},
+			Out: "true:bool",
+		},
+		&FoldCase{
+			// The call is kept since it has to be evaluated
+			In:  &ir.Binary{Op: ir.And, Left: call, Right: boolean(false), Typ: types.Bool},
+			Out: "(&&:bool (call:bool f:?) false:bool)",
+		},
+		&FoldCase{
+			In:  &ir.Ternary{Cond: boolean(true), Then: n, Else: num(0), Typ: types.Int},
+			Out: "n:int",
+		},
+		&FoldCase{
+			In:  &ir.Ternary{Cond: boolean(false), Then: n, Else: num(0), Typ: types.Int},
+			Out: "0:int",
+		},
+		&FoldCase{
+			In: &ir.Ternary{
+				Cond: &ir.Binary{Op: ir.Gt, Left: num(1), Right: num(2), Typ: types.Bool},
+				Then: &ir.Ternary{Cond: ok, Then: name, Else: str("a"), Typ: types.String},
+				Else: name,
+				Typ:  types.String,
+			},
+			Out: "name:string",
+		},
+		&FoldCase{
+			// The branch is not optional so it cannot stand in for the ternary
+			In: &ir.Ternary{
+				Cond: boolean(true),
+				Then: name,
+				Else: &ir.Ref{Name: "nick", Typ: types.NewOptional(types.String)},
+				Typ:  types.NewOptional(types.String),
+			},
+			Out: "(?:string? true:bool name:string nick:string?)",
+		},
+		&FoldCase{
+			In: &ir.Template{Segments: []ir.Segment{
+				{Text: "a "},
+				{Value: &ir.Binary{Op: ir.Add, Left: num(1), Right: num(2), Typ: types.NewUntyped(3, false)}},
+				{Text: " "},
+				{Value: &ir.Ternary{Cond: boolean(true), Then: str("b"), Else: str("c"), Typ: types.String}},
+				{Value: &ir.Template{Segments: []ir.Segment{{Text: "!"}}}},
+			}},
+			Out: `"a 3 b!":string`,
+		},
+		&FoldCase{
+			In:  &ir.Template{Segments: []ir.Segment{}},
+			Out: `"":string`,
+		},
+		&FoldCase{
+			In: &ir.Template{Segments: []ir.Segment{
+				{Value: str("n=")},
+				{Value: &ir.Binary{Op: ir.Add, Left: n, Right: num(0), Typ: types.Int}},
+			}},
+			Out: `(template:? "n=" n:int)`,
+		},
+	}
+
+	test.Run(t, tests)
+}
+
+func TestOptimize(t *testing.T) {
+	assert := assert.New(t)
+
+	out := &ir.IR{
+		FnDefs: []ir.FnDef{{
+			Definition: ir.NewDefinition("f", false),
+			Type:       &types.Fn{Out: types.Bool},
+			Body:       &ir.Binary{Op: ir.Or, Left: boolean(false), Right: boolean(true), Typ: types.Bool},
+		}},
+		Sections: []*ir.Section{{
+			Definition: ir.NewDefinition("s", false),
+			Sections: []*ir.Section{{
+				Definition: ir.NewDefinition("sub", false),
+				Messages: []*ir.Message{{
+					Definition: ir.NewDefinition("k", false),
+					Values: map[language.Tag]ir.Expr{
+						language.English: &ir.Template{Segments: []ir.Segment{{Text: "key"}}},
+					},
+				}},
+			}},
+		}},
+	}
+
+	ir.Optimize(out)
+	assert.Equal("true:bool", ir.Format(out.FnDefs[0].Body))
+	assert.Equal(`"key":string`, ir.Format(out.Sections[0].Sections[0].Messages[0].Values[language.English]))
+}
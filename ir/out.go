@@ -1,8 +1,8 @@
 package ir
 
 import (
-	"github.com/CanPacis/lcl/parser/ast"
 	"github.com/CanPacis/lcl/types"
+	"golang.org/x/text/language"
 )
 
 type Definition struct {
@@ -17,10 +17,16 @@ func NewDefinition(name string, exported bool) *Definition {
 	}
 }
 
+type Param struct {
+	Name string
+	Type types.Type
+}
+
 type FnDef struct {
 	*Definition
-	Type *types.Fn
-	Stmt *ast.FnDefStmt
+	Params []Param
+	Type   *types.Fn
+	Body   Expr
 }
 
 type TypeDef struct {
@@ -28,3 +34,39 @@ type TypeDef struct {
 	Type      types.Type
 	IsSection bool
 }
+
+// Locale is a target of the package, Name is the name it is referred by in
+// the source.
+type Locale struct {
+	Name string
+	Tag  language.Tag
+}
+
+// Pos is the place a definition starts at in the source of the package,
+// lines and columns start at 1
+type Pos struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// Message is a key or a template of a section. Keys have no params and their
+// values are plain strings, templates are rendered with their params.
+// Comment holds the lines of the comments above the entry.
+type Message struct {
+	*Definition
+	IsTemplate bool
+	Params     []Param
+	Type       *types.Template
+	Values     map[language.Tag]Expr
+	Comment    string
+	Pos        Pos
+}
+
+type Section struct {
+	*Definition
+	Messages []*Message
+	Sections []*Section
+	Comment  string
+	Pos      Pos
+}
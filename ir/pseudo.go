@@ -0,0 +1,101 @@
+package ir
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/CanPacis/lcl/types"
+	"golang.org/x/text/language"
+)
+
+// PseudoTag is the tag of the pseudo locale, en-XA is reserved for accented
+// pseudo English by Android and Chrome
+var PseudoTag = language.MustParse("en-XA")
+
+// accents maps ascii letters to accented look-alikes that stay readable
+var accents = strings.NewReplacer(
+	"a", "á", "b", "ƀ", "c", "ç", "d", "ð", "e", "é", "f", "ƒ", "g", "ĝ",
+	"h", "ĥ", "i", "î", "j", "ĵ", "k", "ķ", "l", "ļ", "m", "ɱ", "n", "ñ",
+	"o", "ö", "p", "þ", "q", "ǫ", "r", "ŕ", "s", "š", "t", "ţ", "u", "û",
+	"v", "ṽ", "w", "ŵ", "x", "ẋ", "y", "ý", "z", "ž",
+	"A", "Å", "B", "Ɓ", "C", "Ç", "D", "Ð", "E", "É", "F", "Ƒ", "G", "Ĝ",
+	"H", "Ĥ", "I", "Î", "J", "Ĵ", "K", "Ķ", "L", "Ļ", "M", "Ṁ", "N", "Ñ",
+	"O", "Ö", "P", "Þ", "Q", "Ǫ", "R", "Ŕ", "S", "Š", "T", "Ţ", "U", "Û",
+	"V", "Ṽ", "W", "Ŵ", "X", "Ẋ", "Y", "Ý", "Z", "Ž",
+)
+
+// Pseudo adds the pseudo locale to out, its messages are the ones of the
+// source locale with accented text that is expanded by about a third and
+// enclosed in brackets. Only the literal text of templates is transformed,
+// interpolated values are rendered as they are so hard-coded strings stand
+// out as unaccented and truncated ones lose their closing bracket.
+func Pseudo(out *IR, name string) Locale {
+	Optimize(out)
+	locale := Locale{Name: name, Tag: PseudoTag}
+	if len(out.Locales) == 0 {
+		return locale
+	}
+
+	source := out.Locales[0].Tag
+	var section func(s *Section)
+	section = func(s *Section) {
+		for _, message := range s.Messages {
+			if value := message.Values[source]; value != nil {
+				message.Values[PseudoTag] = pseudoExpr(value, message.Type)
+			}
+		}
+		for _, sub := range s.Sections {
+			section(sub)
+		}
+	}
+	for _, s := range out.Sections {
+		section(s)
+	}
+
+	out.Locales = append(out.Locales, locale)
+	return locale
+}
+
+// Pseudolocalize returns the pseudo text of s, see Pseudo
+func Pseudolocalize(s string) string {
+	s = accents.Replace(s)
+	return "[" + s + expansion(utf8.RuneCountInString(s)) + "]"
+}
+
+// pseudoExpr returns the pseudo value of a message, values that are neither
+// text nor templates are enclosed in brackets as they are
+func pseudoExpr(expr Expr, typ *types.Template) Expr {
+	switch expr := expr.(type) {
+	case *Literal:
+		if text, ok := expr.Value.(string); ok {
+			return &Literal{Value: Pseudolocalize(text), Typ: expr.Typ}
+		}
+	case *Template:
+		typ = expr.Typ
+	}
+
+	segments := []Segment{{Text: "["}}
+	if template, ok := expr.(*Template); ok {
+		segments = append(segments, template.Segments...)
+	} else {
+		segments = append(segments, Segment{Value: expr})
+	}
+
+	length := 0
+	for i := range segments[1:] {
+		segment := &segments[i+1]
+		if segment.Value == nil {
+			segment.Text = accents.Replace(segment.Text)
+			length += utf8.RuneCountInString(segment.Text)
+		}
+	}
+	segments = append(segments, Segment{Text: expansion(length) + "]"})
+
+	return Fold(&Template{Segments: segments, Typ: typ})
+}
+
+// expansion returns the padding that lengthens a text of n runes by about a
+// third, translations tend to be longer than their English source
+func expansion(n int) string {
+	return strings.Repeat("~", (n*3+9)/10)
+}
@@ -0,0 +1,29 @@
+package ir_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPseudo(t *testing.T) {
+	assert := assert.New(t)
+
+	out := test.MustScan(source)
+	locale := ir.Pseudo(out, "pseudo")
+	assert.Equal(ir.Locale{Name: "pseudo", Tag: ir.PseudoTag}, locale)
+	assert.Equal(locale, out.Locales[2])
+
+	buf := &bytes.Buffer{}
+	assert.NoError(ir.Dump(buf, out))
+	// Interpolated values are kept as they are, text is accented and expanded
+	assert.Contains(buf.String(), "locale pseudo en-XA\n")
+	assert.Contains(buf.String(), "    pseudo \"[Ûñŕéáð~~]\":string\n")
+	assert.Contains(buf.String(), `    pseudo (template:template (string int string string string) "[" n:int " " (?:string (==:bool n:int 1:untyped int) "message":string "messages":string) "~]")`)
+
+	assert.Equal("[Ĥéļļö, ŵöŕļð!~~~~]", ir.Pseudolocalize("Hello, world!"))
+	assert.Equal("[]", ir.Pseudolocalize(""))
+}
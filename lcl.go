@@ -0,0 +1,80 @@
+// Package lcl holds the runtime helpers that generated go code depends on.
+package lcl
+
+// Coalesce returns the value v points to, or d when v is nil.
+func Coalesce[T any](v *T, d T) T {
+	if v == nil {
+		return d
+	}
+	return *v
+}
+
+// Some returns a pointer to a copy of v, it is used where an optional value is
+// expected.
+func Some[T any](v T) *T {
+	return &v
+}
+
+// Convert converts the value v points to with fn, it returns nil when v is
+// nil.
+func Convert[T, U any](v *T, fn func(T) U) *U {
+	if v == nil {
+		return nil
+	}
+	return Some(fn(*v))
+}
+
+// ConvertList converts every element of v with fn, it is used where a list is
+// passed as a list of a wider element type.
+func ConvertList[T, U any](v []T, fn func(T) U) []U {
+	if v == nil {
+		return nil
+	}
+
+	list := make([]U, len(v))
+	for i, e := range v {
+		list[i] = fn(e)
+	}
+	return list
+}
+
+// ConvertMap converts every value of v with fn, it is used where a map is
+// passed as a map of a wider value type.
+func ConvertMap[K comparable, T, U any](v map[K]T, fn func(T) U) map[K]U {
+	if v == nil {
+		return nil
+	}
+
+	m := make(map[K]U, len(v))
+	for k, e := range v {
+		m[k] = fn(e)
+	}
+	return m
+}
+
+// Template is a message that is rendered by calling a fn of type F, F is a
+// func type that takes the params of the template and returns a string.
+// Unlike funcs, templates are comparable, two templates are equal only if they
+// were created by the same call to NewTemplate.
+type Template[F any] struct {
+	key string
+	fn  *F
+}
+
+// NewTemplate returns a template identified by key that is rendered with fn.
+func NewTemplate[F any](key string, fn F) Template[F] {
+	return Template[F]{
+		key: key,
+		fn:  &fn,
+	}
+}
+
+// Key returns the path of the template in its catalog, like "auth.greet".
+func (t Template[F]) Key() string {
+	return t.key
+}
+
+// Render returns the fn that renders the template.
+func (t Template[F]) Render() F {
+	return *t.fn
+}
@@ -0,0 +1,68 @@
+package lcl_test
+
+import (
+	"testing"
+
+	"github.com/CanPacis/lcl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalesce(t *testing.T) {
+	assert := assert.New(t)
+	v := 4
+
+	assert.Equal(4, lcl.Coalesce(&v, 0))
+	assert.Equal(0, lcl.Coalesce(nil, 0))
+}
+
+func TestSome(t *testing.T) {
+	assert := assert.New(t)
+	v := 4
+	p := lcl.Some(v)
+	v++
+
+	assert.Equal(4, *p)
+}
+
+func TestConvert(t *testing.T) {
+	assert := assert.New(t)
+	v := int32(4)
+	fn := func(v int32) int { return int(v) }
+
+	assert.Equal(4, *lcl.Convert(&v, fn))
+	assert.Nil(lcl.Convert(nil, fn))
+}
+
+func TestConvertList(t *testing.T) {
+	assert := assert.New(t)
+	fn := func(v int32) int { return int(v) }
+
+	assert.Equal([]int{1, 2}, lcl.ConvertList([]int32{1, 2}, fn))
+	assert.Nil(lcl.ConvertList(nil, fn))
+}
+
+func TestConvertMap(t *testing.T) {
+	assert := assert.New(t)
+	fn := func(v int32) int { return int(v) }
+
+	assert.Equal(map[string]int{"a": 1}, lcl.ConvertMap(map[string]int32{"a": 1}, fn))
+	assert.Nil(lcl.ConvertMap(map[string]int32(nil), fn))
+}
+
+func TestTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	greet := func(name string) string { return "Hello " + name }
+	a := lcl.NewTemplate("auth.greet", greet)
+	b := lcl.NewTemplate("auth.greet", greet)
+	c := a
+
+	assert.Equal("auth.greet", a.Key())
+	assert.Equal("Hello Ada", a.Render()("Ada"))
+	assert.True(a == c)
+	assert.False(a == b)
+
+	set := map[lcl.Template[func(string) string]]bool{a: true}
+	assert.True(set[c])
+	assert.False(set[b])
+}
@@ -1,6 +1,8 @@
 package pkg
 
 import (
+	"math"
+
 	"github.com/CanPacis/lcl/errs"
 	"github.com/CanPacis/lcl/internal"
 	"github.com/CanPacis/lcl/parser/ast"
@@ -24,6 +26,10 @@ type Scope struct {
 	importDefs map[string]*ast.IdentExpr
 	fnDefs     map[string]*ast.FnDefStmt
 
+	// types holds the resolved type of every expression, it is shared with
+	// the sub scopes
+	types map[ast.Expr]types.Type
+
 	ctx    *internal.Stack[Context]
 	parent *Scope
 }
@@ -32,6 +38,12 @@ func (s *Scope) Exports() map[string]types.Type {
 	return s.objects
 }
 
+// Types returns the types of the expressions that were resolved in the scope
+// and its sub scopes
+func (s *Scope) Types() map[ast.Expr]types.Type {
+	return s.types
+}
+
 func (s *Scope) RegisterImport(def *ast.IdentExpr) error {
 	if original, exists := s.importDefs[def.Value]; exists {
 		return &errs.ReferenceError{
@@ -55,6 +67,14 @@ func (s *Scope) Pop() *Scope {
 }
 
 func (s *Scope) RegisterFn(def *ast.FnDefStmt) error {
+	if typ, exists := s.builtin[def.Name.Value]; exists {
+		return &errs.TypeError{
+			Err:  errs.ErrBuiltinOverride,
+			Node: def,
+			Type: typ,
+		}
+	}
+
 	if original, exists := s.fnDefs[def.Name.Value]; exists {
 		return &errs.ReferenceError{
 			Err:      errs.ErrDuplicateDefinition,
@@ -90,6 +110,12 @@ func (s *Scope) DefineBuiltin(name string, typ types.Type) {
 }
 
 func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
+	typ, err := s.resolveExpr(expr)
+	s.types[expr] = typ
+	return typ, err
+}
+
+func (s Scope) resolveExpr(expr ast.Expr) (types.Type, error) {
 	switch expr := expr.(type) {
 	case *ast.BinaryExpr:
 		left, err := s.ResolveExpr(expr.Left)
@@ -102,7 +128,23 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			return types.Bool, err
 		}
 
+		// Optionals are compared once a default is provided, an absent value
+		// has no order and backends hold them as references
+		for _, typ := range []types.Type{left, right} {
+			if types.IsOptional(typ) {
+				return types.Bool, &errs.TypeError{
+					Err:  errs.ErrOptionalValue,
+					Node: expr,
+					Type: typ,
+				}
+			}
+		}
+
 		if !left.Comparable(right) {
+			if err := overflow(expr, left, right); err != nil {
+				return types.Bool, err
+			}
+
 			return types.Bool, &errs.TypeError{
 				Err:   errs.ErrNotComparable,
 				Node:  expr,
@@ -111,6 +153,24 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			}
 		}
 
+		var defined bool
+		switch expr.Operator.Kind {
+		case token.LT, token.LTE, token.GT, token.GTE:
+			defined = types.Ordered(left, right)
+		case token.AND, token.OR:
+			defined = types.RootOf(left) == types.Bool
+		default:
+			defined = true
+		}
+		if !defined {
+			return types.Bool, &errs.TypeError{
+				Err:   errs.ErrInvalidOperation,
+				Node:  expr,
+				Type:  left,
+				Value: expr.Operator.Kind.String(),
+			}
+		}
+
 		return types.Bool, nil
 	case *ast.ArithmeticExpr:
 		left, err := s.ResolveExpr(expr.Left)
@@ -133,9 +193,31 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			op = types.Multiplication
 		case token.FORWARD_SLASH:
 			op = types.Division
+		case token.CARET:
+			op = types.Exponent
+		case token.PERCENT:
+			op = types.Modulo
+		}
+
+		if op == types.Modulo && types.IsNumeric(left) && types.IsNumeric(right) {
+			// Floats have no remainder, the other operand does not matter
+			for _, typ := range []types.Type{left, right} {
+				if !types.IsInteger(typ) {
+					return left, &errs.TypeError{
+						Err:   errs.ErrInvalidOperation,
+						Node:  expr,
+						Type:  typ,
+						Value: expr.Operator.Kind.String(),
+					}
+				}
+			}
 		}
 
 		if !left.Operable(right, op) {
+			if err := overflow(expr, left, right); err != nil {
+				return types.Unify(left, right), err
+			}
+
 			return left, &errs.TypeError{
 				Err:   errs.ErrNotOperable,
 				Node:  expr,
@@ -144,7 +226,54 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			}
 		}
 
-		return left, nil
+		lu, lok := left.(*types.Untyped)
+		ru, rok := right.(*types.Untyped)
+		// A constant zero divisor is an error whatever the dividend is
+		if rok && ru.IsConstant() && ru.Value == 0 && (op == types.Division || op == types.Modulo) {
+			return left, &errs.TypeError{
+				Err:  errs.ErrDivisionByZero,
+				Node: expr,
+			}
+		}
+
+		if lok && rok {
+			typ, ok := types.Fold(lu, ru, op)
+			if !ok {
+				return left, &errs.TypeError{
+					Err:  errs.ErrDivisionByZero,
+					Node: expr,
+				}
+			}
+			return typ, nil
+		}
+
+		return types.Unify(left, right), nil
+	case *ast.UnaryExpr:
+		typ, err := s.ResolveExpr(expr.Expr)
+		if err != nil {
+			return types.Invalid, err
+		}
+
+		switch expr.Operator.Kind {
+		case token.EXCLAMATION_MARK:
+			if types.RootOf(typ) == types.Bool {
+				return types.Bool, nil
+			}
+		case token.MINUS:
+			if u, ok := typ.(*types.Untyped); ok {
+				return types.Negate(u), nil
+			}
+			if types.IsNumeric(typ) {
+				return typ, nil
+			}
+		}
+
+		return typ, &errs.TypeError{
+			Err:   errs.ErrInvalidOperation,
+			Node:  expr,
+			Type:  typ,
+			Value: expr.Operator.Kind.String(),
+		}
 	case *ast.TernaryExpr:
 		pred, err := s.ResolveExpr(expr.Predicate)
 		if err != nil {
@@ -170,6 +299,10 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 		}
 
 		if !left.Convertible(right) {
+			if err := overflow(expr, left, right); err != nil {
+				return types.Unify(left, right), err
+			}
+
 			return left, &errs.TypeError{
 				Err:   errs.ErrMultipleTypes,
 				Node:  expr,
@@ -178,7 +311,45 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			}
 		}
 
-		return left, nil
+		// The result is absent if either of the branches may be absent
+		if types.IsOptional(left) || types.IsOptional(right) {
+			return types.NewOptional(types.Unify(types.Unwrap(left), types.Unwrap(right))), nil
+		}
+		return types.Unify(left, right), nil
+	case *ast.CoalesceExpr:
+		left, err := s.ResolveExpr(expr.Left)
+		if err != nil {
+			return types.Invalid, err
+		}
+
+		right, err := s.ResolveExpr(expr.Right)
+		if err != nil {
+			return types.Unwrap(left), err
+		}
+
+		if !types.IsOptional(left) {
+			return left, &errs.TypeError{
+				Err:  errs.ErrNotOptional,
+				Node: expr.Left,
+				Type: left,
+			}
+		}
+
+		inner := types.Unwrap(left)
+		if !inner.Convertible(types.Unwrap(right)) {
+			return inner, &errs.TypeError{
+				Err:   errs.ErrMultipleTypes,
+				Node:  expr,
+				Left:  inner,
+				Right: right,
+			}
+		}
+
+		// A default that may be absent itself keeps the result optional
+		if types.IsOptional(right) {
+			return types.NewOptional(inner), nil
+		}
+		return inner, nil
 	case *ast.CallExpr:
 		s.ctx.Push(FN)
 		defer s.ctx.Pop()
@@ -187,6 +358,10 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			return types.Invalid, err
 		}
 
+		if conv, ok := fn.(*types.Conversion); ok {
+			return s.resolveConversion(conv, expr)
+		}
+
 		callable, ok := fn.(*types.Fn)
 		if !ok {
 			return types.Invalid, &errs.TypeError{
@@ -224,6 +399,10 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			param := callable.In[i]
 
 			if !param.Assignable(typ) {
+				if err := overflow(arg, param, typ); err != nil {
+					return callable.Out, err
+				}
+
 				return callable.Out, &errs.TypeError{
 					Err:   errs.ErrInvalidType,
 					Left:  param,
@@ -288,21 +467,12 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			return types.Invalid, err
 		}
 
-		if index != types.Int {
-			return types.Invalid, &errs.TypeError{
-				Err:   errs.ErrInvalidIndex,
-				Type:  host,
-				Node:  expr.Index,
-				Value: index.String(),
-			}
-		}
-
-		typ, ok := indexer.Index(0)
+		typ, ok := indexer.Index(index)
 		if !ok {
 			return types.Invalid, &errs.TypeError{
 				Err:   errs.ErrInvalidIndex,
 				Type:  host,
-				Node:  expr.Host,
+				Node:  expr.Index,
 				Value: index.String(),
 			}
 		}
@@ -342,16 +512,30 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 			if err != nil {
 				return types.NewTemplate([]types.Type{}), err
 			}
-			in = append(in, typ)
+
+			if types.IsOptional(typ) {
+				return types.NewTemplate([]types.Type{}), &errs.TypeError{
+					Err:  errs.ErrOptionalValue,
+					Node: part,
+					Type: typ,
+				}
+			}
+
+			if !types.IsFormattable(typ) {
+				return types.NewTemplate([]types.Type{}), &errs.TypeError{
+					Err:  errs.ErrNotFormattable,
+					Node: part,
+					Type: typ,
+				}
+			}
+			in = append(in, types.Default(typ))
 		}
 
 		return types.NewTemplate(in), nil
 	case *ast.NumberLitExpr:
-		isInt := expr.Value == float64(int(expr.Value))
-		if isInt {
-			return types.Int, nil
-		}
-		return types.F64, nil
+		// Literals written without a fraction are ints, 2.0 is a float
+		float := expr.IsFloat || expr.Value != math.Trunc(expr.Value)
+		return types.NewUntyped(expr.Value, float), nil
 	default:
 		return types.Invalid, &errs.TypeError{
 			Err:   errs.ErrInvalidType,
@@ -362,6 +546,63 @@ func (s Scope) ResolveExpr(expr ast.Expr) (types.Type, error) {
 	}
 }
 
+func (s Scope) resolveConversion(conv *types.Conversion, expr *ast.CallExpr) (types.Type, error) {
+	if len(expr.Args) != 1 {
+		e := errs.ErrTooManyArguments
+		if len(expr.Args) == 0 {
+			e = errs.ErrTooFewArguments
+		}
+
+		return conv.Target, &errs.TypeError{
+			Err:  e,
+			Node: expr,
+			Type: conv,
+			N:    1,
+			M:    len(expr.Args),
+		}
+	}
+
+	s.ctx.Push(CONST)
+	typ, err := s.ResolveExpr(expr.Args[0])
+	s.ctx.Pop()
+	if err != nil {
+		return conv.Target, err
+	}
+
+	if !conv.Accepts(typ) {
+		if err := overflow(expr.Args[0], conv.Target, typ); err != nil {
+			return conv.Target, err
+		}
+
+		return conv.Target, &errs.TypeError{
+			Err:   errs.ErrCannotUseType,
+			Node:  expr.Args[0],
+			Left:  typ,
+			Right: conv.Target,
+		}
+	}
+
+	return conv.Target, nil
+}
+
+// overflow returns an error if one of the operands is an untyped constant
+// that the other one cannot represent.
+func overflow(node ast.Node, left, right types.Type) error {
+	target, value := left, right
+	if types.Overflows(right, left) {
+		target, value = right, left
+	} else if !types.Overflows(left, right) {
+		return nil
+	}
+
+	return &errs.TypeError{
+		Err:   errs.ErrConstantOverflow,
+		Node:  node,
+		Type:  target,
+		Value: types.FormatConstant(types.Unrepresentable(target, value.(*types.Untyped))),
+	}
+}
+
 func (s Scope) lookup(name string) (types.Type, bool) {
 	// If parent is not empty, check the local definitions first
 	if s.parent != nil {
@@ -392,12 +633,28 @@ func NewScope() *Scope {
 		objects:    make(map[string]types.Type),
 		importDefs: make(map[string]*ast.IdentExpr),
 		fnDefs:     make(map[string]*ast.FnDefStmt),
+		types:      make(map[ast.Expr]types.Type),
 
 		ctx: internal.NewStack(CONST),
 
 		builtin: map[string]types.Type{
 			"true":  types.Bool,
 			"false": types.Bool,
+
+			"i8":   types.NewConversion(types.I8),
+			"i16":  types.NewConversion(types.I16),
+			"i32":  types.NewConversion(types.I32),
+			"i64":  types.NewConversion(types.I64),
+			"u8":   types.NewConversion(types.U8),
+			"u16":  types.NewConversion(types.U16),
+			"u32":  types.NewConversion(types.U32),
+			"u64":  types.NewConversion(types.U64),
+			"f32":  types.NewConversion(types.F32),
+			"f64":  types.NewConversion(types.F64),
+			"int":  types.NewConversion(types.Int),
+			"uint": types.NewConversion(types.Uint),
+			"byte": types.NewConversion(types.Byte),
+			"rune": types.NewConversion(types.Rune),
 		},
 	}
 }
@@ -409,6 +666,7 @@ func NewSubScope(parent *Scope) *Scope {
 		builtin:    make(map[string]types.Type),
 		importDefs: make(map[string]*ast.IdentExpr),
 		fnDefs:     make(map[string]*ast.FnDefStmt),
+		types:      parent.types,
 
 		ctx:    internal.NewStack(CONST),
 		parent: parent,
@@ -84,11 +84,35 @@ func TestScope(t *testing.T) {
 		),
 	)
 
+	scope.DefineBuiltin("greet", &types.Fn{
+		In:  []types.Type{types.NewStruct(types.NewPair(0, "name", types.String))},
+		Out: types.String,
+	})
+
+	scope.Define("small", types.U8)
+	scope.Define("ratio", types.F32)
+
+	scope.Define("counts", types.NewMap(types.String, types.Int))
+	scope.Define("nickname", types.NewOptional(types.String))
+	scope.Define("tags", types.NewList(types.String))
+	scope.Define("profile",
+		types.NewOptional(types.NewStruct(
+			types.NewPair(0, "bio", types.String),
+		)),
+	)
+
 	tests := []test.Injector[*pkg.Scope]{
 		&RegisterCase{
 			In:  duplicate,
 			Err: errs.ErrDuplicateDefinition,
 		},
+		&RegisterCase{
+			In: &ast.FnDefStmt{
+				Name: &ast.IdentExpr{Value: "f64"},
+				Body: &ast.NumberLitExpr{Value: 0},
+			},
+			Err: errs.ErrBuiltinOverride,
+		},
 		&RegisterCase{
 			In: &ast.FnDefStmt{
 				Name:   &ast.IdentExpr{Value: "Undefined"},
@@ -283,7 +307,7 @@ func TestScope(t *testing.T) {
 			In: &ast.GroupExpr{
 				Expr: &ast.NumberLitExpr{Value: 3.1},
 			},
-			Out: types.F64,
+			Out: types.NewUntyped(3.1, true),
 		},
 		&ResolveCase{
 			In: &ast.GroupExpr{
@@ -302,7 +326,6 @@ func TestScope(t *testing.T) {
 				Right:    &ast.NumberLitExpr{Value: 5.5},
 			},
 			Out: types.Bool,
-			Err: errs.ErrNotComparable,
 		},
 		&ResolveCase{
 			In: &ast.TernaryExpr{
@@ -338,6 +361,563 @@ func TestScope(t *testing.T) {
 			},
 			Out: types.String,
 		},
+		&ResolveCase{
+			In: &ast.CallExpr{
+				Fn:   &ast.IdentExpr{Value: "greet"},
+				Args: []ast.Expr{&ast.IdentExpr{Value: "user"}},
+			},
+			Out: types.String,
+		},
+		&ResolveCase{
+			In: &ast.CallExpr{
+				Fn:   &ast.IdentExpr{Value: "greet"},
+				Args: []ast.Expr{&ast.IdentExpr{Value: "age"}},
+			},
+			Out: types.String,
+			Err: errs.ErrInvalidType,
+		},
+		&ResolveCase{
+			In: &ast.IndexExpr{
+				Host:  &ast.IdentExpr{Value: "counts"},
+				Index: &ast.StringLitExpr{Value: "apples"},
+			},
+			Out: types.Int,
+		},
+		&ResolveCase{
+			In: &ast.IndexExpr{
+				Host:  &ast.IdentExpr{Value: "counts"},
+				Index: &ast.NumberLitExpr{Value: 0},
+			},
+			Out: types.Invalid,
+			Err: errs.ErrInvalidIndex,
+		},
+		&ResolveCase{
+			In: &ast.MemberExpr{
+				Left:  &ast.IdentExpr{Value: "counts"},
+				Right: &ast.IdentExpr{Value: "apples"},
+			},
+			Out: types.Invalid,
+			Err: errs.ErrInvalidIndex,
+		},
+		&ResolveCase{
+			In: &ast.CoalesceExpr{
+				Left:  &ast.IdentExpr{Value: "nickname"},
+				Right: &ast.StringLitExpr{Value: "anonymous"},
+			},
+			Out: types.String,
+		},
+		&ResolveCase{
+			In: &ast.CoalesceExpr{
+				Left: &ast.MemberExpr{
+					Left:  &ast.IdentExpr{Value: "profile"},
+					Right: &ast.IdentExpr{Value: "bio"},
+				},
+				Right: &ast.IdentExpr{Value: "nickname"},
+			},
+			Out: types.NewOptional(types.String),
+		},
+		&ResolveCase{
+			In: &ast.CoalesceExpr{
+				Left:  &ast.IdentExpr{Value: "age"},
+				Right: &ast.NumberLitExpr{Value: 0},
+			},
+			Out: types.Int,
+			Err: errs.ErrNotOptional,
+		},
+		&ResolveCase{
+			In: &ast.CoalesceExpr{
+				Left:  &ast.IdentExpr{Value: "nickname"},
+				Right: &ast.NumberLitExpr{Value: 0},
+			},
+			Out: types.String,
+			Err: errs.ErrMultipleTypes,
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.LT},
+				Left:     &ast.IdentExpr{Value: "true"},
+				Right:    &ast.IdentExpr{Value: "false"},
+			},
+			Out: types.Bool,
+			Err: errs.ErrInvalidOperation,
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.AND},
+				Left:     &ast.IdentExpr{Value: "age"},
+				Right:    &ast.NumberLitExpr{Value: 1},
+			},
+			Out: types.Bool,
+			Err: errs.ErrInvalidOperation,
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.GTE},
+				Left:     &ast.IdentExpr{Value: "newstr"},
+				Right:    &ast.StringLitExpr{Value: "a"},
+			},
+			Out: types.Bool,
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.EQUALS},
+				Left:     &ast.IdentExpr{Value: "tags"},
+				Right:    &ast.IdentExpr{Value: "tags"},
+			},
+			Out: types.Bool,
+			Err: errs.ErrNotComparable,
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.EQUALS},
+				Left:     &ast.IdentExpr{Value: "nickname"},
+				Right:    &ast.StringLitExpr{Value: "anonymous"},
+			},
+			Out: types.Bool,
+			Err: errs.ErrOptionalValue,
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.LT},
+				Left:     &ast.StringLitExpr{Value: "a"},
+				Right:    &ast.IdentExpr{Value: "nickname"},
+			},
+			Out: types.Bool,
+			Err: errs.ErrOptionalValue,
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.EQUALS},
+				Left: &ast.CoalesceExpr{
+					Left:  &ast.IdentExpr{Value: "nickname"},
+					Right: &ast.StringLitExpr{Value: "anonymous"},
+				},
+				Right: &ast.StringLitExpr{Value: "anonymous"},
+			},
+			Out: types.Bool,
+		},
+		&ResolveCase{
+			In: &ast.TemplateLitExpr{
+				Value: []ast.Expr{
+					&ast.StringLitExpr{Value: "hello "},
+					&ast.IdentExpr{Value: "nickname"},
+				},
+			},
+			Out: types.NewTemplate([]types.Type{}),
+			Err: errs.ErrOptionalValue,
+		},
+		&ResolveCase{
+			In: &ast.TemplateLitExpr{
+				Value: []ast.Expr{
+					&ast.StringLitExpr{Value: "hello "},
+					&ast.CoalesceExpr{
+						Left:  &ast.IdentExpr{Value: "nickname"},
+						Right: &ast.StringLitExpr{Value: "there"},
+					},
+				},
+			},
+			Out: types.NewTemplate([]types.Type{types.String, types.String}),
+		},
+		&ResolveCase{
+			In: &ast.TemplateLitExpr{
+				Value: []ast.Expr{
+					&ast.StringLitExpr{Value: "tags "},
+					&ast.IdentExpr{Value: "tags"},
+				},
+			},
+			Out: types.NewTemplate([]types.Type{}),
+			Err: errs.ErrNotFormattable,
+		},
+		&ResolveCase{
+			In:  &ast.TemplateLitExpr{Value: []ast.Expr{&ast.IdentExpr{Value: "user"}}},
+			Out: types.NewTemplate([]types.Type{}),
+			Err: errs.ErrNotFormattable,
+		},
+		&ResolveCase{
+			In:  &ast.TemplateLitExpr{Value: []ast.Expr{&ast.IdentExpr{Value: "counts"}}},
+			Out: types.NewTemplate([]types.Type{}),
+			Err: errs.ErrNotFormattable,
+		},
+		&ResolveCase{
+			In:  &ast.TemplateLitExpr{Value: []ast.Expr{&ast.IdentExpr{Value: "newstr"}}},
+			Out: types.NewTemplate([]types.Type{types.New("str", types.NewList(types.Rune))}),
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.GT},
+				Left:     &ast.IdentExpr{Value: "small"},
+				Right:    &ast.NumberLitExpr{Value: 18},
+			},
+			Out: types.Bool,
+		},
+		&ResolveCase{
+			In: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.GT},
+				Left:     &ast.NumberLitExpr{Value: 300},
+				Right:    &ast.IdentExpr{Value: "small"},
+			},
+			Out: types.Bool,
+			Err: errs.ErrConstantOverflow,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PLUS},
+				Left:     &ast.NumberLitExpr{Value: 1},
+				Right:    &ast.IdentExpr{Value: "small"},
+			},
+			Out: types.U8,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.STAR},
+				Left:     &ast.IdentExpr{Value: "ratio"},
+				Right:    &ast.NumberLitExpr{Value: 2.5},
+			},
+			Out: types.F32,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.STAR},
+				Left:     &ast.IdentExpr{Value: "small"},
+				Right:    &ast.NumberLitExpr{Value: 2.5},
+			},
+			Out: types.U8,
+			Err: errs.ErrConstantOverflow,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PLUS},
+				Left:     &ast.IdentExpr{Value: "small"},
+				Right:    &ast.IdentExpr{Value: "age"},
+			},
+			Out: types.U8,
+			Err: errs.ErrNotOperable,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.FORWARD_SLASH},
+				Left:     &ast.NumberLitExpr{Value: 7},
+				Right:    &ast.NumberLitExpr{Value: 2},
+			},
+			Out: types.NewUntyped(3, false),
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.FORWARD_SLASH},
+				Left:     &ast.NumberLitExpr{Value: 7},
+				Right:    &ast.NumberLitExpr{Value: 0},
+			},
+			Out: types.NewUntyped(7, false),
+			Err: errs.ErrDivisionByZero,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.FORWARD_SLASH},
+				Left:     &ast.IdentExpr{Value: "age"},
+				Right:    &ast.NumberLitExpr{Value: 0},
+			},
+			Out: types.Int,
+			Err: errs.ErrDivisionByZero,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PERCENT},
+				Left:     &ast.IdentExpr{Value: "age"},
+				Right:    &ast.NumberLitExpr{Value: 0},
+			},
+			Out: types.Int,
+			Err: errs.ErrDivisionByZero,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.FORWARD_SLASH},
+				Left:     &ast.IdentExpr{Value: "ratio"},
+				Right:    &ast.NumberLitExpr{Value: 0, IsFloat: true},
+			},
+			Out: types.F32,
+			Err: errs.ErrDivisionByZero,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PLUS},
+				Left:     &ast.IdentExpr{Value: "small"},
+				Right: &ast.CallExpr{
+					Fn:   &ast.IdentExpr{Value: "u8"},
+					Args: []ast.Expr{&ast.IdentExpr{Value: "age"}},
+				},
+			},
+			Out: types.U8,
+		},
+		&ResolveCase{
+			In: &ast.CallExpr{
+				Fn:   &ast.IdentExpr{Value: "f64"},
+				Args: []ast.Expr{&ast.IdentExpr{Value: "small"}},
+			},
+			Out: types.F64,
+		},
+		&ResolveCase{
+			In: &ast.CallExpr{
+				Fn:   &ast.IdentExpr{Value: "int"},
+				Args: []ast.Expr{&ast.NumberLitExpr{Value: 3.5}},
+			},
+			Out: types.Int,
+			Err: errs.ErrConstantOverflow,
+		},
+		&ResolveCase{
+			In: &ast.CallExpr{
+				Fn:   &ast.IdentExpr{Value: "int"},
+				Args: []ast.Expr{&ast.StringLitExpr{Value: "3"}},
+			},
+			Out: types.Int,
+			Err: errs.ErrCannotUseType,
+		},
+		&ResolveCase{
+			In: &ast.CallExpr{
+				Fn: &ast.IdentExpr{Value: "u8"},
+				Args: []ast.Expr{&ast.TernaryExpr{
+					Predicate: &ast.IdentExpr{Value: "true"},
+					Left:      &ast.NumberLitExpr{Value: 1},
+					Right:     &ast.NumberLitExpr{Value: 300},
+				}},
+			},
+			Out: types.U8,
+			Err: errs.ErrConstantOverflow,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PLUS},
+				Left:     &ast.IdentExpr{Value: "small"},
+				Right: &ast.TernaryExpr{
+					Predicate: &ast.IdentExpr{Value: "true"},
+					Left:      &ast.NumberLitExpr{Value: 300},
+					Right:     &ast.NumberLitExpr{Value: 1},
+				},
+			},
+			Out: types.U8,
+			Err: errs.ErrConstantOverflow,
+		},
+		&ResolveCase{
+			In: &ast.CallExpr{
+				Fn:   &ast.IdentExpr{Value: "int"},
+				Args: []ast.Expr{},
+			},
+			Out: types.Int,
+			Err: errs.ErrTooFewArguments,
+		},
+		&ResolveCase{
+			In: &ast.CallExpr{
+				Fn:   &ast.IdentExpr{Value: "itoa"},
+				Args: []ast.Expr{&ast.NumberLitExpr{Value: 3000000000}},
+			},
+			Out: types.String,
+			Err: errs.ErrConstantOverflow,
+		},
+		&ResolveCase{
+			In: &ast.TernaryExpr{
+				Predicate: &ast.IdentExpr{Value: "true"},
+				Left:      &ast.NumberLitExpr{Value: 1},
+				Right:     &ast.IdentExpr{Value: "small"},
+			},
+			Out: types.U8,
+		},
+		&ResolveCase{
+			In: &ast.TernaryExpr{
+				Predicate: &ast.IdentExpr{Value: "true"},
+				Left:      &ast.StringLitExpr{Value: "anonymous"},
+				Right:     &ast.IdentExpr{Value: "nickname"},
+			},
+			Out: types.NewOptional(types.String),
+		},
+		&ResolveCase{
+			In: &ast.TemplateLitExpr{
+				Value: []ast.Expr{
+					&ast.NumberLitExpr{Value: 1},
+					&ast.NumberLitExpr{Value: 1.5},
+				},
+			},
+			Out: types.NewTemplate([]types.Type{types.Int, types.F64}),
+		},
+		&ResolveCase{
+			In: &ast.UnaryExpr{
+				Operator: token.Token{Kind: token.EXCLAMATION_MARK},
+				Expr:     &ast.IdentExpr{Value: "true"},
+			},
+			Out: types.Bool,
+		},
+		&ResolveCase{
+			In: &ast.UnaryExpr{
+				Operator: token.Token{Kind: token.EXCLAMATION_MARK},
+				Expr:     &ast.IdentExpr{Value: "age"},
+			},
+			Out: types.Int,
+			Err: errs.ErrInvalidOperation,
+		},
+		&ResolveCase{
+			In: &ast.UnaryExpr{
+				Operator: token.Token{Kind: token.MINUS},
+				Expr:     &ast.IdentExpr{Value: "small"},
+			},
+			Out: types.U8,
+		},
+		&ResolveCase{
+			In: &ast.UnaryExpr{
+				Operator: token.Token{Kind: token.MINUS},
+				Expr:     &ast.NumberLitExpr{Value: 2.5},
+			},
+			Out: types.NewUntyped(-2.5, true),
+		},
+		&ResolveCase{
+			In: &ast.UnaryExpr{
+				Operator: token.Token{Kind: token.MINUS},
+				Expr:     &ast.StringLitExpr{Value: ""},
+			},
+			Out: types.String,
+			Err: errs.ErrInvalidOperation,
+		},
+		&ResolveCase{
+			In:  &ast.NumberLitExpr{Value: 2, IsFloat: true},
+			Out: types.NewUntyped(2, true),
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.FORWARD_SLASH},
+				Left:     &ast.NumberLitExpr{Value: 7},
+				Right:    &ast.NumberLitExpr{Value: 2, IsFloat: true},
+			},
+			Out: types.NewUntyped(3.5, true),
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.FORWARD_SLASH},
+				Left:     &ast.NumberLitExpr{Value: 7, IsFloat: true},
+				Right:    &ast.NumberLitExpr{Value: 2},
+			},
+			Out: types.NewUntyped(3.5, true),
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.FORWARD_SLASH},
+				Left:     &ast.NumberLitExpr{Value: 7},
+				Right:    &ast.NumberLitExpr{Value: 2},
+			},
+			Out: types.NewUntyped(3, false),
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PERCENT},
+				Left:     &ast.IdentExpr{Value: "age"},
+				Right:    &ast.NumberLitExpr{Value: 3},
+			},
+			Out: types.Int,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PERCENT},
+				Left:     &ast.IdentExpr{Value: "ratio"},
+				Right:    &ast.NumberLitExpr{Value: 3},
+			},
+			Out: types.F32,
+			Err: errs.ErrInvalidOperation,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PERCENT},
+				Left:     &ast.NumberLitExpr{Value: 7.5},
+				Right:    &ast.NumberLitExpr{Value: 2},
+			},
+			Out: types.NewUntyped(7.5, true),
+			Err: errs.ErrInvalidOperation,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PERCENT},
+				Left:     &ast.NumberLitExpr{Value: 7},
+				Right:    &ast.NumberLitExpr{Value: 3},
+			},
+			Out: types.NewUntyped(1, false),
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PLUS},
+				Left:     &ast.StringLitExpr{Value: "a"},
+				Right:    &ast.StringLitExpr{Value: "b"},
+			},
+			Out: types.String,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PLUS},
+				Left:     &ast.StringLitExpr{Value: "a"},
+				Right:    &ast.IdentExpr{Value: "newstr"},
+			},
+			Out: types.String,
+			Err: errs.ErrNotOperable,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.MINUS},
+				Left:     &ast.StringLitExpr{Value: "a"},
+				Right:    &ast.StringLitExpr{Value: "b"},
+			},
+			Out: types.String,
+			Err: errs.ErrNotOperable,
+		},
+		&ResolveCase{
+			In: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PLUS},
+				Left:     &ast.StringLitExpr{Value: "a"},
+				Right:    &ast.NumberLitExpr{Value: 1},
+			},
+			Out: types.String,
+			Err: errs.ErrNotOperable,
+		},
 	}
 	test.RunWith(t, tests, scope)
 }
+
+func TestTypes(t *testing.T) {
+	assert := assert.New(t)
+	scope := pkg.NewScope()
+	scope.Define("name", types.String)
+	sub := pkg.NewSubScope(scope)
+	sub.Define("count", types.Int)
+
+	count := &ast.IdentExpr{Value: "count"}
+	name := &ast.IdentExpr{Value: "name"}
+	expr := &ast.TernaryExpr{
+		Predicate: &ast.BinaryExpr{
+			Operator: token.Token{Kind: token.GT},
+			Left:     count,
+			Right:    &ast.NumberLitExpr{Value: 1},
+		},
+		Left:  name,
+		Right: &ast.StringLitExpr{Value: "someone"},
+	}
+
+	_, err := sub.ResolveExpr(expr)
+	assert.NoError(err)
+	assert.Equal(types.String, scope.Types()[expr])
+	assert.Equal(types.Bool, scope.Types()[expr.Predicate])
+	assert.Equal(types.Int, scope.Types()[count])
+	assert.Equal(types.String, scope.Types()[name])
+}
+
+func TestModulo(t *testing.T) {
+	assert := assert.New(t)
+	scope := pkg.NewScope()
+	scope.Define("ratio", types.F64)
+
+	_, err := scope.ResolveExpr(&ast.ArithmeticExpr{
+		Operator: token.Token{Kind: token.PERCENT},
+		Left:     &ast.NumberLitExpr{Value: 7.5},
+		Right:    &ast.NumberLitExpr{Value: 2},
+	})
+	assert.EqualError(err, "type error: invalid operation, operator % is not defined on untyped float")
+
+	_, err = scope.ResolveExpr(&ast.ArithmeticExpr{
+		Operator: token.Token{Kind: token.PERCENT},
+		Left:     &ast.NumberLitExpr{Value: 7},
+		Right:    &ast.IdentExpr{Value: "ratio"},
+	})
+	assert.EqualError(err, "type error: invalid operation, operator % is not defined on f64")
+}
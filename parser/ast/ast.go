@@ -95,19 +95,21 @@ type Entry interface {
 const KeyEntryNode = "key_entry"
 
 type KeyEntry struct {
-	Node   `json:"node"`
-	Name   *IdentExpr `json:"name"`
-	Fields []*Field   `json:"fields"`
+	Node     `json:"node"`
+	Name     *IdentExpr     `json:"name"`
+	Fields   []*Field       `json:"fields"`
+	Comments []*CommentStmt `json:"comments"`
 }
 
 const TemplateEntryNode = "template_entry"
 
 type TemplateEntry struct {
 	Node        `json:"node"`
-	Partitioned bool        `json:"partitioned"`
-	Name        *IdentExpr  `json:"name"`
-	Fields      []*Field    `json:"fields"`
-	Params      []*TypePair `json:"params"`
+	Partitioned bool           `json:"partitioned"`
+	Name        *IdentExpr     `json:"name"`
+	Fields      []*Field       `json:"fields"`
+	Params      []*TypePair    `json:"params"`
+	Comments    []*CommentStmt `json:"comments"`
 }
 
 const EmptyEntryNode = "empty_entry"
@@ -157,6 +159,14 @@ type ArithmeticExpr struct {
 	Right    Expr        `json:"right"`
 }
 
+const UnaryExprNode = "unary_expr"
+
+type UnaryExpr struct {
+	Node     `json:"node"`
+	Operator token.Token `json:"operator"`
+	Expr     Expr        `json:"expr"`
+}
+
 const TernaryExprNode = "ternary_expr"
 
 type TernaryExpr struct {
@@ -166,6 +176,14 @@ type TernaryExpr struct {
 	Right     Expr `json:"right"`
 }
 
+const CoalesceExprNode = "coalesce_expr"
+
+type CoalesceExpr struct {
+	Node  `json:"node"`
+	Left  Expr `json:"left"`
+	Right Expr `json:"right"`
+}
+
 const CallExprNode = "call_expr"
 
 type CallExpr struct {
@@ -228,9 +246,12 @@ type TemplateLitExpr struct {
 
 const NumberLitExprNode = "number_literal_expr"
 
+// NumberLitExpr is a number literal, IsFloat is set if it is written with a
+// fraction even if its value is whole.
 type NumberLitExpr struct {
-	Node  `json:"node"`
-	Value float64 `json:"value"`
+	Node    `json:"node"`
+	Value   float64 `json:"value"`
+	IsFloat bool    `json:"is_float"`
 }
 
 const EmptyExprNode = "empty_expr"
@@ -246,6 +267,21 @@ type ListTypeExpr struct {
 	Type TypeExpr `json:"type"`
 }
 
+const MapTypeExprNode = "map_type_expr"
+
+type MapTypeExpr struct {
+	Node  `json:"node"`
+	Key   TypeExpr `json:"key"`
+	Value TypeExpr `json:"value"`
+}
+
+const OptionalTypeExprNode = "optional_type_expr"
+
+type OptionalTypeExpr struct {
+	Node `json:"node"`
+	Type TypeExpr `json:"type"`
+}
+
 const StructLitExprNode = "struct_literal_expr"
 
 type StructLitExpr struct {
@@ -264,7 +300,9 @@ type TypePair struct {
 
 func (e *BinaryExpr) exprNode()      {}
 func (e *ArithmeticExpr) exprNode()  {}
+func (e *UnaryExpr) exprNode()       {}
 func (e *TernaryExpr) exprNode()     {}
+func (e *CoalesceExpr) exprNode()    {}
 func (e *CallExpr) exprNode()        {}
 func (e *MemberExpr) exprNode()      {}
 func (e *ImportExpr) exprNode()      {}
@@ -276,8 +314,10 @@ func (e *TemplateLitExpr) exprNode() {}
 func (e *NumberLitExpr) exprNode()   {}
 func (e *EmptyExpr) exprNode()       {}
 
-func (e *IdentExpr) tExprNode()     {}
-func (e *ImportExpr) tExprNode()    {}
-func (e *ListTypeExpr) tExprNode()  {}
-func (e *StructLitExpr) tExprNode() {}
-func (e *EmptyExpr) tExprNode()     {}
+func (e *IdentExpr) tExprNode()        {}
+func (e *ImportExpr) tExprNode()       {}
+func (e *ListTypeExpr) tExprNode()     {}
+func (e *MapTypeExpr) tExprNode()      {}
+func (e *OptionalTypeExpr) tExprNode() {}
+func (e *StructLitExpr) tExprNode()    {}
+func (e *EmptyExpr) tExprNode()        {}
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/CanPacis/lcl/parser/token"
 )
@@ -81,3 +82,108 @@ func NewString(t token.Token) *StringLitExpr {
 		Value: t.Literal,
 	}
 }
+
+// NewText returns a literal of text, strings cannot span lines so text with
+// new lines is a template.
+func NewText(s string) Expr {
+	value := &StringLitExpr{
+		Node:  NewNode(StringLitExprNode, token.Position{}, token.Position{}),
+		Value: s,
+	}
+	if !strings.ContainsAny(s, "\n\r") {
+		return value
+	}
+
+	return &TemplateLitExpr{
+		Node:  NewNode(TemplateLitExprNode, token.Position{}, token.Position{}),
+		Value: []Expr{value},
+	}
+}
+
+// Entries returns the key and template entries of a file by their paths, the
+// names of their sections and their own name joined by dots.
+func Entries(file *File) map[string]Entry {
+	entries := map[string]Entry{}
+
+	var walk func(section *SectionStmt, prefix string)
+	walk = func(section *SectionStmt, prefix string) {
+		prefix += section.Name.Value + "."
+
+		for _, entry := range section.Body {
+			switch entry := entry.(type) {
+			case *KeyEntry:
+				entries[prefix+entry.Name.Value] = entry
+			case *TemplateEntry:
+				entries[prefix+entry.Name.Value] = entry
+			case *SectionStmt:
+				walk(entry, prefix)
+			}
+		}
+	}
+
+	for _, stmt := range file.Stmts {
+		if section, ok := stmt.(*SectionStmt); ok {
+			walk(section, "")
+		}
+	}
+
+	return entries
+}
+
+// GetField returns the value of the field of a key or a template entry for
+// tag, it is nil if the entry has none for it.
+func GetField(entry Entry, tag string) Expr {
+	var fields []*Field
+	switch entry := entry.(type) {
+	case *KeyEntry:
+		fields = entry.Fields
+	case *TemplateEntry:
+		fields = entry.Fields
+	}
+
+	for _, field := range fields {
+		if field.Tag.Value == tag {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+// SetField sets the value of the field of a key or a template entry for tag,
+// a field is added if the entry has none for it.
+func SetField(entry Entry, tag string, value Expr) {
+	var fields *[]*Field
+	switch entry := entry.(type) {
+	case *KeyEntry:
+		fields = &entry.Fields
+	case *TemplateEntry:
+		fields = &entry.Fields
+	default:
+		return
+	}
+
+	for _, field := range *fields {
+		if field.Tag.Value == tag {
+			field.Value = value
+			return
+		}
+	}
+
+	*fields = append(*fields, &Field{
+		Node:  NewNode(FieldNode, token.Position{}, token.Position{}),
+		Tag:   &IdentExpr{Node: NewNode(IdentExprNode, token.Position{}, token.Position{}), Value: tag},
+		Value: value,
+	})
+}
+
+// EntryComments returns the comments written above a key or a template entry
+func EntryComments(entry Entry) []*CommentStmt {
+	switch entry := entry.(type) {
+	case *KeyEntry:
+		return entry.Comments
+	case *TemplateEntry:
+		return entry.Comments
+	default:
+		return nil
+	}
+}
@@ -30,6 +30,18 @@ func CompareExpr(assert A, left, right ast.Expr) {
 		r := right.(*ast.TernaryExpr)
 
 		CompareExpr(assert, left.Predicate, r.Predicate)
+		CompareExpr(assert, left.Left, r.Left)
+		CompareExpr(assert, left.Right, r.Right)
+	case *ast.UnaryExpr:
+		assert.IsType(left, right)
+		r := right.(*ast.UnaryExpr)
+
+		assert.Equal(left.Operator.Kind, r.Operator.Kind)
+		CompareExpr(assert, left.Expr, r.Expr)
+	case *ast.CoalesceExpr:
+		assert.IsType(left, right)
+		r := right.(*ast.CoalesceExpr)
+
 		CompareExpr(assert, left.Left, r.Left)
 		CompareExpr(assert, left.Right, r.Right)
 	case *ast.CallExpr:
@@ -103,6 +115,7 @@ func CompareNumber(assert A, left *ast.NumberLitExpr, right ast.Expr) {
 	assert.IsType(left, right)
 	r := right.(*ast.NumberLitExpr)
 	assert.Equal(left.Value, r.Value)
+	assert.Equal(left.IsFloat, r.IsFloat)
 }
 
 func CompareTypeExpr(assert A, left ast.TypeExpr, right ast.TypeExpr) {
@@ -121,6 +134,15 @@ func CompareTypeExpr(assert A, left ast.TypeExpr, right ast.TypeExpr) {
 		assert.IsType(left, right)
 		r := right.(*ast.ListTypeExpr)
 		CompareTypeExpr(assert, left.Type, r.Type)
+	case *ast.MapTypeExpr:
+		assert.IsType(left, right)
+		r := right.(*ast.MapTypeExpr)
+		CompareTypeExpr(assert, left.Key, r.Key)
+		CompareTypeExpr(assert, left.Value, r.Value)
+	case *ast.OptionalTypeExpr:
+		assert.IsType(left, right)
+		r := right.(*ast.OptionalTypeExpr)
+		CompareTypeExpr(assert, left.Type, r.Type)
 	case *ast.StructLitExpr:
 		assert.IsType(left, right)
 		r := right.(*ast.StructLitExpr)
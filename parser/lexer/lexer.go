@@ -21,6 +21,7 @@ var (
 		"fn":      token.FN,
 		"type":    token.TYPE,
 		"section": token.SECTION,
+		"map":     token.MAP,
 	}
 
 	special = []rune{
@@ -37,6 +38,12 @@ var (
 	}
 )
 
+// IsKeyword reports whether s is a keyword, keywords cannot be identifiers
+func IsKeyword(s string) bool {
+	_, ok := keywords[s]
+	return ok
+}
+
 type Lexer struct {
 	input *bufio.Reader
 
@@ -208,7 +215,13 @@ func (l *Lexer) lexSpecial() token.Token {
 		}
 	case '?':
 		l.advance()
-		tk = l.token(token.QUESTION_MARK)
+
+		if l.current == '?' {
+			l.advance()
+			tk = l.token(token.DOUBLE_QUESTION_MARK)
+		} else {
+			tk = l.token(token.QUESTION_MARK)
+		}
 	case '=':
 		l.advance()
 
@@ -222,6 +235,8 @@ func (l *Lexer) lexSpecial() token.Token {
 		if l.current == '=' {
 			l.advance()
 			tk = l.token(token.NOT_EQUALS)
+		} else {
+			tk = l.token(token.EXCLAMATION_MARK)
 		}
 	case '&':
 		l.advance()
@@ -121,6 +121,27 @@ func TestSpecialChars(t *testing.T) {
 				Exp(token.CARET, "^", 1, 49),
 			},
 		},
+		{
+			skipsWhitespace: true,
+			Input:           "! !! != !",
+			Expected: []Expectation{
+				Exp(token.EXCLAMATION_MARK, "!", 1, 1),
+				Exp(token.EXCLAMATION_MARK, "!", 1, 3),
+				Exp(token.EXCLAMATION_MARK, "!", 1, 4),
+				Exp(token.NOT_EQUALS, "!=", 1, 6),
+				Exp(token.EXCLAMATION_MARK, "!", 1, 9),
+			},
+		},
+		{
+			skipsWhitespace: true,
+			Input:           "?? ??? ?",
+			Expected: []Expectation{
+				Exp(token.DOUBLE_QUESTION_MARK, "??", 1, 1),
+				Exp(token.DOUBLE_QUESTION_MARK, "??", 1, 4),
+				Exp(token.QUESTION_MARK, "?", 1, 6),
+				Exp(token.QUESTION_MARK, "?", 1, 8),
+			},
+		},
 	}
 
 	tests.Run(t)
@@ -142,6 +163,14 @@ func TestAlphanumeric(t *testing.T) {
 				Exp(token.AS, "as", 1, 50),
 			},
 		},
+		{
+			skipsWhitespace: true,
+			Input:           "map mapping",
+			Expected: []Expectation{
+				Exp(token.MAP, "map", 1, 1),
+				Exp(token.IDENT, "mapping", 1, 5),
+			},
+		},
 	}
 
 	tests.Run(t)
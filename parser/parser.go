@@ -23,6 +23,9 @@ type Parser struct {
 
 	errors []error
 	ctx    *internal.Stack[Context]
+
+	// comments are the comments skipped since the last statement or entry
+	comments []*ast.CommentStmt
 }
 
 func (p *Parser) advance() token.Token {
@@ -40,10 +43,36 @@ func (p *Parser) advance() token.Token {
 
 func (p *Parser) skip() {
 	for p.current.Kind == token.WHITESPACE || p.current.Kind == token.COMMENT {
+		if p.current.Kind == token.COMMENT {
+			p.comments = append(p.comments, &ast.CommentStmt{
+				Stmt:    ast.NewStmtNode(ast.CommentStmtNode, p.current.Start, p.current.End),
+				Literal: p.current.Literal,
+				Raw:     p.current.Raw,
+			})
+		}
 		p.advance()
 	}
 }
 
+// leading returns the comments on the lines right above start, the other
+// skipped comments are dropped
+func (p *Parser) leading(start token.Position) []*ast.CommentStmt {
+	list := []*ast.CommentStmt{}
+	line := start.Line
+
+	for i := len(p.comments) - 1; i >= 0; i-- {
+		comment := p.comments[i]
+		if comment.Range().Start.Line != line-1 {
+			break
+		}
+		list = append([]*ast.CommentStmt{comment}, list...)
+		line--
+	}
+
+	p.comments = nil
+	return list
+}
+
 func (p *Parser) error(err error) {
 	if err != nil {
 		p.errors = append(p.errors, err)
@@ -165,6 +194,7 @@ func (p *Parser) parseStmt() ast.Stmt {
 }
 
 func (p *Parser) parseDeclStmt() *ast.DeclStmt {
+	comments := p.leading(p.current.Start)
 	start := p.expect(token.DECLARE)
 	p.skip()
 	name := p.parseIdentExpr()
@@ -197,13 +227,14 @@ func (p *Parser) parseDeclStmt() *ast.DeclStmt {
 	}
 
 	return &ast.DeclStmt{
-		Stmt:    ast.NewStmtNode(ast.DeclStmtNode, start.Start, p.current.End),
+		Stmt:    ast.NewStmtNode(ast.DeclStmtNode, start.Start, p.current.End, comments...),
 		Name:    name,
 		Targets: targets,
 	}
 }
 
 func (p *Parser) parseImportStmt() *ast.ImportStmt {
+	comments := p.leading(p.current.Start)
 	start := p.expect(token.IMPORT)
 	p.skip()
 
@@ -213,7 +244,7 @@ func (p *Parser) parseImportStmt() *ast.ImportStmt {
 		list = append(list, p.parseIdentExpr())
 
 		return &ast.ImportStmt{
-			Stmt: ast.NewStmtNode(ast.ImportStmtNode, start.Start, p.current.End),
+			Stmt: ast.NewStmtNode(ast.ImportStmtNode, start.Start, p.current.End, comments...),
 			List: list,
 		}
 	}
@@ -223,12 +254,13 @@ func (p *Parser) parseImportStmt() *ast.ImportStmt {
 	}
 
 	return &ast.ImportStmt{
-		Stmt: ast.NewStmtNode(ast.ImportStmtNode, start.Start, p.current.End),
+		Stmt: ast.NewStmtNode(ast.ImportStmtNode, start.Start, p.current.End, comments...),
 		List: list,
 	}
 }
 
 func (p *Parser) parseTypeDefStmt() *ast.TypeDefStmt {
+	comments := p.leading(p.current.Start)
 	start := p.expect(token.TYPE)
 	p.skip()
 	name := p.parseIdentExpr()
@@ -236,13 +268,14 @@ func (p *Parser) parseTypeDefStmt() *ast.TypeDefStmt {
 	typ := p.parseTypeExpr()
 
 	return &ast.TypeDefStmt{
-		Stmt: ast.NewStmtNode(ast.TypeDefStmtNode, start.Start, typ.Range().End),
+		Stmt: ast.NewStmtNode(ast.TypeDefStmtNode, start.Start, typ.Range().End, comments...),
 		Name: name,
 		Type: typ,
 	}
 }
 
 func (p *Parser) parseFnDefStmt() *ast.FnDefStmt {
+	comments := p.leading(p.current.Start)
 	start := p.expect(token.FN)
 
 	params := []*ast.TypePair{}
@@ -256,7 +289,7 @@ func (p *Parser) parseFnDefStmt() *ast.FnDefStmt {
 	body := p.parseExpr()
 
 	return &ast.FnDefStmt{
-		Stmt:   ast.NewStmtNode(ast.FnDefStmtNode, start.Start, body.Range().End),
+		Stmt:   ast.NewStmtNode(ast.FnDefStmtNode, start.Start, body.Range().End, comments...),
 		Params: params,
 		Name:   name,
 		Body:   body,
@@ -264,6 +297,7 @@ func (p *Parser) parseFnDefStmt() *ast.FnDefStmt {
 }
 
 func (p *Parser) parseSectionStmt() *ast.SectionStmt {
+	comments := p.leading(p.current.Start)
 	start := p.expect(token.SECTION)
 	p.skip()
 	name := p.parseIdentExpr()
@@ -276,7 +310,7 @@ func (p *Parser) parseSectionStmt() *ast.SectionStmt {
 	}
 
 	return &ast.SectionStmt{
-		Stmt: ast.NewStmtNode(ast.SectionStmtNode, start.Start, p.current.End),
+		Stmt: ast.NewStmtNode(ast.SectionStmtNode, start.Start, p.current.End, comments...),
 		Name: name,
 		Body: list,
 	}
@@ -292,6 +326,7 @@ func (p *Parser) parseEntry() ast.Entry {
 		return p.parseSectionStmt()
 	}
 
+	comments := p.leading(p.current.Start)
 	isTemplate := false
 	isPartitioned := false
 	name := p.parseIdentExpr()
@@ -323,13 +358,15 @@ func (p *Parser) parseEntry() ast.Entry {
 			Name:        name,
 			Fields:      fields,
 			Params:      params,
+			Comments:    comments,
 		}
 	}
 
 	return &ast.KeyEntry{
-		Node:   ast.NewNode(ast.KeyEntryNode, name.Range().Start, p.current.End),
-		Name:   name,
-		Fields: fields,
+		Node:     ast.NewNode(ast.KeyEntryNode, name.Range().Start, p.current.End),
+		Name:     name,
+		Fields:   fields,
+		Comments: comments,
 	}
 }
 
@@ -360,7 +397,7 @@ func (p *Parser) parseExpr() ast.Expr {
 	p.ctx.Push(EXPRESSION)
 	defer p.ctx.Pop()
 
-	var expr ast.Expr = p.parseBinaryExpr()
+	var expr ast.Expr = p.parseCoalesceExpr()
 	p.skip()
 
 	for p.current.Kind == token.QUESTION_MARK {
@@ -383,6 +420,24 @@ func (p *Parser) parseExpr() ast.Expr {
 	return expr
 }
 
+func (p *Parser) parseCoalesceExpr() ast.Expr {
+	var expr ast.Expr = p.parseBinaryExpr()
+	p.skip()
+
+	for p.current.Kind == token.DOUBLE_QUESTION_MARK {
+		p.advance()
+		p.skip()
+		rhs := p.parseBinaryExpr()
+		expr = &ast.CoalesceExpr{
+			Node:  ast.NewNode(ast.CoalesceExprNode, expr.Range().Start, rhs.Range().End),
+			Left:  expr,
+			Right: rhs,
+		}
+	}
+
+	return expr
+}
+
 var operators = []token.Kind{
 	token.AND, token.OR,
 	token.EQUALS, token.NOT_EQUALS,
@@ -429,13 +484,13 @@ func (p *Parser) parseArithmeticExpr() ast.Expr {
 }
 
 func (p *Parser) parseArithmeticExpr2() ast.Expr {
-	var expr ast.Expr = p.parseExponentExpr()
+	var expr ast.Expr = p.parseUnaryExpr()
 	p.skip()
 
-	for p.current.Kind == token.STAR || p.current.Kind == token.FORWARD_SLASH {
+	for p.current.Kind == token.STAR || p.current.Kind == token.FORWARD_SLASH || p.current.Kind == token.PERCENT {
 		operator := p.advance()
 		p.skip()
-		rhs := p.parseExponentExpr()
+		rhs := p.parseUnaryExpr()
 		expr = &ast.ArithmeticExpr{
 			Node:     ast.NewNode(ast.ArithmeticExprNode, expr.Range().Start, rhs.Range().End),
 			Operator: operator,
@@ -447,6 +502,22 @@ func (p *Parser) parseArithmeticExpr2() ast.Expr {
 	return expr
 }
 
+func (p *Parser) parseUnaryExpr() ast.Expr {
+	if p.current.Kind != token.EXCLAMATION_MARK && p.current.Kind != token.MINUS {
+		return p.parseExponentExpr()
+	}
+
+	operator := p.advance()
+	p.skip()
+	expr := p.parseUnaryExpr()
+
+	return &ast.UnaryExpr{
+		Node:     ast.NewNode(ast.UnaryExprNode, operator.Start, expr.Range().End),
+		Operator: operator,
+		Expr:     expr,
+	}
+}
+
 func (p *Parser) parseExponentExpr() ast.Expr {
 	var expr ast.Expr = p.parseIndexExpr()
 	p.skip()
@@ -476,6 +547,8 @@ func (p *Parser) parseIndexExpr() ast.Expr {
 		expr = p.parseBasicExpr()
 	case token.LEFT_PARENS:
 		expr = p.parseGroupExpr()
+	default:
+		expr = p.parseBasicExpr()
 	}
 
 	p.skip()
@@ -647,8 +720,9 @@ func (p *Parser) parseNumberExpr() *ast.NumberLitExpr {
 	}
 
 	return &ast.NumberLitExpr{
-		Node:  ast.NewNode(ast.NumberLitExprNode, expr.Start, expr.End),
-		Value: value,
+		Node:    ast.NewNode(ast.NumberLitExprNode, expr.Start, expr.End),
+		Value:   value,
+		IsFloat: strings.Contains(expr.Literal, "."),
 	}
 }
 
@@ -668,7 +742,17 @@ func (p *Parser) parseTypeExpr() ast.TypeExpr {
 
 	var expr ast.TypeExpr = p.parseStructExpr()
 
-	for p.current.Kind == token.LEFT_SQUARE_BRACKET {
+	for p.current.Kind == token.LEFT_SQUARE_BRACKET || p.current.Kind == token.QUESTION_MARK {
+		if p.current.Kind == token.QUESTION_MARK {
+			end := p.advance()
+
+			expr = &ast.OptionalTypeExpr{
+				Node: ast.NewNode(ast.OptionalTypeExprNode, expr.Range().Start, end.End),
+				Type: expr,
+			}
+			continue
+		}
+
 		p.advance()
 		end := p.expect(token.RIGHT_SQUARE_BRACKET)
 
@@ -682,6 +766,10 @@ func (p *Parser) parseTypeExpr() ast.TypeExpr {
 }
 
 func (p *Parser) parseStructExpr() ast.TypeExpr {
+	if p.current.Kind == token.MAP {
+		return p.parseMapTypeExpr()
+	}
+
 	if p.current.Kind == token.IDENT {
 		member := p.parseImportExpr()
 
@@ -708,6 +796,22 @@ func (p *Parser) parseStructExpr() ast.TypeExpr {
 	}
 }
 
+func (p *Parser) parseMapTypeExpr() *ast.MapTypeExpr {
+	start := p.expect(token.MAP)
+	p.expect(token.LEFT_SQUARE_BRACKET)
+	p.skip()
+	key := p.parseTypeExpr()
+	p.skip()
+	p.expect(token.RIGHT_SQUARE_BRACKET)
+	value := p.parseTypeExpr()
+
+	return &ast.MapTypeExpr{
+		Node:  ast.NewNode(ast.MapTypeExprNode, start.Start, value.Range().End),
+		Key:   key,
+		Value: value,
+	}
+}
+
 func (p *Parser) parseTypePair(i int) *ast.TypePair {
 	name := p.expect(token.IDENT)
 	p.expect(token.COLON)
@@ -40,11 +40,15 @@ func TestExpr(t *testing.T) {
 		},
 		&ExprCase{
 			In:  "3.1",
-			Out: &ast.NumberLitExpr{Value: 3.1},
+			Out: &ast.NumberLitExpr{Value: 3.1, IsFloat: true},
+		},
+		&ExprCase{
+			In:  "2.0",
+			Out: &ast.NumberLitExpr{Value: 2, IsFloat: true},
 		},
 		&ExprCase{
 			In:  "-3.1",
-			Out: &ast.NumberLitExpr{Value: -3.1},
+			Out: &ast.NumberLitExpr{Value: -3.1, IsFloat: true},
 		},
 		&ExprCase{
 			In:  "-0",
@@ -268,7 +272,7 @@ func TestExpr(t *testing.T) {
 						},
 						Right: &ast.BinaryExpr{
 							Operator: token.Token{Kind: token.OR},
-							Left:     &ast.NumberLitExpr{Value: -30.1},
+							Left:     &ast.NumberLitExpr{Value: -30.1, IsFloat: true},
 							Right: &ast.MemberExpr{
 								Left:  &ast.IdentExpr{Value: "m"},
 								Right: &ast.IdentExpr{Value: "of"},
@@ -295,6 +299,109 @@ func TestExpr(t *testing.T) {
 			},
 		},
 		&ExprCase{In: "`template { call(true ? a == b : -30.1 || m.of it::continues) } with { complex.expressions[0] }`"},
+		&ExprCase{
+			In: `!valid`,
+			Out: &ast.UnaryExpr{
+				Operator: token.Token{Kind: token.EXCLAMATION_MARK},
+				Expr:     &ast.IdentExpr{Value: "valid"},
+			},
+		},
+		&ExprCase{
+			In: `!a == b`,
+			Out: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.EQUALS},
+				Left: &ast.UnaryExpr{
+					Operator: token.Token{Kind: token.EXCLAMATION_MARK},
+					Expr:     &ast.IdentExpr{Value: "a"},
+				},
+				Right: &ast.IdentExpr{Value: "b"},
+			},
+		},
+		&ExprCase{
+			In: `- count * 2`,
+			Out: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.STAR},
+				Left: &ast.UnaryExpr{
+					Operator: token.Token{Kind: token.MINUS},
+					Expr:     &ast.IdentExpr{Value: "count"},
+				},
+				Right: &ast.NumberLitExpr{Value: 2},
+			},
+		},
+		&ExprCase{
+			In: `!!(a || b)`,
+			Out: &ast.UnaryExpr{
+				Operator: token.Token{Kind: token.EXCLAMATION_MARK},
+				Expr: &ast.UnaryExpr{
+					Operator: token.Token{Kind: token.EXCLAMATION_MARK},
+					Expr: &ast.GroupExpr{
+						Expr: &ast.BinaryExpr{
+							Operator: token.Token{Kind: token.OR},
+							Left:     &ast.IdentExpr{Value: "a"},
+							Right:    &ast.IdentExpr{Value: "b"},
+						},
+					},
+				},
+			},
+		},
+		&ExprCase{
+			In: `n % 3 == 0`,
+			Out: &ast.BinaryExpr{
+				Operator: token.Token{Kind: token.EQUALS},
+				Left: &ast.ArithmeticExpr{
+					Operator: token.Token{Kind: token.PERCENT},
+					Left:     &ast.IdentExpr{Value: "n"},
+					Right:    &ast.NumberLitExpr{Value: 3},
+				},
+				Right: &ast.NumberLitExpr{Value: 0},
+			},
+		},
+		&ExprCase{
+			In: `a + b % c`,
+			Out: &ast.ArithmeticExpr{
+				Operator: token.Token{Kind: token.PLUS},
+				Left:     &ast.IdentExpr{Value: "a"},
+				Right: &ast.ArithmeticExpr{
+					Operator: token.Token{Kind: token.PERCENT},
+					Left:     &ast.IdentExpr{Value: "b"},
+					Right:    &ast.IdentExpr{Value: "c"},
+				},
+			},
+		},
+		&ExprCase{In: `!`, Err: errs.ErrUnexpectedToken},
+		&ExprCase{
+			In: `user.nickname ?? "anonymous"`,
+			Out: &ast.CoalesceExpr{
+				Left: &ast.MemberExpr{
+					Left:  &ast.IdentExpr{Value: "user"},
+					Right: &ast.IdentExpr{Value: "nickname"},
+				},
+				Right: &ast.StringLitExpr{Value: "anonymous"},
+			},
+		},
+		&ExprCase{
+			In: `a ?? b ?? c`,
+			Out: &ast.CoalesceExpr{
+				Left: &ast.CoalesceExpr{
+					Left:  &ast.IdentExpr{Value: "a"},
+					Right: &ast.IdentExpr{Value: "b"},
+				},
+				Right: &ast.IdentExpr{Value: "c"},
+			},
+		},
+		&ExprCase{
+			In: `a ?? b ? c : d`,
+			Out: &ast.TernaryExpr{
+				Predicate: &ast.CoalesceExpr{
+					Left:  &ast.IdentExpr{Value: "a"},
+					Right: &ast.IdentExpr{Value: "b"},
+				},
+				Left:  &ast.IdentExpr{Value: "c"},
+				Right: &ast.IdentExpr{Value: "d"},
+			},
+		},
+		&ExprCase{In: "`hello { name ?? \"there\" }`"},
+		&ExprCase{In: `a ??`, Err: errs.ErrUnexpectedToken},
 	}
 
 	test.Run(t, tests)
@@ -366,6 +473,54 @@ func TestTypeExpr(t *testing.T) {
 				},
 			},
 		},
+		&TypeExprCase{
+			In: "map[string]int",
+			Out: &ast.MapTypeExpr{
+				Key:   &ast.IdentExpr{Value: "string"},
+				Value: &ast.IdentExpr{Value: "int"},
+			},
+		},
+		&TypeExprCase{
+			In: "map[time::Time]map[string]int[]",
+			Out: &ast.MapTypeExpr{
+				Key: &ast.ImportExpr{
+					Left:  &ast.IdentExpr{Value: "time"},
+					Right: &ast.IdentExpr{Value: "Time"},
+				},
+				Value: &ast.MapTypeExpr{
+					Key: &ast.IdentExpr{Value: "string"},
+					Value: &ast.ListTypeExpr{
+						Type: &ast.IdentExpr{Value: "int"},
+					},
+				},
+			},
+		},
+		&TypeExprCase{In: "map[string]", Contains: "unexpected token"},
+		&TypeExprCase{
+			In: "string?",
+			Out: &ast.OptionalTypeExpr{
+				Type: &ast.IdentExpr{Value: "string"},
+			},
+		},
+		&TypeExprCase{
+			In: "int?[]",
+			Out: &ast.ListTypeExpr{
+				Type: &ast.OptionalTypeExpr{
+					Type: &ast.IdentExpr{Value: "int"},
+				},
+			},
+		},
+		&TypeExprCase{
+			In: "{name: string nickname: string?}?",
+			Out: &ast.OptionalTypeExpr{
+				Type: &ast.StructLitExpr{
+					Fields: []*ast.TypePair{
+						{Type: &ast.IdentExpr{Value: "string"}},
+						{Type: &ast.OptionalTypeExpr{Type: &ast.IdentExpr{Value: "string"}}},
+					},
+				},
+			},
+		},
 	}
 	test.Run(t, tests)
 }
@@ -379,3 +534,44 @@ func TestMarshal(t *testing.T) {
 	assert.NoError(err)
 	assert.NotEmpty(b)
 }
+
+func TestComments(t *testing.T) {
+	assert := assert.New(t)
+
+	file, err := test.Parse(test.WithSourceString(`# the catalog
+declare i18n (en)
+
+# dropped
+
+# greetings
+# for users
+section greet {
+  # the title
+  title {
+    en "Hello" # trailing
+  }
+
+  name(n:string) {
+    en ` + "`Hi {n}`" + `
+  }
+}
+`))
+	if !assert.NoError(err) {
+		return
+	}
+
+	literals := func(list []*ast.CommentStmt) []string {
+		out := []string{}
+		for _, comment := range list {
+			out = append(out, comment.Literal)
+		}
+		return out
+	}
+
+	assert.Equal([]string{" the catalog"}, literals(file.Decl.Comments()))
+
+	section := file.Stmts[0].(*ast.SectionStmt)
+	assert.Equal([]string{" greetings", " for users"}, literals(section.Comments()))
+	assert.Equal([]string{" the title"}, literals(section.Body[0].(*ast.KeyEntry).Comments))
+	assert.Empty(section.Body[1].(*ast.TemplateEntry).Comments)
+}
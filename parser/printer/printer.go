@@ -0,0 +1,301 @@
+// Package printer writes syntax trees back as lcl source. The output is in
+// the canonical layout, so the ranges of the nodes are not used and nodes
+// built by hand can be printed as well.
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/parser/token"
+)
+
+// Precedence of expressions, from loosest to tightest as the parser reads
+// them. Comparison and logical operators share a level.
+const (
+	precTernary = iota + 1
+	precCoalesce
+	precBinary
+	precAdditive
+	precMultiplicative
+	precUnary
+	precExponent
+	precPrimary
+)
+
+// Fprint writes the source of a node to w.
+func Fprint(w io.Writer, node ast.Node) error {
+	_, err := io.WriteString(w, String(node))
+	return err
+}
+
+// String returns the source of a node, files end with a new line.
+func String(node ast.Node) string {
+	p := &printer{}
+
+	switch node := node.(type) {
+	case *ast.File:
+		p.file(node)
+	case ast.Stmt:
+		p.stmt(node, 0)
+	case *ast.KeyEntry, *ast.TemplateEntry:
+		p.entry(node.(ast.Entry), 0)
+	case *ast.Field:
+		p.field(node, 0)
+	case *ast.TypePair:
+		p.WriteString(pair(node))
+	case ast.Expr:
+		p.WriteString(expr(node))
+	case ast.TypeExpr:
+		p.WriteString(typeExpr(node))
+	}
+
+	return p.String()
+}
+
+type printer struct {
+	strings.Builder
+}
+
+func (p *printer) line(depth int, format string, args ...any) {
+	p.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(p, format, args...)
+	p.WriteString("\n")
+}
+
+func (p *printer) file(file *ast.File) {
+	if file.Decl != nil {
+		p.stmt(file.Decl, 0)
+	}
+
+	if len(file.Imports) > 0 {
+		p.WriteString("\n")
+	}
+	for _, stmt := range file.Imports {
+		p.stmt(stmt, 0)
+	}
+
+	for _, stmt := range file.Stmts {
+		if _, ok := stmt.(*ast.EmptyStmt); ok {
+			continue
+		}
+		p.WriteString("\n")
+		p.stmt(stmt, 0)
+	}
+}
+
+func (p *printer) comments(list []*ast.CommentStmt, depth int) {
+	for _, comment := range list {
+		p.line(depth, "%s", comment.Raw)
+	}
+}
+
+func (p *printer) stmt(stmt ast.Stmt, depth int) {
+	p.comments(stmt.Comments(), depth)
+
+	switch stmt := stmt.(type) {
+	case *ast.DeclStmt:
+		targets := []string{}
+		for _, target := range stmt.Targets {
+			if target.Tag != nil {
+				targets = append(targets, str(target.Tag.Value)+" as "+target.Name.Value)
+			} else {
+				targets = append(targets, target.Name.Value)
+			}
+		}
+		p.line(depth, "declare %s (%s)", stmt.Name.Value, strings.Join(targets, " "))
+	case *ast.ImportStmt:
+		names := []string{}
+		for _, name := range stmt.List {
+			names = append(names, name.Value)
+		}
+		if len(names) == 1 {
+			p.line(depth, "import %s", names[0])
+		} else {
+			p.line(depth, "import (%s)", strings.Join(names, " "))
+		}
+	case *ast.TypeDefStmt:
+		p.line(depth, "type %s %s", stmt.Name.Value, typeExpr(stmt.Type))
+	case *ast.FnDefStmt:
+		p.line(depth, "fn(%s) %s %s", pairs(stmt.Params), stmt.Name.Value, expr(stmt.Body))
+	case *ast.SectionStmt:
+		p.line(depth, "section %s {", stmt.Name.Value)
+
+		first := true
+		for _, entry := range stmt.Body {
+			if _, ok := entry.(*ast.EmptyEntry); ok {
+				continue
+			}
+			if !first {
+				p.WriteString("\n")
+			}
+			first = false
+			p.entry(entry, depth+1)
+		}
+
+		p.line(depth, "}")
+	}
+}
+
+func (p *printer) entry(entry ast.Entry, depth int) {
+	var fields []*ast.Field
+
+	switch entry := entry.(type) {
+	case *ast.SectionStmt:
+		p.stmt(entry, depth)
+		return
+	case *ast.KeyEntry:
+		p.comments(entry.Comments, depth)
+		p.line(depth, "%s {", entry.Name.Value)
+		fields = entry.Fields
+	case *ast.TemplateEntry:
+		p.comments(entry.Comments, depth)
+		partitioned := ""
+		if entry.Partitioned {
+			partitioned = "*"
+		}
+		p.line(depth, "%s(%s)%s {", entry.Name.Value, pairs(entry.Params), partitioned)
+		fields = entry.Fields
+	default:
+		return
+	}
+
+	for _, field := range fields {
+		p.field(field, depth+1)
+	}
+	p.line(depth, "}")
+}
+
+func (p *printer) field(field *ast.Field, depth int) {
+	p.line(depth, "%s %s", field.Tag.Value, expr(field.Value))
+}
+
+func pairs(list []*ast.TypePair) string {
+	parts := []string{}
+	for _, p := range list {
+		parts = append(parts, pair(p))
+	}
+	return strings.Join(parts, " ")
+}
+
+func pair(p *ast.TypePair) string {
+	return p.Name.Value + ":" + typeExpr(p.Type)
+}
+
+func typeExpr(typ ast.TypeExpr) string {
+	switch typ := typ.(type) {
+	case *ast.IdentExpr:
+		return typ.Value
+	case *ast.ImportExpr:
+		return typ.Left.Value + "::" + typ.Right.Value
+	case *ast.ListTypeExpr:
+		return typeExpr(typ.Type) + "[]"
+	case *ast.MapTypeExpr:
+		return "map[" + typeExpr(typ.Key) + "]" + typeExpr(typ.Value)
+	case *ast.OptionalTypeExpr:
+		return typeExpr(typ.Type) + "?"
+	case *ast.StructLitExpr:
+		return "{" + pairs(typ.Fields) + "}"
+	default:
+		return ""
+	}
+}
+
+func expr(e ast.Expr) string {
+	code, _ := operand(e)
+	return code
+}
+
+// operand returns the source of an expression along with its precedence
+func operand(e ast.Expr) (string, int) {
+	switch e := e.(type) {
+	case *ast.TernaryExpr:
+		return wrap(e.Predicate, precTernary+1) + " ? " + expr(e.Left) + " : " + expr(e.Right), precTernary
+	case *ast.CoalesceExpr:
+		return wrap(e.Left, precCoalesce) + " ?? " + wrap(e.Right, precCoalesce+1), precCoalesce
+	case *ast.BinaryExpr:
+		return wrap(e.Left, precBinary) + " " + e.Operator.Kind.String() + " " + wrap(e.Right, precBinary+1), precBinary
+	case *ast.ArithmeticExpr:
+		switch e.Operator.Kind {
+		case token.CARET:
+			return wrap(e.Left, precExponent+1) + " ^ " + wrap(e.Right, precExponent), precExponent
+		case token.PLUS, token.MINUS:
+			return wrap(e.Left, precAdditive) + " " + e.Operator.Kind.String() + " " + wrap(e.Right, precAdditive+1), precAdditive
+		default:
+			return wrap(e.Left, precMultiplicative) + " " + e.Operator.Kind.String() + " " + wrap(e.Right, precMultiplicative+1), precMultiplicative
+		}
+	case *ast.UnaryExpr:
+		return e.Operator.Kind.String() + wrap(e.Expr, precUnary), precUnary
+	case *ast.CallExpr:
+		args := []string{}
+		for _, arg := range e.Args {
+			args = append(args, expr(arg))
+		}
+		return wrap(e.Fn, precPrimary) + "(" + strings.Join(args, " ") + ")", precPrimary
+	case *ast.MemberExpr:
+		return wrap(e.Left, precPrimary) + "." + e.Right.Value, precPrimary
+	case *ast.ImportExpr:
+		return e.Left.Value + "::" + e.Right.Value, precPrimary
+	case *ast.IndexExpr:
+		return wrap(e.Host, precPrimary) + "[" + expr(e.Index) + "]", precPrimary
+	case *ast.GroupExpr:
+		return "(" + expr(e.Expr) + ")", precPrimary
+	case *ast.IdentExpr:
+		return e.Value, precPrimary
+	case *ast.StringLitExpr:
+		return str(e.Value), precPrimary
+	case *ast.NumberLitExpr:
+		number := strconv.FormatFloat(e.Value, 'f', -1, 64)
+		if e.IsFloat && !strings.Contains(number, ".") {
+			number += ".0"
+		}
+		return number, precPrimary
+	case *ast.TemplateLitExpr:
+		return "`" + template(e) + "`", precPrimary
+	default:
+		return "", precPrimary
+	}
+}
+
+// wrap groups an expression that binds looser than prec
+func wrap(e ast.Expr, prec int) string {
+	code, p := operand(e)
+	if p < prec {
+		return "(" + code + ")"
+	}
+	return code
+}
+
+func str(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// template returns the contents of a template literal, text is written as it
+// is except for the characters a template cannot hold which are interpolated
+// as strings
+func template(e *ast.TemplateLitExpr) string {
+	b := strings.Builder{}
+
+	for _, part := range e.Value {
+		switch part := part.(type) {
+		case *ast.StringLitExpr:
+			for _, r := range part.Value {
+				switch r {
+				case '{', '`':
+					b.WriteString("{" + str(string(r)) + "}")
+				default:
+					b.WriteRune(r)
+				}
+			}
+		case *ast.TemplateLitExpr:
+			b.WriteString(template(part))
+		default:
+			b.WriteString("{" + expr(part) + "}")
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package printer_test
+
+import (
+	"testing"
+
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/parser/printer"
+	"github.com/CanPacis/lcl/parser/token"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `declare shop (en tr "pt-BR" as pt)
+
+import (time strings)
+
+# Money is in dollars
+type Money f64
+
+type Item {name:string price:Money tags:string[] meta:map[string]int note:string?}
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+fn(a:int b:int) calc -(a + b) * 2 ^ (a % 3) - a / b
+
+fn(t:time::Time) year time::year(t) ?? 0
+
+# shown on the cart page
+section cart {
+  # the heading
+  # of the page
+  title {
+    en "Your \"cart\""
+    tr "Sepetiniz"
+    pt "Seu carrinho"
+  }
+
+  summary(n:int items:Item[] first:Item) {
+    en ` + "`{n} {plural(n)}, first is {first.name} of {items[0]} {\"{\"}braces}`" + `
+    tr ` + "`{n} ürün`" + `
+    pt ` + "`{n > 1 && n < 10 ? \"few\" : \"many\"}`" + `
+  }
+
+  section stock {
+    # n is the stock
+    empty(n:int)* {
+      en ` + "`{(n == 0) == false}`" + `
+      tr "Stokta yok"
+      pt "Esgotado"
+    }
+  }
+}
+`
+
+type ExprCase struct {
+	In  string
+	Out string
+}
+
+func (c *ExprCase) Run(assert *assert.Assertions) {
+	expr := test.MustParseExpr(test.WithSourceString(c.In))
+	assert.Equal(c.Out, printer.String(expr))
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, source, printer.String(test.MustParse(test.WithSourceString(source))))
+}
+
+func TestExpr(t *testing.T) {
+	tests := []test.Runner{
+		&ExprCase{In: "a+b*c", Out: "a + b * c"},
+		&ExprCase{In: "(a+b)*c", Out: "(a + b) * c"},
+		&ExprCase{In: "a-(b-c)", Out: "a - (b - c)"},
+		&ExprCase{In: "(a-b)-c", Out: "(a - b) - c"},
+		&ExprCase{In: "a^b^c", Out: "a ^ b ^ c"},
+		&ExprCase{In: "(a^b)^c", Out: "(a ^ b) ^ c"},
+		&ExprCase{In: "(-a)^2", Out: "(-a) ^ 2"},
+		&ExprCase{In: "(a ? b : c) ? d : e", Out: "(a ? b : c) ? d : e"},
+		&ExprCase{In: "a ? b : c ? d : e", Out: "a ? b : c ? d : e"},
+		&ExprCase{In: "a ?? (b ?? c)", Out: "a ?? (b ?? c)"},
+		&ExprCase{In: "!(a == b)", Out: "!(a == b)"},
+		&ExprCase{In: "f(a b)[0]", Out: "f(a b)[0]"},
+		&ExprCase{In: "a.b.c", Out: "a.b.c"},
+		&ExprCase{In: "pkg::format(1.5)", Out: "pkg::format(1.5)"},
+		&ExprCase{In: "7 / 2.0", Out: "7 / 2.0"},
+	}
+
+	test.Run(t, tests)
+}
+
+func TestBuilt(t *testing.T) {
+	text := func(s string) *ast.StringLitExpr { return &ast.StringLitExpr{Value: s} }
+	ident := func(s string) *ast.IdentExpr { return &ast.IdentExpr{Value: s} }
+
+	expr := &ast.TemplateLitExpr{Value: []ast.Expr{
+		text("`{x}` is "),
+		&ast.BinaryExpr{
+			Operator: token.Token{Kind: token.EQUALS},
+			Left:     ident("x"),
+			Right:    &ast.NumberLitExpr{Value: 1},
+		},
+	}}
+
+	assert.Equal(t, "`{\"`\"}{\"{\"}x}{\"`\"} is {x == 1}`", printer.String(expr))
+
+	minus := func(left, right ast.Expr) ast.Expr {
+		return &ast.ArithmeticExpr{Operator: token.Token{Kind: token.MINUS}, Left: left, Right: right}
+	}
+
+	assert.Equal(t, "a - b - c", printer.String(minus(minus(ident("a"), ident("b")), ident("c"))))
+	assert.Equal(t, "a - (b - c)", printer.String(minus(ident("a"), minus(ident("b"), ident("c")))))
+}
@@ -70,6 +70,8 @@ const (
 	COLON
 	DOUBLE_COLON
 	QUESTION_MARK
+	DOUBLE_QUESTION_MARK
+	EXCLAMATION_MARK
 	STAR
 	PLUS
 	MINUS
@@ -106,6 +108,7 @@ const (
 	FN
 	TYPE
 	SECTION
+	MAP
 )
 
 var tokenMap = map[Kind]string{
@@ -132,6 +135,8 @@ var tokenMap = map[Kind]string{
 	COLON:                ":",
 	DOUBLE_COLON:         "::",
 	QUESTION_MARK:        "?",
+	DOUBLE_QUESTION_MARK: "??",
+	EXCLAMATION_MARK:     "!",
 	STAR:                 "*",
 	PLUS:                 "+",
 	MINUS:                "-",
@@ -164,6 +169,7 @@ var tokenMap = map[Kind]string{
 	FN:      "fn",
 	TYPE:    "type",
 	SECTION: "section",
+	MAP:     "map",
 }
 
 type Token struct {
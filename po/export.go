@@ -0,0 +1,166 @@
+package po
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/icu"
+	"github.com/CanPacis/lcl/ir"
+)
+
+type Config struct{}
+
+type Generator struct {
+	config *Config
+	ir     *ir.IR
+}
+
+// Files returns the template named after the package and a catalog for every
+// locale but the source one named after its tag. The files are returned even
+// if some messages could not be exported.
+func (g *Generator) Files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+	failures := []error{}
+
+	buf := &bytes.Buffer{}
+	if err := g.Template(buf); err != nil {
+		if !errs.IsExportError(err) {
+			return nil, err
+		}
+		failures = append(failures, err)
+	}
+	files[g.ir.Name+".pot"] = buf.Bytes()
+
+	for _, locale := range g.ir.Locales[1:] {
+		buf := &bytes.Buffer{}
+		if err := g.Generate(buf, locale); err != nil {
+			if !errs.IsExportError(err) {
+				return nil, err
+			}
+			failures = append(failures, err)
+		}
+		files[locale.Tag.String()+".po"] = buf.Bytes()
+	}
+
+	return files, errors.Join(failures...)
+}
+
+// Template writes the catalog translators start from, the ids are the
+// messages of the source locale, the first one the package declares, and the
+// strings are empty.
+func (g *Generator) Template(w io.Writer) error {
+	return g.write(w, nil)
+}
+
+// Generate writes the catalog of a locale. Messages the source locale cannot
+// export are left out like Template does, the ones the locale cannot export
+// are left untranslated and reported once the rest is written.
+func (g *Generator) Generate(w io.Writer, locale ir.Locale) error {
+	return g.write(w, &locale)
+}
+
+func (g *Generator) write(w io.Writer, locale *ir.Locale) error {
+	if len(g.ir.Locales) == 0 {
+		return errors.New("package declares no locales")
+	}
+	ir.Optimize(g.ir)
+
+	source := g.ir.Locales[0]
+
+	file := &File{Header: []Field{
+		{"Project-Id-Version", g.ir.Name},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=UTF-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+		{"X-Generator", "lcl"},
+	}}
+	if locale != nil {
+		file.Header = append(file.Header, Field{"Language", strings.ReplaceAll(locale.Tag.String(), "-", "_")})
+	}
+
+	failures := []error{}
+
+	var section func(s *ir.Section, path string)
+	section = func(s *ir.Section, path string) {
+		for _, message := range s.Messages {
+			id, err := text(message, source)
+			if err != nil {
+				if locale == nil {
+					failures = append(failures, errs.Locate(err, path+message.Name, source.Name))
+				}
+				continue
+			}
+
+			entry := &Entry{Context: path + message.Name, ID: id}
+			if message.IsTemplate {
+				entry.Flags = append(entry.Flags, FlagICU)
+			}
+			entry.Comments, entry.References = references(message)
+
+			if locale != nil {
+				str, err := text(message, *locale)
+				if err != nil {
+					failures = append(failures, errs.Locate(err, path+message.Name, locale.Name))
+				}
+				entry.Str = str
+			}
+
+			file.Entries = append(file.Entries, entry)
+		}
+
+		for _, sub := range s.Sections {
+			section(sub, path+sub.Name+".")
+		}
+	}
+
+	for _, s := range g.ir.Sections {
+		section(s, s.Name+".")
+	}
+
+	if err := file.Write(w); err != nil {
+		return err
+	}
+	return errors.Join(failures...)
+}
+
+// references returns the comments of a message and the line it is defined
+// at, messages that were not checked from a file have neither
+func references(message *ir.Message) ([]string, []string) {
+	comments := []string{}
+	if len(message.Comment) > 0 {
+		comments = strings.Split(message.Comment, "\n")
+	}
+
+	if len(message.Pos.File) == 0 {
+		return comments, nil
+	}
+	return comments, []string{fmt.Sprintf("%s:%d", message.Pos.File, message.Pos.Line)}
+}
+
+// text returns the text of a message in a locale, keys as they are and
+// templates as ICU MessageFormat patterns. It is empty if the locale has no
+// value for the message.
+func text(message *ir.Message, locale ir.Locale) (string, error) {
+	value := message.Values[locale.Tag]
+	if value == nil {
+		return "", nil
+	}
+
+	if lit, ok := value.(*ir.Literal); ok && !message.IsTemplate {
+		return lit.Text(), nil
+	}
+	return icu.Export(value)
+}
+
+func New(out *ir.IR, options ...func(*Config)) *Generator {
+	config := &Config{}
+	for _, option := range options {
+		option(config)
+	}
+
+	return &Generator{config: config, ir: out}
+}
@@ -0,0 +1,74 @@
+package po
+
+import (
+	"errors"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/icu"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser/ast"
+)
+
+// Import writes the translations of a catalog into the fields of a locale in
+// a source tree, out is the package checked from the tree. Untranslated and
+// fuzzy entries are skipped, keys are written as they are and templates are
+// read as ICU MessageFormat patterns. Entries that cannot be imported are
+// reported together once the rest is written.
+func Import(tree *ast.File, out *ir.IR, locale ir.Locale, file *File) error {
+	entries := ast.Entries(tree)
+	messages := map[string]*ir.Message{}
+
+	var walk func(s *ir.Section, path string)
+	walk = func(s *ir.Section, path string) {
+		for _, message := range s.Messages {
+			messages[path+message.Name] = message
+		}
+		for _, sub := range s.Sections {
+			walk(sub, path+sub.Name+".")
+		}
+	}
+	for _, s := range out.Sections {
+		walk(s, s.Name+".")
+	}
+
+	failures := []error{}
+	fail := func(entry *Entry, err error) {
+		var e *errs.ImportError
+		if errors.As(err, &e) {
+			e.Path = entry.Context
+			e.Locale = locale.Name
+		}
+		failures = append(failures, err)
+	}
+
+	for _, entry := range file.Entries {
+		if len(entry.Str) == 0 || entry.HasFlag("fuzzy") {
+			continue
+		}
+
+		node, ok := entries[entry.Context]
+		message := messages[entry.Context]
+		if !ok || message == nil {
+			fail(entry, &errs.ImportError{Err: errs.ErrUnknownMessage, Format: "po"})
+			continue
+		}
+		if len(entry.Plural) > 0 {
+			fail(entry, &errs.ImportError{Err: errs.ErrUnrepresentable, Format: "po", Value: "plural form"})
+			continue
+		}
+
+		if !message.IsTemplate {
+			ast.SetField(node, locale.Name, ast.NewText(entry.Str))
+			continue
+		}
+
+		expr, err := icu.Import(entry.Str, message.Params, locale.Tag)
+		if err != nil {
+			fail(entry, err)
+			continue
+		}
+		ast.SetField(node, locale.Name, expr)
+	}
+
+	return errors.Join(failures...)
+}
@@ -0,0 +1,243 @@
+// Package po converts the messages of a package to GNU gettext catalogs and
+// back. Every message is an entry whose context is its path, templates are
+// written as ICU MessageFormat patterns and flagged as such.
+package po
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+)
+
+// FlagICU marks the entries of templates, translation tools check their
+// placeholders with it
+const FlagICU = "icu-message-format"
+
+type Entry struct {
+	// Comments are the extracted comments, written as #.
+	Comments []string
+	// References are the source positions, written as #:
+	References []string
+	Flags      []string
+	Context    string
+	ID         string
+	// Plural is the plural id of the entry, lcl does not write plural forms
+	// but it reads them to report them
+	Plural string
+	Str    string
+}
+
+func (e *Entry) HasFlag(flag string) bool {
+	for _, f := range e.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Field is a field of the header of a catalog
+type Field struct {
+	Key   string
+	Value string
+}
+
+type File struct {
+	Header  []Field
+	Entries []*Entry
+}
+
+// Field returns the value of a header field, it is empty if the header has
+// no such field.
+func (f *File) Field(key string) string {
+	for _, field := range f.Header {
+		if strings.EqualFold(field.Key, key) {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Write writes the catalog, the header is written as the entry with an empty
+// id.
+func (f *File) Write(w io.Writer) error {
+	b := &strings.Builder{}
+
+	header := &strings.Builder{}
+	for _, field := range f.Header {
+		header.WriteString(field.Key + ": " + field.Value + "\n")
+	}
+	b.WriteString(quote("msgid", ""))
+	b.WriteString(quote("msgstr", header.String()))
+
+	for _, entry := range f.Entries {
+		b.WriteString("\n")
+		for _, comment := range entry.Comments {
+			b.WriteString("#. " + comment + "\n")
+		}
+		if len(entry.References) > 0 {
+			b.WriteString("#: " + strings.Join(entry.References, " ") + "\n")
+		}
+		if len(entry.Flags) > 0 {
+			b.WriteString("#, " + strings.Join(entry.Flags, ", ") + "\n")
+		}
+		if len(entry.Context) > 0 {
+			b.WriteString(quote("msgctxt", entry.Context))
+		}
+		b.WriteString(quote("msgid", entry.ID))
+		b.WriteString(quote("msgstr", entry.Str))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quote writes a keyword and its string, strings that span lines are split
+// after every new line
+func quote(keyword, s string) string {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		return keyword + " " + escape(s) + "\n"
+	}
+
+	b := strings.Builder{}
+	b.WriteString(keyword + " \"\"\n")
+	for _, line := range lines {
+		b.WriteString(escape(line) + "\n")
+	}
+	return b.String()
+}
+
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// Parse reads a catalog. Translator comments, obsolete entries and previous
+// strings are skipped and only the first form of a plural is kept.
+func Parse(r io.Reader) (*File, error) {
+	file := &File{}
+	scanner := bufio.NewScanner(r)
+
+	var entry *Entry
+	// target is the string continuation lines are appended to
+	var target *string
+	// id is set once the msgid of the entry is read
+	id := false
+	line := 0
+
+	malformed := func(format string, args ...any) error {
+		return &errs.ImportError{
+			Err:    errs.ErrMalformedFile,
+			Format: "po",
+			Value:  fmt.Sprintf("line %d: ", line) + fmt.Sprintf(format, args...),
+		}
+	}
+
+	flush := func() {
+		switch {
+		case entry == nil || !id:
+		case len(entry.ID) == 0 && len(entry.Context) == 0:
+			file.Header = header(entry.Str)
+		default:
+			file.Entries = append(file.Entries, entry)
+		}
+		entry = nil
+		target = nil
+		id = false
+	}
+
+	current := func() *Entry {
+		if entry == nil {
+			entry = &Entry{}
+		}
+		return entry
+	}
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case len(text) == 0:
+			flush()
+		case strings.HasPrefix(text, "#~"), strings.HasPrefix(text, "#|"):
+		case strings.HasPrefix(text, "#."):
+			current().Comments = append(current().Comments, strings.TrimSpace(text[2:]))
+		case strings.HasPrefix(text, "#:"):
+			current().References = append(current().References, strings.Fields(text[2:])...)
+		case strings.HasPrefix(text, "#,"):
+			for _, flag := range strings.Split(text[2:], ",") {
+				if flag = strings.TrimSpace(flag); len(flag) > 0 {
+					current().Flags = append(current().Flags, flag)
+				}
+			}
+		case strings.HasPrefix(text, "#"):
+		case strings.HasPrefix(text, `"`):
+			if target == nil {
+				return nil, malformed("unexpected string")
+			}
+			s, err := strconv.Unquote(text)
+			if err != nil {
+				return nil, malformed("invalid string %s", text)
+			}
+			*target += s
+		default:
+			keyword, value, _ := strings.Cut(text, " ")
+			s, err := strconv.Unquote(strings.TrimSpace(value))
+			if err != nil {
+				return nil, malformed("invalid string %s", value)
+			}
+
+			// A keyword that starts a new entry without a blank line before
+			if id && (keyword == "msgctxt" || keyword == "msgid") {
+				flush()
+			}
+
+			e := current()
+			switch keyword {
+			case "msgctxt":
+				target = &e.Context
+			case "msgid":
+				target = &e.ID
+				id = true
+			case "msgid_plural":
+				target = &e.Plural
+			case "msgstr", "msgstr[0]":
+				target = &e.Str
+			default:
+				if !strings.HasPrefix(keyword, "msgstr[") {
+					return nil, malformed("unknown keyword %s", keyword)
+				}
+				dropped := ""
+				target = &dropped
+			}
+			*target = s
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+	return file, nil
+}
+
+func header(s string) []Field {
+	fields := []Field{}
+	for _, line := range strings.Split(s, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields = append(fields, Field{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return fields
+}
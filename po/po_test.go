@@ -0,0 +1,189 @@
+package po_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/parser/printer"
+	"github.com/CanPacis/lcl/po"
+	"github.com/CanPacis/lcl/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `declare shop (en tr)
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+section cart {
+  # the heading
+  # of the page
+  title {
+    en "Your \"cart\""
+    tr "Sepetiniz"
+  }
+
+  summary(n:int) {
+    en ` + "`{n} {n == 1 ? \"item\" : \"items\"}`" + `
+    tr ` + "`{n} ürün`" + `
+  }
+
+  total(n:int) {
+    en ` + "`{n} {plural(n)}`" + `
+    tr ` + "`{n} ürün`" + `
+  }
+
+  section stock {
+    empty {
+      en ` + "`Out of stock\nfor now`" + `
+      tr "Stokta yok"
+    }
+  }
+}
+`
+
+const catalog = `msgid ""
+msgstr ""
+"Project-Id-Version: shop\n"
+"MIME-Version: 1.0\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+"Content-Transfer-Encoding: 8bit\n"
+"X-Generator: lcl\n"
+"Language: tr\n"
+
+#. the heading
+#. of the page
+#: shop.lcl:8
+msgctxt "cart.title"
+msgid "Your \"cart\""
+msgstr "Sepetiniz"
+
+#: shop.lcl:13
+#, icu-message-format
+msgctxt "cart.summary"
+msgid "{n, number, integer} {n, plural, =1 {item} other {items}}"
+msgstr "{n, number, integer} ürün"
+
+#: shop.lcl:24
+msgctxt "cart.stock.empty"
+msgid ""
+"Out of stock\n"
+"for now"
+msgstr "Stokta yok"
+`
+
+func TestFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	_, out := test.MustScanFile(test.WithName("shop.lcl"), test.WithSourceString(source))
+	files, err := po.New(out).Files()
+	assert.ErrorIs(err, errs.ErrUnrepresentable)
+	assert.EqualError(err, "export error: cart.total (en), call of plural cannot be represented in icu")
+
+	assert.Equal(catalog, string(files["tr.po"]))
+
+	template := string(files["shop.pot"])
+	assert.NotContains(template, "Language")
+	assert.Contains(template, "msgctxt \"cart.title\"\nmsgid \"Your \\\"cart\\\"\"\nmsgstr \"\"\n")
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	file, err := po.Parse(strings.NewReader(catalog))
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.Equal("tr", file.Field("Language"))
+	assert.Len(file.Entries, 3)
+	assert.Equal(&po.Entry{
+		Comments:   []string{"the heading", "of the page"},
+		References: []string{"shop.lcl:8"},
+		Context:    "cart.title",
+		ID:         `Your "cart"`,
+		Str:        "Sepetiniz",
+	}, file.Entries[0])
+	assert.True(file.Entries[1].HasFlag(po.FlagICU))
+	assert.Equal("Out of stock\nfor now", file.Entries[2].ID)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(file.Write(buf))
+	assert.Equal(catalog, buf.String())
+
+	file, err = po.Parse(strings.NewReader(`# translator comment
+msgid "a"
+msgstr "b"
+msgid "c"
+msgid_plural "cs"
+msgstr[0] "d"
+msgstr[1] "ds"
+#~ msgid "old"
+#~ msgstr "eski"
+`))
+	if assert.NoError(err) && assert.Len(file.Entries, 2) {
+		assert.Equal("b", file.Entries[0].Str)
+		assert.Equal("cs", file.Entries[1].Plural)
+		assert.Equal("d", file.Entries[1].Str)
+	}
+
+	_, err = po.Parse(strings.NewReader("msgid \"a\"\nmsgstr b\n"))
+	assert.ErrorIs(err, errs.ErrMalformedFile)
+	assert.ErrorContains(err, "line 2")
+}
+
+func TestImport(t *testing.T) {
+	assert := assert.New(t)
+
+	tree, out := test.MustScanFile(test.WithName("shop.lcl"), test.WithSourceString(source))
+	file, err := po.Parse(strings.NewReader(`msgid ""
+msgstr "Language: tr\n"
+
+msgctxt "cart.title"
+msgid "Your cart"
+msgstr "Yeni \"sepetiniz\""
+
+#, fuzzy
+msgctxt "cart.total"
+msgid "Total"
+msgstr "Toplam"
+
+#, icu-message-format
+msgctxt "cart.summary"
+msgid "{n, plural, =1 {# item} other {# items}}"
+msgstr "{n, plural, one {# ürün} other {# ürünler}}"
+
+msgctxt "cart.stock.empty"
+msgid "Out of stock"
+msgstr ""
+"Stokta\n"
+"yok"
+
+msgctxt "cart.missing"
+msgid "Missing"
+msgstr "Eksik"
+
+msgctxt "cart.total"
+msgid "{n}"
+msgstr "{count}"
+`))
+	if !assert.NoError(err) {
+		return
+	}
+
+	err = po.Import(tree, out, out.Locales[1], file)
+	assert.ErrorIs(err, errs.ErrUnknownMessage)
+	assert.ErrorIs(err, errs.ErrUnknownArgument)
+	assert.ErrorContains(err, "import error: cart.missing (tr), unknown message")
+
+	printed := printer.String(tree)
+	assert.Contains(printed, "    tr \"Yeni \\\"sepetiniz\\\"\"\n")
+	assert.Contains(printed, "    tr `{n}{n == 1 ? \" ürün\" : \" ürünler\"}`\n")
+	assert.Contains(printed, "    tr `Stokta\nyok`\n")
+	assert.Contains(printed, "  total(n:int) {\n    en `{n} {plural(n)}`\n    tr `{n} ürün`\n")
+	assert.Contains(printed, "  # the heading\n  # of the page\n  title {\n")
+
+	out = test.MustScan(printed)
+	assert.Len(out.Locales, 2)
+}
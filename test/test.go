@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/CanPacis/lcl/analyzer"
 	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	pkg "github.com/CanPacis/lcl/package"
 	"github.com/CanPacis/lcl/parser"
 	"github.com/CanPacis/lcl/parser/ast"
 	"github.com/stretchr/testify/assert"
@@ -133,6 +136,33 @@ func MustParseTypeExpr(options ...func(*ParserOptions)) ast.TypeExpr {
 	return expr
 }
 
+// MustScan parses and checks the source of a package and returns its ir
+func MustScan(source string) *ir.IR {
+	_, out := MustScanFile(WithSourceString(source))
+	return out
+}
+
+// MustScanFile is like MustScan but takes the parser options and returns the
+// syntax tree of the package as well, importers write translations into it
+func MustScanFile(options ...func(*ParserOptions)) (*ast.File, *ir.IR) {
+	opts := &ParserOptions{
+		File: &parser.File{
+			Name: "mock.lcl",
+		},
+	}
+
+	for _, option := range options {
+		option(opts)
+	}
+
+	tree := MustParse(WithFile(opts.File))
+	out, err := analyzer.New(opts.File, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+	if err != nil {
+		panic(FormatError(err))
+	}
+	return tree, out
+}
+
 func FormatError(err error) string {
 	es, ok := err.(*errs.ErrorSet)
 	if !ok {
@@ -23,15 +23,21 @@ func (t *List) Base() Type {
 }
 
 func (t *List) Index(v any) (Type, bool) {
-	_, ok := v.(int)
-	if !ok {
+	switch v := v.(type) {
+	case int:
+		return t.Type, true
+	case Type:
+		if !IsInteger(v) {
+			return Invalid, false
+		}
+		return t.Type, true
+	default:
 		return Invalid, false
 	}
-	return t.Type, true
 }
 
 func (t *List) Assignable(o Type) bool {
-	c, ok := o.(*List)
+	c, ok := RootOf(o).(*List)
 	if !ok {
 		return false
 	}
@@ -39,27 +45,33 @@ func (t *List) Assignable(o Type) bool {
 	return t.Type.Assignable(c.Type)
 }
 
+// Comparable reports whether both lists hold runes, they are compared as text.
+// Other lists would have to be compared element by element.
 func (t *List) Comparable(o Type) bool {
-	c, ok := RootOf(o).(*List)
+	c, ok := RootOf(Unwrap(o)).(*List)
 	if !ok {
 		return false
 	}
 
-	return t.Type.Comparable(c.Type)
+	return isText(t) && isText(c)
 }
 
-func (t *List) Convertible(o Type) bool {
-	return t.Comparable(RootOf(o))
+// isText reports whether a list holds runes
+func isText(t *List) bool {
+	return Identical(RootOf(t.Type), RootOf(Rune))
 }
 
-func (t *List) Operable(o Type, op Operation) bool {
-	switch op {
-	case Addition:
-		// TODO: not exactly right
-		return t.Comparable(o)
-	default:
+func (t *List) Convertible(o Type) bool {
+	c, ok := RootOf(Unwrap(o)).(*List)
+	if !ok {
 		return false
 	}
+
+	return t.Type.Convertible(c.Type)
+}
+
+func (t *List) Operable(o Type, op Operation) bool {
+	return false
 }
 
 func NewList(t Type) *List {
@@ -68,6 +80,90 @@ func NewList(t Type) *List {
 	}
 }
 
+type Map struct {
+	Key   Type
+	Value Type
+}
+
+func (t *Map) String() string {
+	return fmt.Sprintf("map[%s]%s", t.Key.String(), t.Value.String())
+}
+
+func (t *Map) IsRoot() bool {
+	return true
+}
+
+func (t *Map) Base() Type {
+	return nil
+}
+
+// Index only accepts the type of an index expression, maps cannot be indexed
+// with member expressions.
+func (t *Map) Index(v any) (Type, bool) {
+	key, ok := v.(Type)
+	if !ok || !t.Key.Comparable(key) {
+		return Invalid, false
+	}
+
+	return t.Value, true
+}
+
+func (t *Map) Assignable(o Type) bool {
+	c, ok := RootOf(o).(*Map)
+	if !ok {
+		return false
+	}
+
+	return Identical(t.Key, c.Key) && t.Value.Assignable(c.Value)
+}
+
+func (t *Map) Comparable(o Type) bool {
+	return false
+}
+
+func (t *Map) Convertible(o Type) bool {
+	c, ok := RootOf(o).(*Map)
+	if !ok {
+		return false
+	}
+
+	return t.Key.Comparable(c.Key) && t.Value.Comparable(c.Value)
+}
+
+func (t *Map) Operable(o Type, op Operation) bool {
+	return false
+}
+
+func NewMap(key, value Type) *Map {
+	return &Map{
+		Key:   key,
+		Value: value,
+	}
+}
+
+// IsKeyable reports whether the given type can be used as a map key, only
+// scalars and strings are allowed.
+func IsKeyable(t Type) bool {
+	root := RootOf(t)
+	if root == Invalid {
+		return false
+	}
+	if _, ok := root.(*Constant); ok {
+		return true
+	}
+
+	return String.Comparable(t)
+}
+
+// IsFormattable reports whether values of the given type have a text form
+// that templates can interpolate, which are the keyable types and templates.
+func IsFormattable(t Type) bool {
+	if _, ok := t.(*Template); ok {
+		return true
+	}
+	return IsKeyable(Default(t))
+}
+
 type TypePair struct {
 	Index int
 	Name  string
@@ -116,23 +212,61 @@ func (t *Struct) Index(v any) (Type, bool) {
 	return Invalid, false
 }
 
+func (t *Struct) field(name string) (TypePair, bool) {
+	for _, pair := range *t {
+		if pair.Name == name {
+			return pair, true
+		}
+	}
+	return TypePair{}, false
+}
+
 func (t *Struct) Assignable(o Type) bool {
-	// TODO
-	return false
+	c, ok := RootOf(o).(*Struct)
+	if !ok {
+		return false
+	}
+
+	for _, pair := range *t {
+		other, ok := c.field(pair.Name)
+		if !ok || !pair.Type.Assignable(other.Type) {
+			return false
+		}
+	}
+	return true
 }
 
 func (t *Struct) Comparable(o Type) bool {
-	_, ok := RootOf(o).(*Struct)
-	if !ok {
+	c, ok := RootOf(Unwrap(o)).(*Struct)
+	if !ok || len(*t) != len(*c) {
 		return false
 	}
-	// TODO
-	return false
+
+	for _, pair := range *t {
+		other, ok := c.field(pair.Name)
+		if !ok || !field(pair.Type) || !field(other.Type) || !pair.Type.Comparable(other.Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// field reports whether a struct can be compared with a field of the given
+// type. Optional fields may be absent and lists other than strings have no
+// equality.
+func field(t Type) bool {
+	switch RootOf(t).(type) {
+	case *Optional:
+		return false
+	case *List:
+		return IsString(t)
+	default:
+		return true
+	}
 }
 
 func (t *Struct) Convertible(o Type) bool {
-	// TODO
-	return t.Comparable(RootOf(o))
+	return Identical(t, RootOf(o))
 }
 
 func (t *Struct) Operable(o Type, op Operation) bool {
@@ -171,17 +305,25 @@ func (t *Template) Base() Type {
 }
 
 func (t *Template) Assignable(o Type) bool {
-	// TODO
-	return false
+	c, ok := RootOf(o).(*Template)
+	if !ok {
+		return false
+	}
+
+	return acceptsParams(t.In, c.In)
 }
 
 func (t *Template) Comparable(o Type) bool {
-	_, ok := RootOf(o).(*Template)
-	return ok
+	c, ok := RootOf(Unwrap(o)).(*Template)
+	if !ok {
+		return false
+	}
+
+	return identicalList(t.In, c.In)
 }
 
 func (t *Template) Convertible(o Type) bool {
-	return false
+	return Identical(t, RootOf(o))
 }
 
 func (t *Template) Operable(o Type, op Operation) bool {
@@ -219,19 +361,103 @@ func (t *Fn) Base() Type {
 }
 
 func (t *Fn) Assignable(o Type) bool {
-	// TODO
-	return false
+	c, ok := RootOf(o).(*Fn)
+	if !ok {
+		return false
+	}
+
+	return acceptsParams(t.In, c.In) && t.Out.Assignable(c.Out)
 }
 
 func (t *Fn) Comparable(o Type) bool {
-	_, ok := RootOf(o).(*Fn)
-	return ok
+	return false
 }
 
 func (t *Fn) Convertible(o Type) bool {
-	return false
+	return Identical(t, RootOf(o))
 }
 
 func (t *Fn) Operable(o Type, op Operation) bool {
 	return false
 }
+
+type Optional struct {
+	Type Type
+}
+
+func (t *Optional) String() string {
+	return fmt.Sprintf("%s?", t.Type.String())
+}
+
+func (t *Optional) IsRoot() bool {
+	return true
+}
+
+func (t *Optional) Base() Type {
+	return nil
+}
+
+// Index indexes the underlying type, the result is optional as well since the
+// host value may be absent.
+func (t *Optional) Index(v any) (Type, bool) {
+	indexer, ok := t.Type.(Indexer)
+	if !ok {
+		return Invalid, false
+	}
+
+	typ, ok := indexer.Index(v)
+	if !ok {
+		return Invalid, false
+	}
+	return NewOptional(typ), true
+}
+
+func (t *Optional) Assignable(o Type) bool {
+	c, ok := RootOf(o).(*Optional)
+	if !ok {
+		return t.Type.Assignable(o)
+	}
+
+	return t.Type.Assignable(c.Type)
+}
+
+func (t *Optional) Comparable(o Type) bool {
+	return t.Type.Comparable(Unwrap(o))
+}
+
+func (t *Optional) Convertible(o Type) bool {
+	return t.Type.Convertible(Unwrap(o))
+}
+
+func (t *Optional) Operable(o Type, op Operation) bool {
+	return false
+}
+
+// NewOptional wraps the given type, optional types are never nested so
+// wrapping an optional type returns it as is.
+func NewOptional(t Type) *Optional {
+	if o, ok := t.(*Optional); ok {
+		return o
+	}
+
+	return &Optional{
+		Type: t,
+	}
+}
+
+// IsOptional reports whether the root of the given type is optional.
+func IsOptional(t Type) bool {
+	_, ok := RootOf(t).(*Optional)
+	return ok
+}
+
+// Unwrap returns the underlying type of an optional type, other types are
+// returned as is.
+func Unwrap(t Type) Type {
+	o, ok := RootOf(t).(*Optional)
+	if !ok {
+		return t
+	}
+
+	return o.Type
+}
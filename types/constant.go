@@ -1,5 +1,11 @@
 package types
 
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
 type Constant struct {
 	name  string
 	canop bool
@@ -18,16 +24,19 @@ func (t *Constant) Base() Type {
 }
 
 func (t *Constant) Assignable(o Type) bool {
-	c, ok := o.(*Constant)
-	if !ok {
-		return false
+	if u, ok := o.(*Untyped); ok {
+		return Representable(t, u)
 	}
 
-	return t.name == c.name
+	return Identical(t, o)
 }
 
 func (t *Constant) Comparable(o Type) bool {
-	c, ok := RootOf(o).(*Constant)
+	if u, ok := RootOf(Unwrap(o)).(*Untyped); ok {
+		return Representable(t, u)
+	}
+
+	c, ok := RootOf(Unwrap(o)).(*Constant)
 	if !ok {
 		return false
 	}
@@ -43,10 +52,322 @@ func (t *Constant) Operable(o Type, op Operation) bool {
 		return false
 	}
 
-	// TODO
+	if op == Modulo && !IsInteger(t) {
+		return false
+	}
+
+	if u, ok := o.(*Untyped); ok {
+		return Representable(t, u)
+	}
+	return Identical(t, RootOf(o))
+}
+
+// Untyped is the type of numeric literals. Like go's untyped constants it
+// adopts the type of the other operand and falls back to int or f64 when
+// there is none.
+type Untyped struct {
+	Value float64
+	float bool
+	// alt holds the other values of a ternary whose branches are untyped,
+	// such an expression is not a constant
+	alt []float64
+}
+
+func (t *Untyped) String() string {
+	if t.float {
+		return "untyped float"
+	}
+	return "untyped int"
+}
+
+func (t *Untyped) IsRoot() bool {
+	return true
+}
+
+func (t *Untyped) Base() Type {
+	return nil
+}
+
+func (t *Untyped) IsFloat() bool {
+	return t.float
+}
+
+// IsConstant reports whether the expression has a single value, which is
+// Value
+func (t *Untyped) IsConstant() bool {
+	return len(t.alt) == 0
+}
+
+func (t *Untyped) values() []float64 {
+	return append([]float64{t.Value}, t.alt...)
+}
+
+func (t *Untyped) Assignable(o Type) bool {
+	c, ok := o.(*Untyped)
+	if !ok {
+		return false
+	}
+
+	return t.float || !c.float
+}
+
+func (t *Untyped) Comparable(o Type) bool {
+	if _, ok := RootOf(Unwrap(o)).(*Untyped); ok {
+		return true
+	}
+
+	return Unwrap(o).Comparable(t)
+}
+
+func (t *Untyped) Convertible(o Type) bool {
 	return t.Comparable(o)
 }
 
+func (t *Untyped) Operable(o Type, op Operation) bool {
+	if c, ok := o.(*Untyped); ok {
+		return op != Modulo || !(t.float || c.float)
+	}
+
+	return o.Operable(t, op)
+}
+
+func NewUntyped(value float64, float bool) *Untyped {
+	return &Untyped{
+		Value: value,
+		float: float,
+	}
+}
+
+// untyped returns an untyped expression that may take any of the given
+// values, duplicates are dropped
+func untyped(values []float64, float bool) *Untyped {
+	t := NewUntyped(values[0], float)
+	for _, v := range values[1:] {
+		if !slices.Contains(t.values(), v) {
+			t.alt = append(t.alt, v)
+		}
+	}
+	return t
+}
+
+// Negate returns the type of the negation of an untyped expression
+func Negate(t *Untyped) *Untyped {
+	values := t.values()
+	for i := range values {
+		values[i] = -values[i]
+	}
+	return untyped(values, t.float)
+}
+
+// Default returns the type an untyped constant takes when nothing else
+// determines it, other types are returned as is.
+func Default(t Type) Type {
+	u, ok := t.(*Untyped)
+	if !ok {
+		return t
+	}
+
+	if u.float {
+		return F64
+	}
+	return Int
+}
+
+// Unify returns the type of an expression that combines values of the two
+// given types, untyped constants adopt the type of the typed operand. Two
+// untyped ones give an untyped expression that may take the values of both.
+func Unify(a, b Type) Type {
+	au, aok := a.(*Untyped)
+	bu, bok := b.(*Untyped)
+
+	switch {
+	case aok && bok:
+		return untyped(append(au.values(), bu.values()...), au.float || bu.float)
+	case aok:
+		return b
+	default:
+		return a
+	}
+}
+
+// Fold computes the result of an arithmetic operation on two untyped
+// expressions, for every pair of their values. It reports false if the
+// operation cannot be evaluated.
+func Fold(a, b *Untyped, op Operation) (*Untyped, bool) {
+	float := a.float || b.float
+
+	values := []float64{}
+	for _, x := range a.values() {
+		for _, y := range b.values() {
+			value, ok := fold(x, y, op, float)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, value)
+		}
+	}
+
+	return untyped(values, float), true
+}
+
+func fold(a, b float64, op Operation, float bool) (float64, bool) {
+	switch op {
+	case Addition:
+		return a + b, true
+	case Subtraction:
+		return a - b, true
+	case Multiplication:
+		return a * b, true
+	case Division:
+		if b == 0 {
+			return 0, false
+		}
+
+		if !float {
+			return math.Trunc(a / b), true
+		}
+		return a / b, true
+	case Exponent:
+		return math.Pow(a, b), true
+	case Modulo:
+		if float || b == 0 {
+			return 0, false
+		}
+
+		return math.Mod(a, b), true
+	default:
+		return 0, false
+	}
+}
+
+type limit struct {
+	min, max float64
+}
+
+var limits = map[*Constant]limit{
+	I8:  {math.MinInt8, math.MaxInt8},
+	I16: {math.MinInt16, math.MaxInt16},
+	I32: {math.MinInt32, math.MaxInt32},
+	I64: {math.MinInt64, math.MaxInt64},
+	U8:  {0, math.MaxUint8},
+	U16: {0, math.MaxUint16},
+	U32: {0, math.MaxUint32},
+	U64: {0, math.MaxUint64},
+	F32: {-math.MaxFloat32, math.MaxFloat32},
+	F64: {-math.MaxFloat64, math.MaxFloat64},
+}
+
+// Representable reports whether the values of an untyped expression fit
+// into the given type without overflowing or being truncated.
+func Representable(t Type, u *Untyped) bool {
+	return Unrepresentable(t, u) == nil
+}
+
+// Unrepresentable returns the first value of an untyped expression that does
+// not fit into the given type, or nil if all of them do.
+func Unrepresentable(t Type, u *Untyped) *Untyped {
+	c, ok := RootOf(t).(*Constant)
+	l, known := limits[c]
+
+	for _, v := range u.values() {
+		switch {
+		case !ok || !known,
+			IsInteger(c) && v != math.Trunc(v),
+			v < l.min || v > l.max:
+			return NewUntyped(v, u.float)
+		}
+	}
+	return nil
+}
+
+// Overflows reports whether v is an untyped constant that the numeric type t
+// cannot represent.
+func Overflows(t, v Type) bool {
+	u, ok := v.(*Untyped)
+	if !ok || !IsNumeric(t) {
+		return false
+	}
+
+	return !Representable(t, u)
+}
+
+// FormatConstant formats the value of an untyped constant.
+func FormatConstant(u *Untyped) string {
+	return fmt.Sprintf("%g", u.Value)
+}
+
+// IsInteger reports whether the root of the given type is an integer.
+func IsInteger(t Type) bool {
+	switch c := RootOf(t).(type) {
+	case *Untyped:
+		return !c.float
+	case *Constant:
+		return c.canop && c != F32 && c != F64
+	default:
+		return false
+	}
+}
+
+// IsNumeric reports whether the root of the given type is a number.
+func IsNumeric(t Type) bool {
+	switch c := RootOf(t).(type) {
+	case *Untyped:
+		return true
+	case *Constant:
+		return c.canop
+	default:
+		return false
+	}
+}
+
+// Conversion is the type of the builtin numeric conversion fns like f64(x),
+// they convert any numeric value to their target type.
+type Conversion struct {
+	Target Type
+}
+
+func (t *Conversion) String() string {
+	return t.Target.String()
+}
+
+func (t *Conversion) IsRoot() bool {
+	return true
+}
+
+func (t *Conversion) Base() Type {
+	return nil
+}
+
+func (t *Conversion) Accepts(o Type) bool {
+	if u, ok := o.(*Untyped); ok {
+		return Representable(t.Target, u)
+	}
+
+	return IsNumeric(o)
+}
+
+func (t *Conversion) Assignable(o Type) bool {
+	return false
+}
+
+func (t *Conversion) Comparable(o Type) bool {
+	return false
+}
+
+func (t *Conversion) Convertible(o Type) bool {
+	return false
+}
+
+func (t *Conversion) Operable(o Type, op Operation) bool {
+	return false
+}
+
+func NewConversion(target Type) *Conversion {
+	return &Conversion{
+		Target: target,
+	}
+}
+
 var (
 	Invalid = &Constant{"invalid", false}
 
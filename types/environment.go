@@ -131,6 +131,33 @@ func (e Environment) ResolveType(expr ast.TypeExpr) (Type, error) {
 		}
 
 		return NewList(typ), err
+	case *ast.MapTypeExpr:
+		key, err := e.ResolveType(expr.Key)
+		if err != nil {
+			return NewMap(Invalid, Invalid), err
+		}
+
+		if !IsKeyable(key) {
+			return NewMap(Invalid, Invalid), &errs.TypeError{
+				Err:  errs.ErrInvalidMapKey,
+				Node: expr.Key,
+				Type: key,
+			}
+		}
+
+		value, err := e.ResolveType(expr.Value)
+		if err != nil {
+			return NewMap(key, Invalid), err
+		}
+
+		return NewMap(key, value), nil
+	case *ast.OptionalTypeExpr:
+		typ, err := e.ResolveType(expr.Type)
+		if err != nil {
+			return NewOptional(Invalid), err
+		}
+
+		return NewOptional(typ), err
 	default:
 		return Invalid, &errs.TypeError{
 			Err: errs.ErrInvalidType,
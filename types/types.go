@@ -1,5 +1,27 @@
 package types
 
+// Type is implemented by every lcl type. The relations between types are:
+//
+//   - t.Assignable(o) reports whether a value of type o can be used where a
+//     value of type t is expected. Builtin and named types are nominal, only
+//     the same type is assignable to them. Unnamed composite types are
+//     structural and accept named types by their underlying type. Lists,
+//     optionals and map values are covariant since lcl values are immutable.
+//     Structs use width and depth subtyping, a struct is assignable to another
+//     one if it has at least the same fields and each of them is assignable.
+//     Fn and template params are contravariant and fn results are covariant.
+//   - t.Comparable(o) reports whether the two values can be compared with the
+//     equality operators. Only the underlying types matter, maps and fns are
+//     never comparable and lists only are if they hold runes, so are structs
+//     whose fields are not optional or lists other than strings. An optional
+//     value is comparable with the type it wraps, the checker still asks for a
+//     default before comparing. Ordering is reported by Ordered.
+//   - t.Operable(o, op) reports whether the arithmetic operation op can be
+//     applied to the two values. Numbers of the same type support every
+//     operation, modulo is only defined on integers and strings can only be
+//     concatenated with other strings.
+//   - t.Convertible(o) reports whether the underlying types of t and o are
+//     identical so a value can be converted from one to the other.
 type Type interface {
 	String() string
 	IsRoot() bool
@@ -23,9 +45,11 @@ const (
 	Division
 	Multiplication
 	Exponent
+	Modulo
 )
 
 type Extended struct {
+	pkg  string
 	name string
 	base Type
 }
@@ -34,6 +58,12 @@ func (t *Extended) String() string {
 	return t.name
 }
 
+// Package returns the name of the package the type is declared in, it is
+// empty for builtin types.
+func (t *Extended) Package() string {
+	return t.pkg
+}
+
 func (t *Extended) IsRoot() bool {
 	return false
 }
@@ -43,12 +73,11 @@ func (t *Extended) Base() Type {
 }
 
 func (t *Extended) Assignable(o Type) bool {
-	c, ok := o.(*Extended)
-	if !ok {
-		return false
+	if _, ok := o.(*Untyped); ok {
+		return t.base.Assignable(o)
 	}
 
-	return t.name == c.name
+	return Identical(t, o)
 }
 
 func (t *Extended) Comparable(o Type) bool {
@@ -56,10 +85,14 @@ func (t *Extended) Comparable(o Type) bool {
 }
 
 func (t *Extended) Convertible(o Type) bool {
-	return t.base.Comparable(RootOf(o))
+	return t.base.Convertible(RootOf(o))
 }
 
 func (t *Extended) Operable(o Type, op Operation) bool {
+	if IsString(t) {
+		return op == Addition && IsString(o)
+	}
+
 	return t.base.Operable(o, op)
 }
 
@@ -69,7 +102,13 @@ type ExtIndexer struct {
 }
 
 func New(name string, base Type) Type {
+	return NewNamed("", name, base)
+}
+
+// NewNamed creates a type that is declared in the package pkg.
+func NewNamed(pkg, name string, base Type) Type {
 	var t Type = &Extended{
+		pkg:  pkg,
 		name: name,
 		base: base,
 	}
@@ -85,6 +124,123 @@ func New(name string, base Type) Type {
 	return t
 }
 
+// Named returns the declaration of a named type.
+func Named(t Type) (*Extended, bool) {
+	switch t := t.(type) {
+	case *Extended:
+		return t, true
+	case *ExtIndexer:
+		return Named(t.Type)
+	default:
+		return nil, false
+	}
+}
+
+// Identical reports whether the two types are the same type, named types
+// are identical if they have the same package, name and identical underlying
+// types.
+func Identical(a, b Type) bool {
+	if a == b {
+		return true
+	}
+
+	an, aok := Named(a)
+	bn, bok := Named(b)
+	if aok || bok {
+		return aok && bok && an.pkg == bn.pkg && an.name == bn.name && Identical(an.base, bn.base)
+	}
+
+	switch a := a.(type) {
+	case *List:
+		c, ok := b.(*List)
+		return ok && Identical(a.Type, c.Type)
+	case *Map:
+		c, ok := b.(*Map)
+		return ok && Identical(a.Key, c.Key) && Identical(a.Value, c.Value)
+	case *Optional:
+		c, ok := b.(*Optional)
+		return ok && Identical(a.Type, c.Type)
+	case *Struct:
+		c, ok := b.(*Struct)
+		if !ok || len(*a) != len(*c) {
+			return false
+		}
+
+		for i, pair := range *a {
+			other := (*c)[i]
+			if pair.Name != other.Name || !Identical(pair.Type, other.Type) {
+				return false
+			}
+		}
+		return true
+	case *Template:
+		c, ok := b.(*Template)
+		return ok && identicalList(a.In, c.In)
+	case *Fn:
+		c, ok := b.(*Fn)
+		return ok && identicalList(a.In, c.In) && Identical(a.Out, c.Out)
+	default:
+		return false
+	}
+}
+
+func identicalList(a, b []Type) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i, typ := range a {
+		if !Identical(typ, b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// acceptsParams reports whether a callable with the params in can be used
+// where a callable with the params expected is required.
+func acceptsParams(expected, in []Type) bool {
+	if len(expected) != len(in) {
+		return false
+	}
+
+	for i, typ := range in {
+		if !typ.Assignable(expected[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ordered reports whether two values can be compared with the ordering
+// operators, only numbers and text have an order.
+func Ordered(t, o Type) bool {
+	return t.Comparable(o) && ordered(t) && ordered(o)
+}
+
+func ordered(t Type) bool {
+	if list, ok := RootOf(Unwrap(t)).(*List); ok {
+		return isText(list)
+	}
+	return IsNumeric(Unwrap(t))
+}
+
+// IsString reports whether the given type is the builtin string type or a type
+// based on it.
+func IsString(t Type) bool {
+	str, _ := Named(String)
+	for t != nil {
+		if n, ok := Named(t); ok && n == str {
+			return true
+		}
+		if t.IsRoot() {
+			return false
+		}
+		t = t.Base()
+	}
+	return false
+}
+
 func RootOf(t Type) Type {
 	if t.IsRoot() {
 		return t
@@ -123,6 +123,27 @@ func TestEnvironment(t *testing.T) {
 			},
 			Out: data,
 		},
+		&ResolveCase{
+			In: &ast.MapTypeExpr{
+				Key:   &ast.IdentExpr{Value: "string"},
+				Value: &ast.IdentExpr{Value: "Time"},
+			},
+			Out: types.NewMap(types.String, time),
+		},
+		&ResolveCase{
+			In: &ast.MapTypeExpr{
+				Key:   &ast.ListTypeExpr{Type: &ast.IdentExpr{Value: "bool"}},
+				Value: &ast.IdentExpr{Value: "Time"},
+			},
+			Out: types.NewMap(types.Invalid, types.Invalid),
+			Err: errs.ErrInvalidMapKey,
+		},
+		&ResolveCase{
+			In: &ast.OptionalTypeExpr{
+				Type: &ast.IdentExpr{Value: "string"},
+			},
+			Out: types.NewOptional(types.String),
+		},
 		&ResolveCase{
 			In: &ast.ImportExpr{
 				Left:  &ast.IdentExpr{Value: "Invalid"},
@@ -199,6 +220,328 @@ func TestCompare(t *testing.T) {
 			Left:   types.NewList(types.New("U32", types.U32)),
 			Result: true,
 		},
+		&CompareCase{
+			Left:   types.NewOptional(types.String),
+			Right:  types.String,
+			Result: true,
+		},
+		&CompareCase{
+			Left:   types.NewOptional(types.Int),
+			Right:  types.NewOptional(types.I32),
+			Result: true,
+		},
+		&CompareCase{
+			Left:   types.NewOptional(types.Int),
+			Right:  types.String,
+			Result: false,
+		},
+	}
+	test.Run(t, tests)
+}
+
+type RelationCase struct {
+	Left        types.Type
+	Right       types.Type
+	Assignable  bool
+	Comparable  bool
+	Ordered     bool
+	Convertible bool
+}
+
+func (c *RelationCase) Run(assert *assert.Assertions) {
+	pair := c.Left.String() + " <- " + c.Right.String()
+	assert.Equal(c.Assignable, c.Left.Assignable(c.Right), "assignable "+pair)
+	assert.Equal(c.Comparable, c.Left.Comparable(c.Right), "comparable "+pair)
+	assert.Equal(c.Ordered, types.Ordered(c.Left, c.Right), "ordered "+pair)
+	assert.Equal(c.Convertible, c.Left.Convertible(c.Right), "convertible "+pair)
+}
+
+func TestRelations(t *testing.T) {
+	name := types.NewStruct(types.NewPair(0, "name", types.String))
+	user := types.New("User", types.NewStruct(
+		types.NewPair(0, "name", types.String),
+		types.NewPair(1, "age", types.U8),
+	))
+	admin := types.New("Admin", types.NewStruct(
+		types.NewPair(0, "name", types.String),
+		types.NewPair(1, "age", types.U8),
+	))
+	str := types.New("Str", types.NewList(types.Rune))
+	id := types.New("Id", types.U64)
+	greet := types.NewTemplate([]types.Type{name})
+	fn := &types.Fn{In: []types.Type{name}, Out: types.String}
+
+	tests := []test.Runner{
+		// builtins
+		&RelationCase{Left: types.Bool, Right: types.Bool, Assignable: true, Comparable: true, Convertible: true},
+		&RelationCase{Left: types.I32, Right: types.I64},
+		&RelationCase{Left: types.I32, Right: types.Int, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: types.Int, Right: types.I32, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: types.Int, Right: types.Int, Assignable: true, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: types.String, Right: types.String, Assignable: true, Comparable: true, Ordered: true, Convertible: true},
+		// named types
+		&RelationCase{Left: id, Right: id, Assignable: true, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: id, Right: types.U64, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: types.U64, Right: id, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: str, Right: types.String, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: types.String, Right: str, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: user, Right: admin, Comparable: true, Convertible: true},
+		&RelationCase{Left: id, Right: types.NewNamed("other", "Id", types.U64), Comparable: true, Ordered: true, Convertible: true},
+		// lists
+		&RelationCase{Left: types.NewList(types.Rune), Right: types.String, Assignable: true, Comparable: true, Ordered: true, Convertible: true},
+		&RelationCase{Left: types.NewList(name), Right: types.NewList(user), Assignable: true},
+		&RelationCase{Left: types.NewList(user), Right: types.NewList(name)},
+		&RelationCase{Left: types.NewList(types.Int), Right: types.NewList(types.I32), Convertible: true},
+		&RelationCase{Left: types.NewList(types.String), Right: types.NewList(types.String), Assignable: true, Convertible: true},
+		// maps
+		&RelationCase{Left: types.NewMap(types.String, name), Right: types.NewMap(types.String, user), Assignable: true},
+		&RelationCase{Left: types.NewMap(types.String, types.Int), Right: types.NewMap(types.Int, types.Int)},
+		// optionals
+		&RelationCase{Left: types.NewOptional(name), Right: user, Assignable: true},
+		&RelationCase{Left: types.NewOptional(name), Right: types.NewOptional(user), Assignable: true},
+		&RelationCase{Left: name, Right: types.NewOptional(name), Comparable: true},
+		&RelationCase{Left: types.NewOptional(types.Int), Right: types.Int, Assignable: true, Comparable: true, Ordered: true, Convertible: true},
+		// structs
+		&RelationCase{Left: name, Right: name, Assignable: true, Comparable: true, Convertible: true},
+		&RelationCase{Left: name, Right: user, Assignable: true},
+		&RelationCase{Left: user, Right: name},
+		&RelationCase{Left: user, Right: user, Assignable: true, Comparable: true, Convertible: true},
+		&RelationCase{
+			Left:        types.NewStruct(types.NewPair(0, "a", types.Int), types.NewPair(1, "b", types.Bool)),
+			Right:       types.NewStruct(types.NewPair(0, "b", types.Bool), types.NewPair(1, "a", types.Int)),
+			Assignable:  true,
+			Comparable:  true,
+			Convertible: false,
+		},
+		&RelationCase{
+			Left:        types.NewStruct(types.NewPair(0, "tags", types.NewList(types.String))),
+			Right:       types.NewStruct(types.NewPair(0, "tags", types.NewList(types.String))),
+			Assignable:  true,
+			Convertible: true,
+		},
+		&RelationCase{
+			Left:        types.NewStruct(types.NewPair(0, "nick", types.NewOptional(types.String))),
+			Right:       types.NewStruct(types.NewPair(0, "nick", types.NewOptional(types.String))),
+			Assignable:  true,
+			Convertible: true,
+		},
+		&RelationCase{
+			Left:  types.NewStruct(types.NewPair(0, "user", name)),
+			Right: types.NewStruct(types.NewPair(0, "user", user), types.NewPair(1, "id", id)),
+			// depth subtyping
+			Assignable: true,
+		},
+		// templates
+		&RelationCase{Left: greet, Right: greet, Assignable: true, Comparable: true, Convertible: true},
+		&RelationCase{Left: types.NewTemplate([]types.Type{user}), Right: greet, Assignable: true},
+		&RelationCase{Left: greet, Right: types.NewTemplate([]types.Type{user})},
+		&RelationCase{Left: greet, Right: types.NewTemplate([]types.Type{})},
+		&RelationCase{Left: greet, Right: fn},
+		// fns
+		&RelationCase{Left: fn, Right: fn, Assignable: true, Convertible: true},
+		&RelationCase{Left: &types.Fn{In: []types.Type{user}, Out: types.NewList(types.Rune)}, Right: fn, Assignable: true},
+		&RelationCase{Left: fn, Right: &types.Fn{In: []types.Type{user}, Out: types.String}},
+		&RelationCase{Left: fn, Right: &types.Fn{In: []types.Type{name}, Out: types.Int}},
+		&RelationCase{Left: fn, Right: greet},
+	}
+	test.Run(t, tests)
+}
+
+type RepresentCase struct {
+	Type   types.Type
+	Value  *types.Untyped
+	Result bool
+}
+
+func (c *RepresentCase) Run(assert *assert.Assertions) {
+	assert.Equal(c.Result, types.Representable(c.Type, c.Value), c.Type.String()+" "+types.FormatConstant(c.Value))
+}
+
+func TestUntyped(t *testing.T) {
+	assert := assert.New(t)
+	one := types.NewUntyped(1, false)
+	half := types.NewUntyped(0.5, true)
+
+	assert.Equal(types.Int, types.Default(one))
+	assert.Equal(types.F64, types.Default(half))
+	assert.Equal(types.U8, types.Unify(one, types.U8))
+	assert.Equal(types.U8, types.Unify(types.U8, one))
+	assert.Equal(types.NewUntyped(1, true), types.Unify(one, types.NewUntyped(1, true)))
+	mixed := types.Unify(one, half).(*types.Untyped)
+	assert.True(mixed.IsFloat())
+	assert.False(mixed.IsConstant())
+	assert.False(types.Int.Assignable(mixed))
+	branches := types.Unify(one, types.NewUntyped(300, false)).(*types.Untyped)
+	assert.False(types.U8.Assignable(branches))
+	assert.Equal("300", types.FormatConstant(types.Unrepresentable(types.U8, branches)))
+	assert.True(types.U16.Assignable(branches))
+	assert.True(types.U8.Assignable(one))
+	assert.True(types.Int.Assignable(one))
+	assert.False(types.Int.Assignable(half))
+	assert.False(types.String.Assignable(one))
+	assert.True(types.F32.Operable(half, types.Addition))
+	assert.True(one.Operable(types.Int, types.Addition))
+	assert.False(one.Operable(types.Bool, types.Addition))
+	assert.True(types.IsInteger(one))
+	assert.False(types.IsInteger(half))
+	assert.True(types.Overflows(types.U8, types.NewUntyped(256, false)))
+	assert.False(types.Overflows(types.String, types.NewUntyped(256, false)))
+
+	folded, ok := types.Fold(types.NewUntyped(7, false), types.NewUntyped(2, false), types.Division)
+	assert.True(ok)
+	assert.Equal(types.NewUntyped(3, false), folded)
+	folded, ok = types.Fold(types.NewUntyped(7, false), types.NewUntyped(2, true), types.Division)
+	assert.True(ok)
+	assert.Equal(types.NewUntyped(3.5, true), folded)
+	_, ok = types.Fold(one, types.NewUntyped(0, false), types.Division)
+	assert.False(ok)
+	folded, ok = types.Fold(branches, types.NewUntyped(-256, false), types.Addition)
+	assert.True(ok)
+	assert.False(types.U8.Assignable(folded))
+	assert.Equal("-255", types.FormatConstant(types.Unrepresentable(types.U8, folded)))
+	assert.Equal("-44", types.FormatConstant(types.Unrepresentable(types.U8, types.Negate(folded))))
+
+	tests := []test.Runner{
+		&RepresentCase{Type: types.U8, Value: types.NewUntyped(255, false), Result: true},
+		&RepresentCase{Type: types.U8, Value: types.NewUntyped(256, false), Result: false},
+		&RepresentCase{Type: types.U8, Value: types.NewUntyped(-1, false), Result: false},
+		&RepresentCase{Type: types.I8, Value: types.NewUntyped(-128, false), Result: true},
+		&RepresentCase{Type: types.I8, Value: types.NewUntyped(128, false), Result: false},
+		&RepresentCase{Type: types.Int, Value: types.NewUntyped(2147483648, false), Result: false},
+		&RepresentCase{Type: types.I64, Value: types.NewUntyped(2147483648, false), Result: true},
+		&RepresentCase{Type: types.Int, Value: types.NewUntyped(1.5, true), Result: false},
+		&RepresentCase{Type: types.F32, Value: types.NewUntyped(1.5, true), Result: true},
+		&RepresentCase{Type: types.F32, Value: types.NewUntyped(1e39, true), Result: false},
+		&RepresentCase{Type: types.F64, Value: types.NewUntyped(1e39, true), Result: true},
+		&RepresentCase{Type: types.Bool, Value: types.NewUntyped(1, false), Result: false},
+		&RepresentCase{Type: types.New("Age", types.U8), Value: types.NewUntyped(18, false), Result: true},
+	}
+	test.Run(t, tests)
+}
+
+type IndexCase struct {
+	Host  types.Type
+	Index any
+	Out   types.Type
+	Ok    bool
+}
+
+func (c *IndexCase) Run(assert *assert.Assertions) {
+	indexer, ok := c.Host.(types.Indexer)
+	assert.True(ok)
+	typ, ok := indexer.Index(c.Index)
+	assert.Equal(c.Ok, ok)
+	assert.Equal(c.Out, typ)
+}
+
+func TestOperable(t *testing.T) {
+	assert := assert.New(t)
+	name := types.New("Name", types.String)
+
+	assert.True(types.IsString(types.String))
+	assert.True(types.IsString(name))
+	assert.False(types.IsString(types.NewList(types.Rune)))
+	assert.True(types.String.Operable(types.String, types.Addition))
+	assert.True(name.Operable(types.String, types.Addition))
+	assert.False(types.String.Operable(types.String, types.Subtraction))
+	assert.False(types.String.Operable(types.Rune, types.Addition))
+	assert.False(types.NewList(types.Int).Operable(types.NewList(types.Int), types.Addition))
+	assert.True(types.Int.Operable(types.Int, types.Modulo))
+	assert.False(types.F64.Operable(types.F64, types.Modulo))
+	assert.False(types.NewUntyped(1.5, true).Operable(types.NewUntyped(1, false), types.Modulo))
+}
+
+func TestMap(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("map[string]int", types.NewMap(types.String, types.Int).String())
+	assert.True(types.IsKeyable(types.String))
+	assert.True(types.IsKeyable(types.New("Id", types.U64)))
+	assert.False(types.IsKeyable(types.NewList(types.Int)))
+	assert.False(types.IsKeyable(types.NewStruct()))
+	assert.True(types.NewMap(types.Int, types.Bool).Assignable(types.NewMap(types.Int, types.Bool)))
+	assert.False(types.NewMap(types.Int, types.Bool).Comparable(types.NewMap(types.Int, types.Bool)))
+}
+
+func TestOptional(t *testing.T) {
+	assert := assert.New(t)
+	user := types.NewStruct(
+		types.NewPair(0, "name", types.String),
+		types.NewPair(1, "nickname", types.NewOptional(types.String)),
+	)
+
+	nickname := types.NewOptional(types.String)
+	assert.Same(nickname, types.NewOptional(nickname))
+	assert.True(types.IsOptional(types.New("Nickname", types.NewOptional(types.String))))
+	assert.False(types.IsOptional(types.String))
+	assert.Equal(types.String, types.Unwrap(types.NewOptional(types.String)))
+	assert.Equal(types.String, types.Unwrap(types.String))
+	assert.True(types.NewOptional(types.Int).Assignable(types.Int))
+	assert.True(types.NewOptional(types.Int).Assignable(types.NewOptional(types.Int)))
+	assert.False(types.Int.Assignable(types.NewOptional(types.Int)))
+	assert.False(types.NewOptional(types.Int).Operable(types.Int, types.Addition))
+
+	tests := []test.Runner{
+		&IndexCase{
+			Host:  user,
+			Index: "nickname",
+			Out:   types.NewOptional(types.String),
+			Ok:    true,
+		},
+		&IndexCase{
+			Host:  types.NewOptional(user),
+			Index: "name",
+			Out:   types.NewOptional(types.String),
+			Ok:    true,
+		},
+		&IndexCase{
+			Host:  types.NewOptional(user),
+			Index: "nickname",
+			Out:   types.NewOptional(types.String),
+			Ok:    true,
+		},
+		&IndexCase{
+			Host:  types.NewOptional(types.NewList(types.Int)),
+			Index: 0,
+			Out:   types.NewOptional(types.Int),
+			Ok:    true,
+		},
+		&IndexCase{
+			Host:  types.NewMap(types.String, types.Int),
+			Index: types.String,
+			Out:   types.Int,
+			Ok:    true,
+		},
+		&IndexCase{
+			Host:  types.NewMap(types.String, types.Int),
+			Index: "member",
+			Out:   types.Invalid,
+			Ok:    false,
+		},
+		&IndexCase{
+			Host:  types.NewMap(types.U8, types.Bool),
+			Index: types.Int,
+			Out:   types.Invalid,
+			Ok:    false,
+		},
+		&IndexCase{
+			Host:  types.NewList(types.Bool),
+			Index: types.U8,
+			Out:   types.Bool,
+			Ok:    true,
+		},
+		&IndexCase{
+			Host:  types.NewList(types.Bool),
+			Index: types.F32,
+			Out:   types.Invalid,
+			Ok:    false,
+		},
+		&IndexCase{
+			Host:  types.NewOptional(types.Bool),
+			Index: 0,
+			Out:   types.Invalid,
+			Ok:    false,
+		},
 	}
 	test.Run(t, tests)
 }
@@ -0,0 +1,171 @@
+package xliff
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/ir"
+)
+
+type Config struct {
+	version Version
+	file    string
+}
+
+// WithVersion sets the version of the documents, XLIFF 1.2 by default
+func WithVersion(version Version) func(*Config) {
+	return func(c *Config) {
+		c.version = version
+	}
+}
+
+// WithOriginal sets the file the documents refer to as their original
+func WithOriginal(file string) func(*Config) {
+	return func(c *Config) {
+		c.file = file
+	}
+}
+
+type Generator struct {
+	config *Config
+	ir     *ir.IR
+}
+
+// Files returns a document for every locale but the source one named after
+// its tag.
+func (g *Generator) Files() (map[string][]byte, error) {
+	if len(g.ir.Locales) == 0 {
+		return nil, errors.New("package declares no locales")
+	}
+
+	files := map[string][]byte{}
+	for _, locale := range g.ir.Locales[1:] {
+		buf := &bytes.Buffer{}
+		if err := g.Generate(buf, locale); err != nil {
+			return nil, err
+		}
+		files[locale.Tag.String()+".xlf"] = buf.Bytes()
+	}
+
+	return files, nil
+}
+
+// Generate writes the document of a locale. Its sources are the fields of the
+// source locale, the first one the package declares, written back as lcl
+// source and its targets are the fields of the locale. Messages without a
+// source field are left out and the ones without a target are left
+// untranslated.
+func (g *Generator) Generate(w io.Writer, locale ir.Locale) error {
+	if len(g.ir.Locales) == 0 {
+		return errors.New("package declares no locales")
+	}
+
+	source := g.ir.Locales[0]
+
+	file := &File{
+		Version:        g.config.version,
+		Original:       g.config.file,
+		SourceLanguage: source.Tag.String(),
+		TargetLanguage: locale.Tag.String(),
+	}
+
+	var section func(s *ir.Section, path string)
+	section = func(s *ir.Section, path string) {
+		for _, message := range s.Messages {
+			value := message.Values[source.Tag]
+			if value == nil {
+				continue
+			}
+
+			ids := &numbering{ids: map[string][]string{}, used: map[string]int{}}
+			unit := &Unit{ID: path + message.Name, Source: parts(value, ids.source)}
+			if value := message.Values[locale.Tag]; value != nil {
+				unit.Target = parts(value, ids.target)
+			}
+			if len(message.Comment) > 0 {
+				unit.Notes = strings.Split(message.Comment, "\n")
+			}
+
+			file.Units = append(file.Units, unit)
+		}
+
+		for _, sub := range s.Sections {
+			section(sub, path+sub.Name+".")
+		}
+	}
+
+	for _, s := range g.ir.Sections {
+		section(s, s.Name+".")
+	}
+
+	return file.Write(w)
+}
+
+// parts splits the value of a field into text and placeholders, every
+// expression interpolated in a template is a placeholder and so is a value
+// that is not a string
+func parts(value ir.Expr, id func(code string) string) []Part {
+	list := content{}
+	placeholder := func(e ir.Expr) {
+		code := "{" + ir.Source(e) + "}"
+		list = append(list, Part{ID: id(code), Code: code})
+	}
+
+	switch value := value.(type) {
+	case *ir.Template:
+		for _, segment := range value.Segments {
+			if segment.Value == nil {
+				list.text(segment.Text)
+			} else {
+				placeholder(segment.Value)
+			}
+		}
+	case *ir.Literal:
+		if text, ok := value.Value.(string); ok {
+			list.text(text)
+		} else {
+			placeholder(value)
+		}
+	default:
+		placeholder(value)
+	}
+
+	return list
+}
+
+// numbering gives ids to the placeholders of a unit, the placeholders of its
+// target take the ids of the ones of its source with the same code in order
+type numbering struct {
+	next int
+	ids  map[string][]string
+	used map[string]int
+}
+
+func (n *numbering) source(code string) string {
+	n.next++
+	id := strconv.Itoa(n.next)
+	n.ids[code] = append(n.ids[code], id)
+	return id
+}
+
+func (n *numbering) target(code string) string {
+	if i := n.used[code]; i < len(n.ids[code]) {
+		n.used[code]++
+		return n.ids[code][i]
+	}
+
+	n.next++
+	return strconv.Itoa(n.next)
+}
+
+func New(out *ir.IR, options ...func(*Config)) *Generator {
+	config := &Config{version: Version12}
+	for _, option := range options {
+		option(config)
+	}
+
+	return &Generator{config: config, ir: out}
+}
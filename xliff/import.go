@@ -0,0 +1,146 @@
+package xliff
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+	"github.com/CanPacis/lcl/ir"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/parser/ast"
+	"github.com/CanPacis/lcl/parser/printer"
+	"github.com/CanPacis/lcl/parser/token"
+)
+
+// Import writes the targets of a document into the fields of a locale in a
+// source tree. Placeholders are read back from the codes of the source ones
+// with the same id, a target must keep every placeholder of its source and
+// may only add ones it carries the code of. Untranslated units and targets
+// the locale already has are skipped, units that cannot be imported are
+// reported together once the rest is written.
+func Import(tree *ast.File, locale ir.Locale, file *File) error {
+	entries := ast.Entries(tree)
+	failures := []error{}
+
+	for _, unit := range file.Units {
+		if len(unit.Target) == 0 {
+			continue
+		}
+
+		fail := func(err error, value string) {
+			failures = append(failures, &errs.ImportError{
+				Err:    err,
+				Format: "xliff",
+				Path:   unit.ID,
+				Locale: locale.Name,
+				Value:  value,
+			})
+		}
+
+		entry, ok := entries[unit.ID]
+		if !ok {
+			fail(errs.ErrUnknownMessage, "")
+			continue
+		}
+
+		codes := map[string]string{}
+		for _, part := range unit.Source {
+			if part.IsPlaceholder() {
+				codes[part.ID] = part.Code
+			}
+		}
+
+		value, err := target(unit, codes)
+		if err != nil {
+			fail(errs.ErrUnknownPlaceholder, err.Error())
+			continue
+		}
+
+		current := ast.GetField(entry, locale.Name)
+		if current != nil && printer.String(current) == printer.String(value) {
+			continue
+		}
+
+		kept := map[string]bool{}
+		for _, part := range unit.Target {
+			kept[part.ID] = true
+		}
+		missing := false
+		for _, part := range unit.Source {
+			if part.IsPlaceholder() && !kept[part.ID] {
+				fail(errs.ErrMissingPlaceholder, part.Code)
+				missing = true
+			}
+		}
+		if missing {
+			continue
+		}
+
+		ast.SetField(entry, locale.Name, value)
+	}
+
+	return errors.Join(failures...)
+}
+
+// target returns the value of the target of a unit, codes are the codes of
+// the placeholders of its source by their ids. The error is the placeholder
+// that cannot be read back.
+func target(unit *Unit, codes map[string]string) (ast.Expr, error) {
+	placeholders := false
+	for _, part := range unit.Target {
+		placeholders = placeholders || part.IsPlaceholder()
+	}
+
+	if !placeholders {
+		text := strings.Builder{}
+		for _, part := range unit.Target {
+			text.WriteString(part.Text)
+		}
+		return ast.NewText(text.String()), nil
+	}
+
+	exprs := []ast.Expr{}
+	for _, part := range unit.Target {
+		if !part.IsPlaceholder() {
+			exprs = append(exprs, &ast.StringLitExpr{
+				Node:  ast.NewNode(ast.StringLitExprNode, token.Position{}, token.Position{}),
+				Value: part.Text,
+			})
+			continue
+		}
+
+		code, ok := codes[part.ID]
+		if !ok {
+			code = part.Code
+		}
+		if len(code) == 0 {
+			return nil, errors.New(part.ID)
+		}
+		expr, err := expression(code)
+		if err != nil {
+			return nil, errors.New(code)
+		}
+		exprs = append(exprs, expr)
+	}
+
+	return &ast.TemplateLitExpr{
+		Node:  ast.NewNode(ast.TemplateLitExprNode, token.Position{}, token.Position{}),
+		Value: exprs,
+	}, nil
+}
+
+// expression parses the code of a placeholder
+func expression(code string) (expr ast.Expr, err error) {
+	// The parser panics on some malformed input
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	if !strings.HasPrefix(code, "{") || !strings.HasSuffix(code, "}") {
+		return nil, errors.New("placeholder is not an interpolation")
+	}
+	return parser.ParseExpr(parser.NewFile("placeholder", strings.NewReader(code[1:len(code)-1])))
+}
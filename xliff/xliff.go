@@ -0,0 +1,409 @@
+// Package xliff converts the messages of a package to XLIFF 1.2 and 2.0
+// documents and back. Every message is a unit whose id is its path, the
+// expressions interpolated in templates are written as placeholders that
+// carry their source so translators can move them but cannot change them.
+package xliff
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/CanPacis/lcl/errs"
+)
+
+type Version string
+
+const (
+	Version12 Version = "1.2"
+	Version20 Version = "2.0"
+)
+
+// Part is a run of text or a placeholder of a source or a target
+type Part struct {
+	Text string
+	// ID is the id of a placeholder, parts without one are text. Placeholders
+	// of a target share their ids with the ones of the source they stand for.
+	ID string
+	// Code is the expression a placeholder stands for as it is written in a
+	// template, braces included
+	Code string
+
+	// ref is the data an XLIFF 2.0 placeholder refers to until it is resolved
+	ref string
+}
+
+func (p Part) IsPlaceholder() bool {
+	return len(p.ID) > 0
+}
+
+type Unit struct {
+	ID     string
+	Notes  []string
+	Source []Part
+	// Target is nil if the unit is not translated
+	Target []Part
+}
+
+type File struct {
+	Version        Version
+	Original       string
+	SourceLanguage string
+	TargetLanguage string
+	Units          []*Unit
+}
+
+// Write writes the document in its version, XLIFF 1.2 placeholders are <x>
+// elements with their code as equiv-text and XLIFF 2.0 ones are <ph> elements
+// referring to the original data of their unit.
+func (f *File) Write(w io.Writer) error {
+	b := &writer{}
+	b.WriteString(xml.Header)
+
+	switch f.Version {
+	case Version12:
+		f.write12(b)
+	case Version20:
+		f.write20(b)
+	default:
+		return fmt.Errorf("unknown xliff version '%s'", f.Version)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (f *File) write12(b *writer) {
+	b.line(0, `<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">`)
+	b.line(1, `<file original=%s datatype="plaintext" source-language=%s target-language=%s>`, attr(f.Original), attr(f.SourceLanguage), attr(f.TargetLanguage))
+	b.line(2, "<body>")
+
+	for _, unit := range f.Units {
+		b.line(3, `<trans-unit id=%s xml:space="preserve">`, attr(unit.ID))
+		b.line(4, "<source>%s</source>", inline12(unit.Source))
+		if unit.Target != nil {
+			b.line(4, `<target state="translated">%s</target>`, inline12(unit.Target))
+		}
+		for _, note := range unit.Notes {
+			b.line(4, "<note>%s</note>", text(note))
+		}
+		b.line(3, "</trans-unit>")
+	}
+
+	b.line(2, "</body>")
+	b.line(1, "</file>")
+	b.line(0, "</xliff>")
+}
+
+func (f *File) write20(b *writer) {
+	b.line(0, `<xliff version="2.0" xmlns="urn:oasis:names:tc:xliff:document:2.0" srcLang=%s trgLang=%s>`, attr(f.SourceLanguage), attr(f.TargetLanguage))
+	b.line(1, `<file id="f1" original=%s>`, attr(f.Original))
+
+	for _, unit := range f.Units {
+		b.line(2, `<unit id=%s xml:space="preserve">`, attr(unit.ID))
+
+		if len(unit.Notes) > 0 {
+			b.line(3, "<notes>")
+			for _, note := range unit.Notes {
+				b.line(4, "<note>%s</note>", text(note))
+			}
+			b.line(3, "</notes>")
+		}
+
+		// Placeholders with the same code refer to the same data
+		refs := map[string]string{}
+		codes := []string{}
+		for _, part := range slices.Concat(unit.Source, unit.Target) {
+			if _, ok := refs[part.Code]; part.IsPlaceholder() && !ok {
+				refs[part.Code] = "d" + strconv.Itoa(len(codes)+1)
+				codes = append(codes, part.Code)
+			}
+		}
+		if len(codes) > 0 {
+			b.line(3, "<originalData>")
+			for _, code := range codes {
+				b.line(4, "<data id=%s>%s</data>", attr(refs[code]), text(code))
+			}
+			b.line(3, "</originalData>")
+		}
+
+		if unit.Target != nil {
+			b.line(3, `<segment state="translated">`)
+		} else {
+			b.line(3, "<segment>")
+		}
+		b.line(4, "<source>%s</source>", inline20(unit.Source, refs))
+		if unit.Target != nil {
+			b.line(4, "<target>%s</target>", inline20(unit.Target, refs))
+		}
+		b.line(3, "</segment>")
+
+		b.line(2, "</unit>")
+	}
+
+	b.line(1, "</file>")
+	b.line(0, "</xliff>")
+}
+
+type writer struct {
+	strings.Builder
+}
+
+func (w *writer) line(depth int, format string, args ...any) {
+	w.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(w, format, args...)
+	w.WriteString("\n")
+}
+
+func inline12(parts []Part) string {
+	b := strings.Builder{}
+	for _, part := range parts {
+		if part.IsPlaceholder() {
+			fmt.Fprintf(&b, "<x id=%s equiv-text=%s/>", attr(part.ID), attr(part.Code))
+		} else {
+			b.WriteString(text(part.Text))
+		}
+	}
+	return b.String()
+}
+
+func inline20(parts []Part, refs map[string]string) string {
+	b := strings.Builder{}
+	for _, part := range parts {
+		if part.IsPlaceholder() {
+			fmt.Fprintf(&b, "<ph id=%s dataRef=%s/>", attr(part.ID), attr(refs[part.Code]))
+		} else {
+			b.WriteString(text(part.Text))
+		}
+	}
+	return b.String()
+}
+
+func text(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func attr(s string) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+	return `"` + r.Replace(s) + `"`
+}
+
+// Parse reads a document of either version, the units of all of its files
+// are read in order. Markers around text are dropped, other inline elements
+// are reported since lcl does not write them.
+func Parse(r io.Reader) (*File, error) {
+	file := &File{}
+	decoder := xml.NewDecoder(r)
+
+	malformed := func(err error) error {
+		line, _ := decoder.InputPos()
+		return &errs.ImportError{
+			Err:    errs.ErrMalformedFile,
+			Format: "xliff",
+			Value:  fmt.Sprintf("line %d: %s", line, err),
+		}
+	}
+
+	for {
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, malformed(err)
+		}
+
+		start, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "xliff":
+			file.Version = Version(value(start, "version"))
+			file.SourceLanguage = value(start, "srcLang")
+			file.TargetLanguage = value(start, "trgLang")
+		case "file":
+			file.Original = value(start, "original")
+			if file.Version == Version12 {
+				file.SourceLanguage = value(start, "source-language")
+				file.TargetLanguage = value(start, "target-language")
+			}
+		case "trans-unit":
+			u := &unit12{}
+			if err := decoder.DecodeElement(u, &start); err != nil {
+				return nil, malformed(err)
+			}
+			file.Units = append(file.Units, &Unit{ID: u.ID, Notes: u.Notes, Source: u.Source, Target: u.Target})
+		case "unit":
+			u := &unit20{}
+			if err := decoder.DecodeElement(u, &start); err != nil {
+				return nil, malformed(err)
+			}
+			unit, err := u.unit()
+			if err != nil {
+				return nil, malformed(err)
+			}
+			file.Units = append(file.Units, unit)
+		}
+	}
+
+	if file.Version != Version12 && file.Version != Version20 {
+		return nil, &errs.ImportError{
+			Err:    errs.ErrMalformedFile,
+			Format: "xliff",
+			Value:  fmt.Sprintf("unsupported version '%s'", file.Version),
+		}
+	}
+
+	return file, nil
+}
+
+type unit12 struct {
+	ID     string   `xml:"id,attr"`
+	Source content  `xml:"source"`
+	Target content  `xml:"target"`
+	Notes  []string `xml:"note"`
+}
+
+type unit20 struct {
+	ID    string   `xml:"id,attr"`
+	Notes []string `xml:"notes>note"`
+	Data  []struct {
+		ID    string `xml:"id,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"originalData>data"`
+	// Segments are the segments and the ignorable parts of the unit
+	Segments []struct {
+		XMLName xml.Name
+		Source  content `xml:"source"`
+		Target  content `xml:"target"`
+	} `xml:",any"`
+}
+
+// unit joins the segments of a unit and resolves the data its placeholders
+// refer to, ignorable whitespace without a target is kept as it is
+func (u *unit20) unit() (*Unit, error) {
+	source, target := content{}, content(nil)
+	for _, segment := range u.Segments {
+		if segment.XMLName.Local != "segment" && segment.XMLName.Local != "ignorable" {
+			continue
+		}
+
+		source.add(segment.Source)
+		switch {
+		case segment.Target != nil:
+			if target == nil {
+				target = content{}
+			}
+			target.add(segment.Target)
+		case segment.XMLName.Local == "ignorable" && target != nil:
+			target.add(segment.Source)
+		}
+	}
+	unit := &Unit{ID: u.ID, Notes: u.Notes, Source: source, Target: target}
+
+	data := map[string]string{}
+	for _, d := range u.Data {
+		data[d.ID] = d.Value
+	}
+	resolve := func(parts []Part) error {
+		for i, part := range parts {
+			if !part.IsPlaceholder() || len(part.ref) == 0 {
+				continue
+			}
+			code, ok := data[part.ref]
+			if !ok {
+				return fmt.Errorf("unit %s refers to unknown data '%s'", u.ID, part.ref)
+			}
+			parts[i].Code = code
+			parts[i].ref = ""
+		}
+		return nil
+	}
+
+	if err := resolve(unit.Source); err != nil {
+		return nil, err
+	}
+	if err := resolve(unit.Target); err != nil {
+		return nil, err
+	}
+	return unit, nil
+}
+
+// content is the text of a source or a target with its inline elements, it is
+// nil if the element is missing and empty if the element has no content.
+type content []Part
+
+func (c *content) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*c = content{}
+
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.CharData:
+			c.text(string(t))
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "x", "ph":
+				part := Part{ID: value(t, "id"), Code: value(t, "equiv-text"), ref: value(t, "dataRef")}
+				if len(part.ID) == 0 {
+					return errors.New("placeholder without an id")
+				}
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				*c = append(*c, part)
+			case "mrk":
+				inner := content{}
+				if err := d.DecodeElement(&inner, &t); err != nil {
+					return err
+				}
+				c.add(inner)
+			default:
+				return fmt.Errorf("unsupported inline element <%s>", t.Name.Local)
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// add appends parts, text is joined to the text before it
+func (c *content) add(parts []Part) {
+	for _, part := range parts {
+		if part.IsPlaceholder() {
+			*c = append(*c, part)
+		} else {
+			c.text(part.Text)
+		}
+	}
+}
+
+func (c *content) text(s string) {
+	if len(s) == 0 {
+		return
+	}
+	if n := len(*c); n > 0 && !(*c)[n-1].IsPlaceholder() {
+		(*c)[n-1].Text += s
+		return
+	}
+	*c = append(*c, Part{Text: s})
+}
+
+func value(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+package xliff_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/CanPacis/lcl/analyzer"
+	"github.com/CanPacis/lcl/errs"
+	pkg "github.com/CanPacis/lcl/package"
+	"github.com/CanPacis/lcl/parser"
+	"github.com/CanPacis/lcl/parser/printer"
+	"github.com/CanPacis/lcl/test"
+	"github.com/CanPacis/lcl/xliff"
+	"github.com/stretchr/testify/assert"
+)
+
+const source = `declare shop (en tr)
+
+fn(n:int) plural n == 1 ? "item" : "items"
+
+section cart {
+  # the heading
+  title {
+    en "Your <cart> & more"
+    tr "Sepetiniz"
+  }
+
+  summary(n:int) {
+    en ` + "`{n} {plural(n)} for {n}`" + `
+    tr ` + "`{n} ürün`" + `
+  }
+}
+`
+
+const document12 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file original="shop.lcl" datatype="plaintext" source-language="en" target-language="tr">
+    <body>
+      <trans-unit id="cart.title" xml:space="preserve">
+        <source>Your &lt;cart&gt; &amp; more</source>
+        <target state="translated">Sepetiniz</target>
+        <note>the heading</note>
+      </trans-unit>
+      <trans-unit id="cart.summary" xml:space="preserve">
+        <source><x id="1" equiv-text="{n}"/> <x id="2" equiv-text="{plural(n)}"/> for <x id="3" equiv-text="{n}"/></source>
+        <target state="translated"><x id="1" equiv-text="{n}"/> ürün</target>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+
+const document20 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="2.0" xmlns="urn:oasis:names:tc:xliff:document:2.0" srcLang="en" trgLang="tr">
+  <file id="f1" original="shop.lcl">
+    <unit id="cart.title" xml:space="preserve">
+      <notes>
+        <note>the heading</note>
+      </notes>
+      <segment state="translated">
+        <source>Your &lt;cart&gt; &amp; more</source>
+        <target>Sepetiniz</target>
+      </segment>
+    </unit>
+    <unit id="cart.summary" xml:space="preserve">
+      <originalData>
+        <data id="d1">{n}</data>
+        <data id="d2">{plural(n)}</data>
+      </originalData>
+      <segment state="translated">
+        <source><ph id="1" dataRef="d1"/> <ph id="2" dataRef="d2"/> for <ph id="3" dataRef="d1"/></source>
+        <target><ph id="1" dataRef="d1"/> ürün</target>
+      </segment>
+    </unit>
+  </file>
+</xliff>
+`
+
+func TestGenerate(t *testing.T) {
+	assert := assert.New(t)
+
+	out := test.MustScan(source)
+	files, err := xliff.New(out, xliff.WithOriginal("shop.lcl")).Files()
+	if assert.NoError(err) {
+		assert.Equal(document12, string(files["tr.xlf"]))
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(xliff.New(out, xliff.WithOriginal("shop.lcl"), xliff.WithVersion(xliff.Version20)).Generate(buf, out.Locales[1]))
+	assert.Equal(document20, buf.String())
+}
+
+func TestCodes(t *testing.T) {
+	assert := assert.New(t)
+
+	// templates are written with ' for backticks
+	src := strings.ReplaceAll(`declare codes (en tr)
+
+section s {
+  t(n:int m:i32 name:string?) {
+    en '{(n + 1) * 2} {n + m} {f64(n) / 2} {-(n ^ 2)} {name ?? "you"} {n > 1 ? "many" : "one"}'
+    tr '{n}'
+  }
+}
+`, "'", "`")
+
+	file := parser.NewFile("codes.lcl", bytes.NewBufferString(src))
+	tree := test.MustParse(test.WithFile(file))
+	out, err := analyzer.New(file, tree, pkg.New(tree.Decl.Name.Value)).Scan()
+	if !assert.NoError(err) {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(xliff.New(out).Generate(buf, out.Locales[1]))
+	document, err := xliff.Parse(buf)
+	if !assert.NoError(err) || !assert.Len(document.Units, 1) {
+		return
+	}
+
+	codes := []string{}
+	for _, part := range document.Units[0].Source {
+		if len(part.Code) > 0 {
+			codes = append(codes, part.Code)
+		}
+	}
+	assert.Equal([]string{
+		"{(n + 1) * 2}",
+		"{n + m}",
+		"{f64(n) / 2}",
+		"{-n ^ 2}",
+		`{name ?? "you"}`,
+		`{n > 1 ? "many" : "one"}`,
+	}, codes)
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	summary := &xliff.Unit{
+		ID: "cart.summary",
+		Source: []xliff.Part{
+			{ID: "1", Code: "{n}"},
+			{Text: " "},
+			{ID: "2", Code: "{plural(n)}"},
+			{Text: " for "},
+			{ID: "3", Code: "{n}"},
+		},
+		Target: []xliff.Part{
+			{ID: "1", Code: "{n}"},
+			{Text: " ürün"},
+		},
+	}
+
+	for _, document := range []string{document12, document20} {
+		file, err := xliff.Parse(strings.NewReader(document))
+		if !assert.NoError(err) {
+			continue
+		}
+
+		assert.Equal("en", file.SourceLanguage)
+		assert.Equal("tr", file.TargetLanguage)
+		assert.Equal("shop.lcl", file.Original)
+		if assert.Len(file.Units, 2) {
+			assert.Equal([]string{"the heading"}, file.Units[0].Notes)
+			assert.Equal([]xliff.Part{{Text: "Your <cart> & more"}}, file.Units[0].Source)
+			assert.Equal(summary, file.Units[1])
+		}
+
+		buf := &bytes.Buffer{}
+		assert.NoError(file.Write(buf))
+		assert.Equal(document, buf.String())
+	}
+
+	file, err := xliff.Parse(strings.NewReader(`<xliff version="2.0" srcLang="en" trgLang="tr">
+  <file id="f1">
+    <unit id="a">
+      <segment><source>One.</source><target>Bir.</target></segment>
+      <ignorable><source> </source></ignorable>
+      <segment><source>Two.</source><target><mrk id="m1" translate="no">İki</mrk>.</target></segment>
+    </unit>
+  </file>
+</xliff>`))
+	if assert.NoError(err) && assert.Len(file.Units, 1) {
+		assert.Equal([]xliff.Part{{Text: "One. Two."}}, file.Units[0].Source)
+		assert.Equal([]xliff.Part{{Text: "Bir. İki."}}, file.Units[0].Target)
+	}
+
+	_, err = xliff.Parse(strings.NewReader(`<xliff version="1.2"><file><body>
+<trans-unit id="a"><source>a <g id="1">b</g></source></trans-unit>
+</body></file></xliff>`))
+	assert.ErrorIs(err, errs.ErrMalformedFile)
+	assert.ErrorContains(err, "unsupported inline element <g>")
+
+	_, err = xliff.Parse(strings.NewReader(`<xliff version="2.0"><file id="f1"><unit id="a">
+<segment><source><ph id="1" dataRef="d1"/></source></segment>
+</unit></file></xliff>`))
+	assert.ErrorContains(err, "unit a refers to unknown data 'd1'")
+
+	_, err = xliff.Parse(strings.NewReader(`<xliff version="1.1"></xliff>`))
+	assert.EqualError(err, "import error: malformed file, unsupported version '1.1'")
+}
+
+func TestImport(t *testing.T) {
+	assert := assert.New(t)
+
+	tree, out := test.MustScanFile(test.WithSourceString(source))
+	file, err := xliff.Parse(strings.NewReader(strings.NewReplacer(
+		`<target state="translated">Sepetiniz</target>`,
+		`<target state="translated">Sepet &amp; daha</target>`,
+		`<target state="translated"><x id="1" equiv-text="{n}"/> ürün</target>`,
+		`<target state="translated"><x id="3" equiv-text="{n + 1}"/> için <x id="1"/> <x id="2"/></target>`,
+	).Replace(document12)))
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.NoError(xliff.Import(tree, out.Locales[1], file))
+	printed := printer.String(tree)
+	assert.Contains(printed, "    tr \"Sepet & daha\"\n")
+	assert.Contains(printed, "    tr `{n} için {n} {plural(n)}`\n")
+
+	// Targets the locale already has are skipped even if they drop
+	// placeholders, changed ones have to keep them
+	file, err = xliff.Parse(strings.NewReader(strings.NewReplacer(
+		`<target state="translated">Sepetiniz</target>`,
+		`<target state="translated"><x id="4"/></target>`,
+		`<trans-unit id="cart.summary"`,
+		`<trans-unit id="cart.missing"><source>a</source><target>b</target></trans-unit><trans-unit id="cart.summary"`,
+	).Replace(document12)))
+	if !assert.NoError(err) {
+		return
+	}
+
+	tree, _ = test.MustScanFile(test.WithSourceString(source))
+	assert.NoError(xliff.Import(tree, out.Locales[1], &xliff.File{Units: file.Units[2:]}))
+	assert.Equal(source, printer.String(tree))
+
+	file.Units[2].Target = file.Units[2].Target[1:]
+	err = xliff.Import(tree, out.Locales[1], file)
+	assert.ErrorIs(err, errs.ErrUnknownPlaceholder)
+	assert.ErrorIs(err, errs.ErrUnknownMessage)
+	assert.ErrorIs(err, errs.ErrMissingPlaceholder)
+	assert.EqualError(err, strings.Join([]string{
+		"import error: cart.title (tr), unknown placeholder, 4",
+		"import error: cart.missing (tr), unknown message",
+		"import error: cart.summary (tr), missing placeholder, {n}",
+		"import error: cart.summary (tr), missing placeholder, {plural(n)}",
+		"import error: cart.summary (tr), missing placeholder, {n}",
+	}, "\n"))
+	assert.Equal(source, printer.String(tree))
+}